@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/disbursement"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func getBalance(t *testing.T, mux *http.ServeMux, query string) int {
+	t.Helper()
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/xendit/balance"+query, nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var body struct {
+		Balance int `json:"balance"`
+	}
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	return body.Balance
+}
+
+func TestDisbursementsSpendBalance(t *testing.T) {
+	var received []domain.CallbackPayload
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+	}))
+	defer callbackSrv.Close()
+
+	ledger := balance.NewLedger(balance.Config{
+		Default: map[string]int{balance.AccountCash: 15000, balance.AccountTax: 500},
+		Fee:     1000,
+	})
+	engine := scenario.NewEngine(&scenario.Config{
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "x1", Disbursements: []scenario.Rule{{ExternalID: "ext-fail", Outcome: "fail_until_timeout"}}},
+		},
+	})
+	service := disbursement.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewBalanceHandler(ledger, "user_mock").RegisterRoutes(mux)
+
+	if resp, _ := service.Create(domain.DisbursementRequest{ExternalID: "ext-1", AccountNumber: "y1", Amount: 10000}); resp.Status != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %s", resp.Status)
+	}
+	if got := getBalance(t, mux, ""); got != 4000 {
+		t.Fatalf("expected amount and fee debited to 4000, got %d", got)
+	}
+
+	resp, _ := service.Create(domain.DisbursementRequest{ExternalID: "ext-2", AccountNumber: "y1", Amount: 10000})
+	if resp.Status != "FAILED" || resp.FailureCode != domain.FailureInsufficientBalance {
+		t.Fatalf("expected FAILED with INSUFFICIENT_BALANCE, got %s %s", resp.Status, resp.FailureCode)
+	}
+	if last := received[len(received)-1]; last.FailureCode != domain.FailureInsufficientBalance {
+		t.Fatalf("expected INSUFFICIENT_BALANCE callback, got %+v", last)
+	}
+
+	topUp := httptest.NewRecorder()
+	mux.ServeHTTP(topUp, httptest.NewRequest(http.MethodPost, "/xendit/admin/balance", strings.NewReader(`{"amount":20000}`)))
+	if topUp.Code != http.StatusOK {
+		t.Fatalf("expected 200 top-up, got %d", topUp.Code)
+	}
+	if got := getBalance(t, mux, "?account_type=CASH"); got != 24000 {
+		t.Fatalf("expected 24000 after top-up, got %d", got)
+	}
+
+	if resp, _ := service.Create(domain.DisbursementRequest{ExternalID: "ext-fail", AccountNumber: "x1", Amount: 10000}); resp.Status != "FAILED" {
+		t.Fatalf("expected scenario FAILED, got %s", resp.Status)
+	}
+	if got := getBalance(t, mux, ""); got != 24000 {
+		t.Fatalf("expected failed disbursement credited back, got %d", got)
+	}
+
+	if got := getBalance(t, mux, "?account_type=TAX"); got != 500 {
+		t.Fatalf("expected seeded TAX balance 500, got %d", got)
+	}
+	other := httptest.NewRequest(http.MethodGet, "/xendit/balance?account_type=BOGUS", nil)
+	otherResp := httptest.NewRecorder()
+	mux.ServeHTTP(otherResp, other)
+	if otherResp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown account_type, got %d", otherResp.Code)
+	}
+}
+
+func TestReversalCreditsBalanceBack(t *testing.T) {
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer callbackSrv.Close()
+
+	ledger := balance.NewLedger(balance.Config{Default: map[string]int{balance.AccountCash: 10000}})
+	service := disbursement.NewService(scenario.NewEngine(&scenario.Config{}), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+
+	resp, _ := service.Create(domain.DisbursementRequest{ExternalID: "ext-1", Amount: 4000})
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 6000 {
+		t.Fatalf("expected 6000 after disbursement, got %d", got)
+	}
+	if _, err := service.SendStatus(resp.ID, domain.StatusReversed, ""); err != nil {
+		t.Fatalf("expected reversal callback, got %v", err)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 10000 {
+		t.Fatalf("expected reversal to credit back to 10000, got %d", got)
+	}
+}
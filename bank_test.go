@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/payout"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newBankMux() *http.ServeMux {
+	banks := bank.NewCatalogue(bank.DefaultBanks("ID"))
+	cbClient := newTestClient()
+	mux := http.NewServeMux()
+	httptransport.NewBankHandler(banks).RegisterRoutes(mux)
+	httptransport.NewHandler(newTestService(cbClient), cbClient).WithBanks(banks).RegisterRoutes(mux)
+	payouts := payout.NewService(scenario.NewEngine(nil), cbClient, "user_mock")
+	httptransport.NewPayoutHandler(payouts).WithBanks(banks).RegisterRoutes(mux)
+	return mux
+}
+
+func TestAvailableDisbursementBanks(t *testing.T) {
+	resp := httptest.NewRecorder()
+	newBankMux().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/xendit/available_disbursements_banks", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var banks []bank.Bank
+	if err := json.Unmarshal(resp.Body.Bytes(), &banks); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	found := false
+	for _, b := range banks {
+		if b.Code == "BCA" {
+			found = b.CanDisburse && b.CanNameValidate && b.AmountLimits.Minimum > 0
+		}
+		if b.Country != "ID" {
+			t.Fatalf("expected only Indonesian channels by default, got %+v", b)
+		}
+	}
+	if !found {
+		t.Fatalf("expected BCA in catalogue, got %s", resp.Body.String())
+	}
+
+	if len(bank.DefaultBanks("ID", "PH")) <= len(banks) {
+		t.Fatalf("expected Philippine channels to extend the catalogue")
+	}
+}
+
+func TestBankCodeValidation(t *testing.T) {
+	mux := newBankMux()
+	cases := []struct {
+		path, body, key, code string
+	}{
+		{"/xendit/disbursements", `{"external_id":"ext-1","amount":50000,"bank_code":"NOPE","account_holder_name":"A","account_number":"1"}`, "", "BANK_CODE_NOT_SUPPORTED_ERROR"},
+		{"/xendit/disbursements", `{"external_id":"ext-2","amount":500,"bank_code":"BCA","account_holder_name":"A","account_number":"1"}`, "", "MINIMUM_TRANSFER_LIMIT_ERROR"},
+		{"/xendit/disbursements", `{"external_id":"ext-3","amount":50000000,"bank_code":"OVO","account_holder_name":"A","account_number":"1"}`, "", "MAXIMUM_TRANSFER_LIMIT_ERROR"},
+		{"/xendit/v2/payouts", `{"reference_id":"ref-1","channel_code":"ID_NOPE","channel_properties":{"account_holder_name":"A","account_number":"1"},"amount":50000,"currency":"IDR"}`, "key-1", "CHANNEL_CODE_NOT_SUPPORTED"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		if tc.key != "" {
+			req.Header.Set("Idempotency-key", tc.key)
+		}
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+		if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), tc.code) {
+			t.Fatalf("expected 400 %s for %s, got %d %s", tc.code, tc.body, resp.Code, resp.Body.String())
+		}
+	}
+
+	createDisbursement(t, mux, `{"external_id":"ext-ok","amount":50000,"bank_code":"BCA","account_holder_name":"A","account_number":"1"}`)
+	req := httptest.NewRequest(http.MethodPost, "/xendit/v2/payouts", strings.NewReader(`{"reference_id":"ref-ok","channel_code":"ID_BCA","channel_properties":{"account_holder_name":"A","account_number":"1"},"amount":50000,"currency":"IDR"}`))
+	req.Header.Set("Idempotency-key", "key-ok")
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected ID_BCA payout to be accepted, got %d %s", resp.Code, resp.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/batch"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+const batchRequestBody = `{"reference":"PAYROLL-1","disbursements":[
+	{"external_id":"pay-1","amount":1000,"bank_code":"BCA","bank_account_name":"A","bank_account_number":"acct-1","description":"salary"},
+	{"external_id":"pay-2","amount":2000,"bank_code":"BNI","bank_account_name":"B","bank_account_number":"acct-1","description":"salary"}
+]}`
+
+func newBatchMux(t *testing.T, autoApprove bool, received *[]domain.BatchCallbackPayload) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.BatchCallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	engine := scenario.NewEngine(&scenario.Config{
+		Batches: []scenario.BatchScenario{
+			{TopupID: "PAYROLL-1", AccountNumber: "acct-1", Disbursements: []scenario.Rule{{Outcome: "success"}, {Outcome: "fail_until_timeout"}}},
+		},
+	})
+	service := batch.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithAutoApprove(autoApprove)
+	mux := http.NewServeMux()
+	httptransport.NewBatchHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestBatchDisbursementUsesBatchRulesByReference(t *testing.T) {
+	var received []domain.BatchCallbackPayload
+	mux := newBatchMux(t, true, &received)
+
+	req := httptest.NewRequest(http.MethodPost, "/xendit/batch_disbursements", strings.NewReader(batchRequestBody))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var created domain.BatchDisbursementResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if created.Status != domain.BatchStatusNeedsApproval || created.TotalUploadedCount != 2 || created.TotalUploadedAmount != 3000 {
+		t.Fatalf("unexpected create response %+v", created)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected one batch callback, got %d", len(received))
+	}
+	payload := received[0]
+	if payload.Status != domain.BatchStatusPartiallyCompleted {
+		t.Fatalf("expected PARTIALLY_COMPLETED, got %s", payload.Status)
+	}
+	if payload.Disbursements[0].Status != "COMPLETED" || payload.Disbursements[1].Status != "FAILED" {
+		t.Fatalf("expected per-item outcomes from batch rules, got %+v", payload.Disbursements)
+	}
+	if payload.TotalDisbursedAmount != 1000 || payload.TotalErrorAmount != 2000 {
+		t.Fatalf("unexpected totals %+v", payload)
+	}
+}
+
+func TestBatchDisbursementManualApproval(t *testing.T) {
+	var received []domain.BatchCallbackPayload
+	mux := newBatchMux(t, false, &received)
+
+	req := httptest.NewRequest(http.MethodPost, "/xendit/batch_disbursements", strings.NewReader(batchRequestBody))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	var created domain.BatchDisbursementResponse
+	_ = json.Unmarshal(resp.Body.Bytes(), &created)
+	if len(received) != 0 {
+		t.Fatalf("expected no callback before approval, got %d", len(received))
+	}
+
+	approveReq := httptest.NewRequest(http.MethodPost, "/xendit/admin/batch_disbursements/"+created.ID+"/approve", nil)
+	approveResp := httptest.NewRecorder()
+	mux.ServeHTTP(approveResp, approveReq)
+	if approveResp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", approveResp.Code)
+	}
+	if len(received) != 1 || received[0].ApprovedAt == "" {
+		t.Fatalf("expected approved batch callback, got %+v", received)
+	}
+
+	approveResp = httptest.NewRecorder()
+	mux.ServeHTTP(approveResp, httptest.NewRequest(http.MethodPost, "/xendit/admin/batch_disbursements/"+created.ID+"/approve", nil))
+	if approveResp.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for second approval, got %d", approveResp.Code)
+	}
+}
+
+func TestBatchDisbursementRejectsDuplicateReference(t *testing.T) {
+	var received []domain.BatchCallbackPayload
+	mux := newBatchMux(t, false, &received)
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/xendit/batch_disbursements", strings.NewReader(batchRequestBody)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var created domain.BatchDisbursementResponse
+	_ = json.Unmarshal(resp.Body.Bytes(), &created)
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/xendit/batch_disbursements", strings.NewReader(batchRequestBody)))
+	if resp.Code != http.StatusConflict || !strings.Contains(resp.Body.String(), "DUPLICATE_ERROR") {
+		t.Fatalf("expected 409 DUPLICATE_ERROR for a reused reference, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/xendit/admin/batch_disbursements/"+created.ID, nil))
+	var stored domain.BatchCallbackPayload
+	_ = json.Unmarshal(resp.Body.Bytes(), &stored)
+	if stored.Status != domain.BatchStatusNeedsApproval {
+		t.Fatalf("expected the first batch to be left alone, got %s", resp.Body.String())
+	}
+}
+
+func TestBatchDisbursementValidation(t *testing.T) {
+	var received []domain.BatchCallbackPayload
+	mux := newBatchMux(t, true, &received)
+
+	req := httptest.NewRequest(http.MethodPost, "/xendit/batch_disbursements", strings.NewReader(`{"reference":"PAYROLL-1","disbursements":[]}`))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), "API_VALIDATION_ERROR") {
+		t.Fatalf("expected Xendit validation error, got %s", resp.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/card"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newCardMux(t *testing.T, ledger *balance.Ledger, received *[]domain.CardCharge) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CardCharge
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := card.NewService(callback.NewClient(callbackSrv.URL, "", nil), "user_mock").
+		WithBaseURL("http://mock.test").
+		WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewCardHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func cardToken(t *testing.T, mux *http.ServeMux, body string) domain.CardToken {
+	t.Helper()
+	code, resp := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_tokens", body)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 tokenizing, got %d: %s", code, resp)
+	}
+	var token domain.CardToken
+	_ = json.Unmarshal(resp, &token)
+	return token
+}
+
+func TestCardThreeDSCharge(t *testing.T) {
+	var received []domain.CardCharge
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newCardMux(t, ledger, &received)
+
+	token := cardToken(t, mux, `{"card_number":"4000000000001091","card_exp_month":"12","card_exp_year":"2099","card_cvn":"123","amount":75000}`)
+	if token.Status != domain.CardTokenInReview || token.PayerAuthenticationURL != "http://mock.test"+domain.CardThreeDSPath+token.AuthenticationID {
+		t.Fatalf("expected an IN_REVIEW token with a 3DS URL, got %+v", token)
+	}
+
+	charge := `{"token_id":"` + token.ID + `","external_id":"order-1","amount":75000,"authentication_id":"` + token.AuthenticationID + `"}`
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", charge); code != http.StatusBadRequest || !strings.Contains(string(body), "AUTHENTICATION_ID_MISSING_ERROR") {
+		t.Fatalf("expected 400 charging before 3DS, got %d: %s", code, body)
+	}
+
+	if code, body := doRequest(t, mux, http.MethodGet, domain.CardThreeDSPath+token.AuthenticationID, ""); code != http.StatusOK || !strings.Contains(string(body), "/complete") {
+		t.Fatalf("expected the 3DS page, got %d: %s", code, body)
+	}
+	if code, body := doRequest(t, mux, http.MethodPost, domain.CardThreeDSPath+token.AuthenticationID+"/complete", ""); code != http.StatusOK || !strings.Contains(string(body), domain.CardTokenVerified) {
+		t.Fatalf("expected the authentication VERIFIED, got %d: %s", code, body)
+	}
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", charge)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 charging, got %d: %s", code, body)
+	}
+	var captured domain.CardCharge
+	_ = json.Unmarshal(body, &captured)
+	if captured.Status != domain.CardChargeCaptured || captured.CardBrand != "VISA" || captured.MaskedCardNumber != "400000XXXXXX1091" {
+		t.Fatalf("unexpected charge %s", body)
+	}
+	if len(received) != 1 || received[0].ID != captured.ID || received[0].Status != domain.CardChargeCaptured {
+		t.Fatalf("expected a CAPTURED charge callback, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 75000 {
+		t.Fatalf("expected the charge credited to CASH, got %d", got)
+	}
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", charge); code != http.StatusBadRequest || !strings.Contains(string(body), "TOKEN_ALREADY_USED_ERROR") {
+		t.Fatalf("expected 400 reusing a single use token, got %d: %s", code, body)
+	}
+}
+
+func TestCardTestNumbersAndCapture(t *testing.T) {
+	var received []domain.CardCharge
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newCardMux(t, ledger, &received)
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_tokens", `{"card_number":"4000000000000001","card_exp_month":"12","card_exp_year":"2099","is_multiple_use":true}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a number failing the Luhn check, got %d", code)
+	}
+
+	declined := cardToken(t, mux, `{"card_number":"4000000000000069","card_exp_month":"12","card_exp_year":"2099","is_multiple_use":true}`)
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", `{"token_id":"`+declined.ID+`","external_id":"order-2","amount":10000}`)
+	var failed domain.CardCharge
+	_ = json.Unmarshal(body, &failed)
+	if failed.Status != domain.CardChargeFailed || failed.FailureReason != domain.CardFailureInsufficient {
+		t.Fatalf("expected a charge FAILED for insufficient balance, got %s", body)
+	}
+
+	token := cardToken(t, mux, `{"card_number":"5200000000000007","card_exp_month":"12","card_exp_year":"2099","is_multiple_use":true}`)
+	_, body = doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", `{"token_id":"`+token.ID+`","external_id":"order-3","amount":20000,"capture":false}`)
+	var authorized domain.CardCharge
+	_ = json.Unmarshal(body, &authorized)
+	if authorized.Status != domain.CardChargeAuthorized {
+		t.Fatalf("expected an AUTHORIZED charge, got %s", body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges/"+authorized.ID+"/capture", `{"amount":25000}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 capturing more than authorized, got %d", code)
+	}
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges/"+authorized.ID+"/capture", `{"amount":15000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 capturing, got %d: %s", code, body)
+	}
+	if last := received[len(received)-1]; last.Status != domain.CardChargeCaptured || last.CaptureAmount != 15000 {
+		t.Fatalf("expected a CAPTURED callback for 15000, got %+v", last)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 15000 {
+		t.Fatalf("expected only the captured amount in CASH, got %d", got)
+	}
+}
+
+func TestCardAuthenticationIsForOneChargeOfItsAmount(t *testing.T) {
+	var received []domain.CardCharge
+	mux := newCardMux(t, nil, &received)
+
+	token := cardToken(t, mux, `{"card_number":"4000000000001091","card_exp_month":"12","card_exp_year":"2099","is_multiple_use":true}`)
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_tokens/"+token.ID+"/authentications", `{"amount":50000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 authenticating, got %d: %s", code, body)
+	}
+	var auth domain.CardAuthentication
+	_ = json.Unmarshal(body, &auth)
+	if code, body := doRequest(t, mux, http.MethodPost, domain.CardThreeDSPath+auth.ID+"/complete", ""); code != http.StatusOK {
+		t.Fatalf("expected 200 completing 3DS, got %d: %s", code, body)
+	}
+
+	charge := func(amount string) (int, []byte) {
+		return doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", `{"token_id":"`+token.ID+`","external_id":"order-4","amount":`+amount+`,"authentication_id":"`+auth.ID+`"}`)
+	}
+	if code, body := charge("60000"); code != http.StatusBadRequest || !strings.Contains(string(body), "API_VALIDATION_ERROR") {
+		t.Fatalf("expected 400 charging another amount than authenticated, got %d: %s", code, body)
+	}
+	if code, body := charge("50000"); code != http.StatusOK {
+		t.Fatalf("expected 200 charging the authenticated amount, got %d: %s", code, body)
+	}
+	if code, body := charge("50000"); code != http.StatusBadRequest || !strings.Contains(string(body), "already been used") {
+		t.Fatalf("expected 400 reusing the authentication, got %d: %s", code, body)
+	}
+}
+
+func TestCardChargeAnswersWhenTheCallbackFails(t *testing.T) {
+	service := card.NewService(callback.NewClient("", "", nil), "user_mock")
+	mux := http.NewServeMux()
+	httptransport.NewCardHandler(service).RegisterRoutes(mux)
+
+	token := cardToken(t, mux, `{"card_number":"5200000000000007","card_exp_month":"12","card_exp_year":"2099","is_multiple_use":true}`)
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/credit_card_charges", `{"token_id":"`+token.ID+`","external_id":"order-5","amount":10000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 when only the callback fails, got %d: %s", code, body)
+	}
+}
@@ -3,8 +3,13 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/callback"
 	"xendit-api-mock/internal/scenario"
 )
 
@@ -15,6 +20,36 @@ func getenv(key, fallback string) string {
 	return fallback
 }
 
+func getenvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("[getenvInt] invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getenvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("[getenvFloat] invalid %s=%q, using %g", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getenvMillis(key string, fallback time.Duration) time.Duration {
+	return time.Duration(getenvInt(key, int(fallback/time.Millisecond))) * time.Millisecond
+}
+
 func loadDotEnv(path string) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -54,3 +89,70 @@ func loadScenario(path string) *scenario.Config {
 
 	return cfg
 }
+
+func loadRetryPolicy() callback.RetryPolicy {
+	statuses, err := callback.ParseStatusRanges(getenv("CALLBACK_RETRY_STATUSES", ""))
+	if err != nil {
+		log.Printf("[loadRetryPolicy] invalid CALLBACK_RETRY_STATUSES: %v", err)
+		statuses = nil
+	}
+	return callback.RetryPolicy{
+		MaxAttempts:     getenvInt("CALLBACK_RETRY_ATTEMPTS", 4),
+		InitialBackoff:  getenvMillis("CALLBACK_RETRY_BACKOFF_MS", time.Second),
+		MaxBackoff:      getenvMillis("CALLBACK_RETRY_MAX_BACKOFF_MS", 30*time.Second),
+		Multiplier:      getenvFloat("CALLBACK_RETRY_MULTIPLIER", 2),
+		Jitter:          getenvFloat("CALLBACK_RETRY_JITTER", 0.2),
+		FailureStatuses: statuses,
+	}
+}
+
+func loadCallbackRoutes(path string) []callback.Route {
+	if path == "" {
+		return nil
+	}
+
+	table, err := callback.LoadRoutingTable(path)
+	if err != nil {
+		log.Printf("[loadCallbackRoutes] failed to load callback routes file: %v", err)
+		return nil
+	}
+
+	return table.Routes
+}
+
+func loadBalanceConfig(path string) balance.Config {
+	cfg := balance.Config{
+		Default: map[string]int{balance.AccountCash: getenvInt("BALANCE_INITIAL_CASH", 1000000000)},
+		Fee:     getenvInt("DISBURSEMENT_FEE", 0),
+	}
+	if path == "" {
+		return cfg
+	}
+
+	loaded, err := balance.LoadConfig(path)
+	if err != nil {
+		log.Printf("[loadBalanceConfig] failed to load balance file: %v", err)
+		return cfg
+	}
+	if loaded.Default == nil {
+		loaded.Default = cfg.Default
+	}
+	if loaded.Fee == 0 {
+		loaded.Fee = cfg.Fee
+	}
+	return loaded
+}
+
+func loadBanks(path string) []bank.Bank {
+	defaults := bank.DefaultBanks(strings.Split(getenv("BANK_COUNTRIES", "ID"), ",")...)
+	if path == "" {
+		return defaults
+	}
+
+	banks, err := bank.LoadBanks(path)
+	if err != nil {
+		log.Printf("[loadBanks] failed to load banks file: %v", err)
+		return defaults
+	}
+	return banks
+}
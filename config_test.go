@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestGetenv(t *testing.T) {
@@ -16,6 +17,20 @@ func TestGetenv(t *testing.T) {
 	}
 }
 
+func TestGetenvInt(t *testing.T) {
+	t.Setenv("TEST_INT", "7")
+	if got := getenvInt("TEST_INT", 1); got != 7 {
+		t.Fatalf("expected 7, got %d", got)
+	}
+	t.Setenv("TEST_INT", "seven")
+	if got := getenvInt("TEST_INT", 1); got != 1 {
+		t.Fatalf("expected fallback for invalid value, got %d", got)
+	}
+	if got := getenvMillis("MISSING_MS", 2*time.Second); got != 2*time.Second {
+		t.Fatalf("expected fallback duration, got %s", got)
+	}
+}
+
 func TestLoadDotEnvSetsValues(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, ".env")
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/customer"
+	"xendit-api-mock/internal/service/paymentrequest"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newCustomerMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	customerService := customer.NewService("user_mock")
+	paymentRequestService := paymentrequest.NewService(scenario.NewEngine(nil), callback.NewClient("http://127.0.0.1:0", "", nil), "user_mock").
+		WithCustomers(customerService)
+	mux := http.NewServeMux()
+	httptransport.NewCustomerHandler(customerService).RegisterRoutes(mux)
+	httptransport.NewPaymentRequestHandler(paymentRequestService).RegisterRoutes(mux)
+	return mux
+}
+
+func TestCustomerLifecycle(t *testing.T) {
+	mux := newCustomerMux(t)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/customers", `{"reference_id":"user-1","type":"INDIVIDUAL","individual_detail":{"given_names":"Budi"},"mobile_number":"+628123456789"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", code, body)
+	}
+	var created domain.Customer
+	_ = json.Unmarshal(body, &created)
+	if created.ID == "" || created.IndividualDetail == nil || created.IndividualDetail.GivenNames != "Budi" {
+		t.Fatalf("unexpected customer %s", body)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/customers", `{"reference_id":"user-1","type":"INDIVIDUAL","individual_detail":{"given_names":"Budi"}}`); code != http.StatusConflict {
+		t.Fatalf("expected 409 for a reused reference_id, got %d", code)
+	}
+	for _, invalid := range []string{
+		`{"reference_id":"user-2","type":"INDIVIDUAL"}`,
+		`{"reference_id":"user-2","type":"BUSINESS","business_detail":{"business_name":"Toko"},"email":"not-an-email"}`,
+		`{"reference_id":"user-2","type":"INDIVIDUAL","individual_detail":{"given_names":"Ani"},"mobile_number":"08123"}`,
+	} {
+		if code, body := doRequest(t, mux, http.MethodPost, "/xendit/customers", invalid); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d: %s", invalid, code, body)
+		}
+	}
+
+	code, body = doRequest(t, mux, http.MethodPatch, "/xendit/customers/"+created.ID, `{"email":"budi@example.com"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 updating, got %d: %s", code, body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPatch, "/xendit/customers/"+created.ID, `{"business_detail":{"business_name":"Toko"}}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 setting business_detail on an individual, got %d", code)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/customers?reference_id=user-1", "")
+	var found struct {
+		Data []domain.Customer `json:"data"`
+	}
+	_ = json.Unmarshal(body, &found)
+	if len(found.Data) != 1 || found.Data[0].Email != "budi@example.com" {
+		t.Fatalf("expected the updated customer, got %s", body)
+	}
+}
+
+func TestCustomerReferencesMustExist(t *testing.T) {
+	mux := newCustomerMux(t)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_methods", `{"type":"EWALLET","reusability":"MULTIPLE_USE","customer_id":"cust-missing","ewallet":{"channel_code":"OVO"}}`)
+	var apiErr struct {
+		ErrorCode string `json:"error_code"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	if code != http.StatusNotFound || apiErr.ErrorCode != "DATA_NOT_FOUND" {
+		t.Fatalf("expected 404 DATA_NOT_FOUND for an unknown customer, got %d: %s", code, body)
+	}
+
+	_, body = doRequest(t, mux, http.MethodPost, "/xendit/customers", `{"reference_id":"user-1","type":"BUSINESS","business_detail":{"business_name":"Toko"}}`)
+	var created domain.Customer
+	_ = json.Unmarshal(body, &created)
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_methods", `{"type":"EWALLET","reusability":"MULTIPLE_USE","customer_id":"`+created.ID+`","ewallet":{"channel_code":"OVO"}}`); code != http.StatusCreated {
+		t.Fatalf("expected 201 for a known customer, got %d: %s", code, body)
+	}
+}
+
+func TestCustomersAreScopedToTheirBusiness(t *testing.T) {
+	mux := newCustomerMux(t)
+	asSubAccount := func(method, path, body string) (int, []byte) {
+		return doRequestWithHeader(t, mux, method, path, body, http.Header{"For-User-Id": {"sub-account-1"}})
+	}
+
+	_, body := asSubAccount(http.MethodPost, "/xendit/customers", `{"reference_id":"user-1","type":"INDIVIDUAL","individual_detail":{"given_names":"Budi"}}`)
+	var created domain.Customer
+	_ = json.Unmarshal(body, &created)
+
+	var found struct {
+		Data []domain.Customer `json:"data"`
+	}
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/customers?reference_id=user-1", "")
+	_ = json.Unmarshal(body, &found)
+	if len(found.Data) != 0 {
+		t.Fatalf("expected no customers for the main account, got %s", body)
+	}
+	_, body = asSubAccount(http.MethodGet, "/xendit/customers?reference_id=user-1", "")
+	_ = json.Unmarshal(body, &found)
+	if len(found.Data) != 1 || found.Data[0].ID != created.ID {
+		t.Fatalf("expected the sub-account's customer, got %s", body)
+	}
+
+	var apiErr struct {
+		ErrorCode string `json:"error_code"`
+	}
+	code, body := doRequest(t, mux, http.MethodGet, "/xendit/customers/"+created.ID, "")
+	_ = json.Unmarshal(body, &apiErr)
+	if code != http.StatusNotFound || apiErr.ErrorCode != "DATA_NOT_FOUND" {
+		t.Fatalf("expected 404 DATA_NOT_FOUND getting another business's customer, got %d: %s", code, body)
+	}
+	if code, body := doRequest(t, mux, http.MethodPatch, "/xendit/customers/"+created.ID, `{"email":"budi@example.com"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 updating another business's customer, got %d: %s", code, body)
+	}
+	if code, body := asSubAccount(http.MethodGet, "/xendit/customers/"+created.ID, ""); code != http.StatusOK {
+		t.Fatalf("expected the sub-account to get its customer, got %d: %s", code, body)
+	}
+	if code, body := asSubAccount(http.MethodPatch, "/xendit/customers/"+created.ID, `{"email":"budi@example.com"}`); code != http.StatusOK {
+		t.Fatalf("expected the sub-account to update its customer, got %d: %s", code, body)
+	}
+
+	method := `{"type":"EWALLET","reusability":"MULTIPLE_USE","customer_id":"` + created.ID + `","ewallet":{"channel_code":"OVO"}}`
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_methods", method); code != http.StatusNotFound {
+		t.Fatalf("expected 404 using another business's customer, got %d: %s", code, body)
+	}
+	if code, body := asSubAccount(http.MethodPost, "/xendit/payment_methods", method); code != http.StatusCreated {
+		t.Fatalf("expected 201 using the business's own customer, got %d: %s", code, body)
+	}
+}
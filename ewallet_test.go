@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/ewallet"
+	"xendit-api-mock/internal/service/refund"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newEWalletMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]domain.EWalletWebhook) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.EWalletWebhook
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	engine := scenario.NewEngine(cfg)
+	cb := callback.NewClient(callbackSrv.URL, "", nil)
+	service := ewallet.NewService(engine, cb, "user_mock").
+		WithBaseURL("http://mock.test").
+		WithLedger(ledger)
+	refunds := refund.NewService(engine, cb, "user_mock").WithEWallets(service).WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewEWalletHandler(service).WithRefunds(refunds).RegisterRoutes(mux)
+	httptransport.NewRefundHandler(refunds).RegisterRoutes(mux)
+	return mux
+}
+
+func TestEWalletRedirectCheckout(t *testing.T) {
+	var received []domain.EWalletWebhook
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newEWalletMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges", `{"reference_id":"order-1","currency":"IDR","amount":30000,"checkout_method":"ONE_TIME_PAYMENT","channel_code":"ID_DANA","channel_properties":{"success_redirect_url":"https://shop.test/success"}}`)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", code, body)
+	}
+	var charge domain.EWalletCharge
+	_ = json.Unmarshal(body, &charge)
+	checkoutURL := "http://mock.test" + domain.EWalletCheckoutPath + charge.ID
+	if charge.Status != domain.EWalletStatusPending || !charge.IsRedirectRequired || charge.Actions == nil || charge.Actions.DesktopWebCheckoutURL != checkoutURL {
+		t.Fatalf("unexpected charge %s", body)
+	}
+
+	code, body = doRequest(t, mux, http.MethodGet, domain.EWalletCheckoutPath+charge.ID, "")
+	if code != http.StatusOK || !strings.Contains(string(body), "/approve") {
+		t.Fatalf("expected checkout page, got %d: %s", code, body)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, domain.EWalletCheckoutPath+charge.ID+"/approve", nil))
+	if resp.Code != http.StatusSeeOther || resp.Header().Get("Location") != "https://shop.test/success" {
+		t.Fatalf("expected redirect to success URL, got %d %s", resp.Code, resp.Header().Get("Location"))
+	}
+	if len(received) != 1 || received[0].Event != domain.EWalletEventCapture || received[0].Data.Status != domain.EWalletStatusSucceeded {
+		t.Fatalf("expected SUCCEEDED capture callback, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 30000 {
+		t.Fatalf("expected capture credited to CASH, got %d", got)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/refunds", `{"amount":10000,"reason":"REQUESTED_BY_CUSTOMER"}`)
+	var legacy domain.EWalletRefund
+	_ = json.Unmarshal(body, &legacy)
+	if code != http.StatusOK || legacy.RefundAmount != 10000 || legacy.Status != domain.RefundStatusSucceeded {
+		t.Fatalf("expected partial refund, got %d: %s", code, body)
+	}
+	if last := received[len(received)-1]; last.Event != domain.RefundEventSucceeded {
+		t.Fatalf("expected refund.succeeded webhook, got %+v", last)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 20000 {
+		t.Fatalf("expected refund debited from CASH, got %d", got)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/refunds", `{"amount":50000}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 refunding more than captured, got %d", code)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/refunds", `{"amount":1000,"reason":"BORED"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown reason, got %d", code)
+	}
+	code, body = doRequestWithHeader(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/refunds", `{"amount":1000}`, http.Header{"For-User-Id": {"sub-account-1"}})
+	if code != http.StatusNotFound || !strings.Contains(string(body), "DATA_NOT_FOUND") {
+		t.Fatalf("expected 404 DATA_NOT_FOUND refunding another business's charge, got %d: %s", code, body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/void", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 voiding a refunded charge, got %d", code)
+	}
+	doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges/"+charge.ID+"/refunds", "")
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/ewallets/charges/"+charge.ID, "")
+	_ = json.Unmarshal(body, &charge)
+	if charge.Status != domain.EWalletStatusRefunded || charge.RefundedAmount != 30000 {
+		t.Fatalf("expected fully refunded charge, got %s", body)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/refunds?ewallet_charge_id="+charge.ID, "")
+	var list struct {
+		Data []domain.Refund `json:"data"`
+	}
+	_ = json.Unmarshal(body, &list)
+	if len(list.Data) != 2 || list.Data[0].EWalletChargeID != charge.ID {
+		t.Fatalf("expected both refunds listed under /refunds, got %s", body)
+	}
+}
+
+func TestEWalletScenarioRules(t *testing.T) {
+	var received []domain.EWalletWebhook
+	mux := newEWalletMux(t, &scenario.Config{
+		EWallets: []scenario.EWalletRule{
+			{ChannelCode: "ID_OVO", Outcome: scenario.EWalletFail, FailureCode: "USER_UNREACHABLE"},
+			{Outcome: scenario.EWalletSucceed},
+		},
+	}, nil, &received)
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges", `{"reference_id":"ovo-1","currency":"IDR","amount":1000,"checkout_method":"ONE_TIME_PAYMENT","channel_code":"ID_OVO","channel_properties":{"mobile_number":"+628123456789"}}`)
+	var charge domain.EWalletCharge
+	_ = json.Unmarshal(body, &charge)
+	if charge.IsRedirectRequired || charge.Actions != nil {
+		t.Fatalf("expected OVO push flow without actions, got %s", body)
+	}
+	if len(received) != 1 || received[0].Data.Status != domain.EWalletStatusFailed || received[0].Data.FailureCode != "USER_UNREACHABLE" {
+		t.Fatalf("expected FAILED callback, got %+v", received)
+	}
+
+	doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges", `{"reference_id":"token-1","currency":"IDR","amount":1000,"checkout_method":"TOKENIZED_PAYMENT","channel_code":"ID_SHOPEEPAY","payment_method_id":"pm-1"}`)
+	if len(received) != 2 || received[1].Data.Status != domain.EWalletStatusSucceeded {
+		t.Fatalf("expected SUCCEEDED callback from the fallback rule, got %+v", received)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges", `{"reference_id":"x","currency":"IDR","amount":1000,"checkout_method":"ONE_TIME_PAYMENT","channel_code":"ID_OVO"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without mobile_number, got %d", code)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/ewallets/charges", `{"reference_id":"x","currency":"IDR","amount":1000,"checkout_method":"ONE_TIME_PAYMENT","channel_code":"ID_GOPAY"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported channel, got %d", code)
+	}
+}
@@ -14,17 +14,52 @@ import (
 	"xendit-api-mock/internal/domain"
 	"xendit-api-mock/internal/scenario"
 	"xendit-api-mock/internal/service/disbursement"
+	"xendit-api-mock/internal/sink"
+	"xendit-api-mock/internal/snapshot"
 	httptransport "xendit-api-mock/internal/transport/http"
 )
 
-func newTestHandler() *httptransport.Handler {
+func newTestClient() *callback.Client {
+	return callback.NewClient(getenv("CALLBACK_URL", ""), getenv("CALLBACK_TOKEN", ""), nil)
+}
+
+func newTestService(cbClient *callback.Client) *disbursement.Service {
 	engine := scenario.NewEngine(nil)
-	callbackURL := getenv("CALLBACK_URL", "")
-	callbackToken := getenv("CALLBACK_TOKEN", "")
 	userID := getenv("XENDIT_USER_ID", "user_mock")
-	cbClient := callback.NewClient(callbackURL, callbackToken, nil)
-	service := disbursement.NewService(engine, cbClient, userID)
-	return httptransport.NewHandler(service, callbackURL)
+	return disbursement.NewService(engine, cbClient, userID)
+}
+
+func newTestHandler() *httptransport.Handler {
+	cbClient := newTestClient()
+	return httptransport.NewHandler(newTestService(cbClient), cbClient)
+}
+
+func newTestAdminMux() *http.ServeMux {
+	cbClient := newTestClient()
+	service := newTestService(cbClient)
+	snapshots := snapshot.NewRegistry()
+	snapshots.Register("disbursement", service)
+	snapshots.Register("callbacks", cbClient.History())
+	snapshots.Register("callback_routes", cbClient.Router())
+	mux := http.NewServeMux()
+	httptransport.NewHandler(service, cbClient).RegisterRoutes(mux)
+	httptransport.NewAdminHandler(snapshots, cbClient, getenv("XENDIT_USER_ID", "user_mock")).RegisterRoutes(mux)
+	return mux
+}
+
+func createDisbursement(t *testing.T, mux *http.ServeMux, body string) domain.DisbursementResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/xendit/disbursements", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var payload domain.DisbursementResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	return payload
 }
 
 func TestNewHandler(t *testing.T) {
@@ -136,6 +171,69 @@ func TestHandleCallbackHealthSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleCallbackHealthSendsSignedPayload(t *testing.T) {
+	var payload domain.CallbackPayload
+	var token string
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		token = r.Header.Get("X-Callback-Token")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer callback.Close()
+
+	t.Setenv("CALLBACK_URL", callback.URL)
+	t.Setenv("CALLBACK_TOKEN", "token123")
+	mux := http.NewServeMux()
+	newTestHandler().RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/xendit/healthz-callback", nil)
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	if token != "token123" {
+		t.Fatalf("expected callback token, got %q", token)
+	}
+	if payload.ID == "" || payload.Status != "COMPLETED" || payload.WebhookID == "" {
+		t.Fatalf("expected well-formed callback payload, got %+v", payload)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if body["status_code"] != float64(200) || body["response_body"] != `{"ok":true}` {
+		t.Fatalf("expected receiver status and body in response, got %v", body)
+	}
+	if _, ok := body["latency_ms"]; !ok {
+		t.Fatalf("expected latency_ms in response, got %v", body)
+	}
+}
+
+func TestHandleCallbackHealthRejected(t *testing.T) {
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer callback.Close()
+
+	t.Setenv("CALLBACK_URL", callback.URL)
+	mux := http.NewServeMux()
+	newTestHandler().RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/xendit/healthz-callback", nil)
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if body["status_code"] != float64(http.StatusUnauthorized) {
+		t.Fatalf("expected receiver status 401, got %v", body["status_code"])
+	}
+}
+
 func TestHandleCreateDisbursementInvalidJSON(t *testing.T) {
 	mux := http.NewServeMux()
 	newTestHandler().RegisterRoutes(mux)
@@ -294,3 +392,262 @@ func TestShortHash(t *testing.T) {
 		t.Fatalf("expected 8-char hash")
 	}
 }
+
+func TestSnapshotExportAndRestore(t *testing.T) {
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callbackSrv.Close()
+
+	t.Setenv("CALLBACK_URL", callbackSrv.URL)
+	mux := newTestAdminMux()
+
+	if got := createDisbursement(t, mux, `{"external_id":"ext-1"}`); got.Status != "FAILED" {
+		t.Fatalf("expected FAILED on first attempt, got %s", got.Status)
+	}
+
+	exportReq := httptest.NewRequest(http.MethodGet, "/xendit/admin/snapshot", nil)
+	exportResp := httptest.NewRecorder()
+	mux.ServeHTTP(exportResp, exportReq)
+	if exportResp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", exportResp.Code)
+	}
+	var snap snapshot.Snapshot
+	if err := json.Unmarshal(exportResp.Body.Bytes(), &snap); err != nil {
+		t.Fatalf("expected json snapshot, got %v", err)
+	}
+	if _, ok := snap.State["disbursement"]; !ok {
+		t.Fatalf("expected disbursement section, got %v", snap.State)
+	}
+
+	resetReq := httptest.NewRequest(http.MethodPost, "/xendit/reset", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), resetReq)
+
+	importReq := httptest.NewRequest(http.MethodPost, "/xendit/admin/snapshot", bytes.NewReader(exportResp.Body.Bytes()))
+	importResp := httptest.NewRecorder()
+	mux.ServeHTTP(importResp, importReq)
+	if importResp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", importResp.Code)
+	}
+
+	if got := createDisbursement(t, mux, `{"external_id":"ext-2"}`); got.Status != "COMPLETED" {
+		t.Fatalf("expected restored state to skip first failure, got %s", got.Status)
+	}
+}
+
+func TestSnapshotImportRejectsUnknownSection(t *testing.T) {
+	mux := newTestAdminMux()
+	body := `{"version":1,"state":{"nope":{}}}`
+	req := httptest.NewRequest(http.MethodPost, "/xendit/admin/snapshot", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+}
+
+func TestDeadLettersAfterFailedCallback(t *testing.T) {
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer callbackSrv.Close()
+
+	t.Setenv("CALLBACK_URL", callbackSrv.URL)
+	mux := newTestAdminMux()
+	createDisbursement(t, mux, `{"external_id":"ext-dead"}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/xendit/admin/callbacks/dead-letters", nil)
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var body struct {
+		Data []callback.Delivery `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0].ExternalID != "ext-dead" {
+		t.Fatalf("expected one dead letter for ext-dead, got %+v", body.Data)
+	}
+	if got := body.Data[0].Attempts[0].StatusCode; got != http.StatusInternalServerError {
+		t.Fatalf("expected recorded status 500, got %d", got)
+	}
+}
+
+func TestListAndResendCallbacks(t *testing.T) {
+	var received []domain.CallbackPayload
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callbackSrv.Close()
+
+	t.Setenv("CALLBACK_URL", callbackSrv.URL)
+	mux := newTestAdminMux()
+	created := createDisbursement(t, mux, `{"external_id":"ext-resend"}`)
+
+	listReq := httptest.NewRequest(http.MethodGet, "/xendit/admin/callbacks?id="+created.ID, nil)
+	listResp := httptest.NewRecorder()
+	mux.ServeHTTP(listResp, listReq)
+	var list struct {
+		Data []callback.Delivery `json:"data"`
+	}
+	if err := json.Unmarshal(listResp.Body.Bytes(), &list); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].WebhookID == "" || list.Data[0].LastResult == nil {
+		t.Fatalf("expected one recorded delivery with webhook id and result, got %+v", list.Data)
+	}
+
+	resendReq := httptest.NewRequest(http.MethodPost, "/xendit/admin/callbacks/"+list.Data[0].DeliveryID+"/resend", nil)
+	resendResp := httptest.NewRecorder()
+	mux.ServeHTTP(resendResp, resendReq)
+	if resendResp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resendResp.Code)
+	}
+	var resent callback.Delivery
+	if err := json.Unmarshal(resendResp.Body.Bytes(), &resent); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if resent.ResendOf != list.Data[0].DeliveryID || resent.State != callback.DeliveryDelivered {
+		t.Fatalf("expected delivered resend of %s, got %+v", list.Data[0].DeliveryID, resent)
+	}
+	if len(received) != 2 || received[0].WebhookID != received[1].WebhookID {
+		t.Fatalf("expected same callback twice, got %+v", received)
+	}
+
+	missingReq := httptest.NewRequest(http.MethodPost, "/xendit/admin/callbacks/cbd_missing/resend", nil)
+	missingResp := httptest.NewRecorder()
+	mux.ServeHTTP(missingResp, missingReq)
+	if missingResp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", missingResp.Code)
+	}
+}
+
+func TestSendStatusCallbackForExistingDisbursement(t *testing.T) {
+	var payload domain.CallbackPayload
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callbackSrv.Close()
+
+	t.Setenv("CALLBACK_URL", callbackSrv.URL)
+	mux := newTestAdminMux()
+	created := createDisbursement(t, mux, `{"external_id":"ext-transition"}`)
+
+	body := `{"status":"COMPLETED"}`
+	req := httptest.NewRequest(http.MethodPost, "/xendit/admin/disbursements/"+created.ID+"/callback", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	if payload.ID != created.ID || payload.Status != "COMPLETED" {
+		t.Fatalf("expected COMPLETED callback for %s, got %+v", created.ID, payload)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/xendit/admin/disbursements/disb_missing/callback", strings.NewReader(body))
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.Code)
+	}
+}
+
+func TestSetCallbackURLRoutesPerUser(t *testing.T) {
+	defaultHits, userHits := 0, 0
+	defaultSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultHits++
+	}))
+	defer defaultSrv.Close()
+	userSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userHits++
+	}))
+	defer userSrv.Close()
+
+	t.Setenv("CALLBACK_URL", defaultSrv.URL)
+	t.Setenv("CALLBACK_TOKEN", "token123")
+	mux := newTestAdminMux()
+
+	req := httptest.NewRequest(http.MethodPost, "/xendit/callback_urls/disbursement", strings.NewReader(`{"url":"`+userSrv.URL+`"}`))
+	req.Header.Set("for-user-id", "sub-account-1")
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if body["status"] != "SUCCESSFUL" || body["user_id"] != "sub-account-1" || body["callback_token"] != "token123" {
+		t.Fatalf("unexpected set callback url response %v", body)
+	}
+
+	createReq := httptest.NewRequest(http.MethodPost, "/xendit/disbursements", strings.NewReader(`{"external_id":"ext-sub"}`))
+	createReq.Header.Set("for-user-id", "sub-account-1")
+	mux.ServeHTTP(httptest.NewRecorder(), createReq)
+	createDisbursement(t, mux, `{"external_id":"ext-main"}`)
+
+	if userHits != 1 || defaultHits != 1 {
+		t.Fatalf("expected one callback per receiver, got user=%d default=%d", userHits, defaultHits)
+	}
+}
+
+func TestSetCallbackURLRejectsUnknownType(t *testing.T) {
+	mux := newTestAdminMux()
+	req := httptest.NewRequest(http.MethodPost, "/xendit/callback_urls/nope", strings.NewReader(`{"url":"https://example.com"}`))
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+}
+
+func TestSinkCapturesCallbacksFromTheMockItself(t *testing.T) {
+	var mux *http.ServeMux
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	t.Setenv("CALLBACK_URL", server.URL+"/xendit/admin/sink/it")
+	t.Setenv("CALLBACK_TOKEN", "sink-token")
+	mux = newTestAdminMux()
+	httptransport.NewSinkHandler(sink.NewStore()).RegisterRoutes(mux)
+
+	configReq := httptest.NewRequest(http.MethodPut, "/xendit/admin/sink/it/config", strings.NewReader(`{"status_codes":[503]}`))
+	configResp := httptest.NewRecorder()
+	mux.ServeHTTP(configResp, configReq)
+	if configResp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", configResp.Code)
+	}
+
+	createDisbursement(t, mux, `{"external_id":"ext-sink"}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/xendit/admin/sink/it", nil)
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	var body struct {
+		Data []sink.Capture `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected json response, got %v", err)
+	}
+	if len(body.Data) != 1 {
+		t.Fatalf("expected one captured callback, got %d", len(body.Data))
+	}
+	capture := body.Data[0]
+	if capture.CallbackToken != "sink-token" || capture.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected capture %+v", capture)
+	}
+	var payload domain.CallbackPayload
+	if err := json.Unmarshal(capture.Body, &payload); err != nil || payload.ExternalID != "ext-sink" {
+		t.Fatalf("expected captured payload for ext-sink, got %s", string(capture.Body))
+	}
+}
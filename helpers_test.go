@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// doRequest serves one request on mux and returns the status code and body.
+func doRequest(t *testing.T, mux *http.ServeMux, method, path, body string) (int, []byte) {
+	t.Helper()
+	return doRequestWithHeader(t, mux, method, path, body, nil)
+}
+
+// doRequestWithHeader is doRequest with extra request headers, such as
+// for-user-id or Idempotency-key.
+func doRequestWithHeader(t *testing.T, mux *http.ServeMux, method, path, body string, header http.Header) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+	return resp.Code, resp.Body.Bytes()
+}
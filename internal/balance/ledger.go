@@ -0,0 +1,192 @@
+package balance
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+)
+
+const (
+	AccountCash    = "CASH"
+	AccountHolding = "HOLDING"
+	AccountTax     = "TAX"
+)
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+func IsAccountType(accountType string) bool {
+	switch accountType {
+	case AccountCash, AccountHolding, AccountTax:
+		return true
+	}
+	return false
+}
+
+// Config seeds balances. Users without their own entry start from Default;
+// Fee is charged on top of every disbursement amount.
+type Config struct {
+	Default map[string]int            `json:"default"`
+	Users   map[string]map[string]int `json:"users"`
+	Fee     int                       `json:"fee"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+type Ledger struct {
+	mu       sync.Mutex
+	config   Config
+	balances map[string]map[string]int
+}
+
+func NewLedger(cfg Config) *Ledger {
+	return &Ledger{config: cfg, balances: make(map[string]map[string]int)}
+}
+
+func (l *Ledger) Fee() int {
+	return l.config.Fee
+}
+
+func (l *Ledger) Balance(userID, accountType string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.accounts(userID)[accountType]
+}
+
+// Withdraw debits amount plus the fee from the user's CASH account, or fails
+// with ErrInsufficientBalance without touching it.
+func (l *Ledger) Withdraw(userID string, amount int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	accounts := l.accounts(userID)
+	if accounts[AccountCash] < amount+l.config.Fee {
+		return ErrInsufficientBalance
+	}
+	accounts[AccountCash] -= amount + l.config.Fee
+	return nil
+}
+
+// Charge debits amount plus the fee even if that leaves the balance negative,
+// for status changes forced by hand.
+func (l *Ledger) Charge(userID string, amount int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.accounts(userID)[AccountCash] -= amount + l.config.Fee
+}
+
+// Refund credits back what Withdraw debited for amount.
+func (l *Ledger) Refund(userID string, amount int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.accounts(userID)[AccountCash] += amount + l.config.Fee
+}
+
+func (l *Ledger) TopUp(userID, accountType string, amount int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	accounts := l.accounts(userID)
+	accounts[accountType] += amount
+	return accounts[accountType]
+}
+
+type Account struct {
+	UserID      string `json:"user_id"`
+	AccountType string `json:"account_type"`
+	Balance     int    `json:"balance"`
+}
+
+func (l *Ledger) List() []Account {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	users := make(map[string]bool, len(l.balances)+len(l.config.Users))
+	for userID := range l.config.Users {
+		users[userID] = true
+	}
+	for userID := range l.balances {
+		users[userID] = true
+	}
+	ids := make([]string, 0, len(users))
+	for userID := range users {
+		ids = append(ids, userID)
+	}
+	sort.Strings(ids)
+
+	accounts := make([]Account, 0, len(ids)*3)
+	for _, userID := range ids {
+		balances := l.accounts(userID)
+		for _, accountType := range []string{AccountCash, AccountHolding, AccountTax} {
+			accounts = append(accounts, Account{UserID: userID, AccountType: accountType, Balance: balances[accountType]})
+		}
+	}
+	return accounts
+}
+
+func (l *Ledger) accounts(userID string) map[string]int {
+	if accounts, ok := l.balances[userID]; ok {
+		return accounts
+	}
+	seed, ok := l.config.Users[userID]
+	if !ok {
+		seed = l.config.Default
+	}
+	accounts := map[string]int{AccountCash: 0, AccountHolding: 0, AccountTax: 0}
+	for accountType, amount := range seed {
+		accounts[accountType] = amount
+	}
+	l.balances[userID] = accounts
+	return accounts
+}
+
+func (l *Ledger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.balances = make(map[string]map[string]int)
+}
+
+func (l *Ledger) Snapshot() any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	balances := make(map[string]map[string]int, len(l.balances))
+	for userID, accounts := range l.balances {
+		balances[userID] = make(map[string]int, len(accounts))
+		for accountType, amount := range accounts {
+			balances[userID][accountType] = amount
+		}
+	}
+	return balances
+}
+
+func (l *Ledger) Restore(data json.RawMessage) error {
+	var balances map[string]map[string]int
+	if err := json.Unmarshal(data, &balances); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.balances = balances
+	if l.balances == nil {
+		l.balances = make(map[string]map[string]int)
+	}
+	return nil
+}
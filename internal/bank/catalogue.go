@@ -0,0 +1,193 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	CategoryBank    = "BANK"
+	CategoryEwallet = "EWALLET"
+)
+
+var (
+	ErrUnsupported  = errors.New("destination bank code is not supported")
+	ErrBelowMinimum = errors.New("amount is below the minimum transfer limit")
+	ErrAboveMaximum = errors.New("amount exceeds the maximum transfer limit")
+	ErrIncrement    = errors.New("amount is not a supported increment")
+)
+
+type AmountLimits struct {
+	Minimum          int `json:"minimum"`
+	Maximum          int `json:"maximum"`
+	MinimumIncrement int `json:"minimum_increment"`
+}
+
+type Bank struct {
+	Name            string       `json:"name"`
+	Code            string       `json:"code"`
+	CanDisburse     bool         `json:"can_disburse"`
+	CanNameValidate bool         `json:"can_name_validate"`
+	ChannelCategory string       `json:"channel_category"`
+	Country         string       `json:"country"`
+	Currency        string       `json:"currency"`
+	AmountLimits    AmountLimits `json:"amount_limits"`
+}
+
+// ChannelCode is the Payouts v2 name of the bank, e.g. ID_BCA.
+func (b Bank) ChannelCode() string {
+	return b.Country + "_" + b.Code
+}
+
+// Validate reports whether amount can be sent to the bank.
+func (b Bank) Validate(amount int) error {
+	limits := b.AmountLimits
+	switch {
+	case !b.CanDisburse:
+		return ErrUnsupported
+	case limits.Minimum > 0 && amount < limits.Minimum:
+		return fmt.Errorf("%w of %d %s", ErrBelowMinimum, limits.Minimum, b.Currency)
+	case limits.Maximum > 0 && amount > limits.Maximum:
+		return fmt.Errorf("%w of %d %s", ErrAboveMaximum, limits.Maximum, b.Currency)
+	case limits.MinimumIncrement > 1 && amount%limits.MinimumIncrement != 0:
+		return fmt.Errorf("%w of %d %s", ErrIncrement, limits.MinimumIncrement, b.Currency)
+	}
+	return nil
+}
+
+func indonesianBank(code, name string, canNameValidate bool) Bank {
+	return Bank{
+		Name:            name,
+		Code:            code,
+		CanDisburse:     true,
+		CanNameValidate: canNameValidate,
+		ChannelCategory: CategoryBank,
+		Country:         "ID",
+		Currency:        "IDR",
+		AmountLimits:    AmountLimits{Minimum: 10000, Maximum: 100000000000, MinimumIncrement: 1},
+	}
+}
+
+func indonesianEwallet(code, name string, maximum int) Bank {
+	return Bank{
+		Name:            name,
+		Code:            code,
+		CanDisburse:     true,
+		ChannelCategory: CategoryEwallet,
+		Country:         "ID",
+		Currency:        "IDR",
+		AmountLimits:    AmountLimits{Minimum: 10000, Maximum: maximum, MinimumIncrement: 1},
+	}
+}
+
+func philippineChannel(code, name, category string) Bank {
+	return Bank{
+		Name:            name,
+		Code:            code,
+		CanDisburse:     true,
+		CanNameValidate: category == CategoryBank,
+		ChannelCategory: category,
+		Country:         "PH",
+		Currency:        "PHP",
+		AmountLimits:    AmountLimits{Minimum: 1, Maximum: 50000, MinimumIncrement: 1},
+	}
+}
+
+var defaultBanks = map[string][]Bank{
+	"ID": {
+		indonesianBank("BCA", "Bank Central Asia (BCA)", true),
+		indonesianBank("BNI", "Bank Negara Indonesia (BNI)", true),
+		indonesianBank("BRI", "Bank Rakyat Indonesia (BRI)", true),
+		indonesianBank("MANDIRI", "Bank Mandiri", true),
+		indonesianBank("PERMATA", "Bank Permata", true),
+		indonesianBank("CIMB", "Bank CIMB Niaga", true),
+		indonesianBank("BSI", "Bank Syariah Indonesia (BSI)", true),
+		indonesianBank("DANAMON", "Bank Danamon", true),
+		indonesianBank("BTN", "Bank Tabungan Negara (BTN)", false),
+		indonesianBank("MAYBANK", "Bank Maybank", true),
+		indonesianBank("OCBC", "Bank OCBC NISP", true),
+		indonesianBank("PANIN", "Bank Panin", false),
+		indonesianBank("MEGA", "Bank Mega", false),
+		indonesianBank("BJB", "Bank BJB", false),
+		indonesianBank("SAHABAT_SAMPOERNA", "Bank Sahabat Sampoerna", false),
+		indonesianEwallet("OVO", "OVO", 10000000),
+		indonesianEwallet("DANA", "DANA", 20000000),
+		indonesianEwallet("GOPAY", "GoPay", 20000000),
+		indonesianEwallet("LINKAJA", "LinkAja", 10000000),
+		indonesianEwallet("SHOPEEPAY", "ShopeePay", 20000000),
+	},
+	"PH": {
+		philippineChannel("BPI", "Bank of the Philippine Islands", CategoryBank),
+		philippineChannel("BDO", "BDO Unibank", CategoryBank),
+		philippineChannel("MET", "Metropolitan Bank and Trust Company", CategoryBank),
+		philippineChannel("UBP", "Union Bank of the Philippines", CategoryBank),
+		philippineChannel("LBP", "Land Bank of the Philippines", CategoryBank),
+		philippineChannel("GCASH", "GCash", CategoryEwallet),
+		philippineChannel("PAYMAYA", "Maya", CategoryEwallet),
+	},
+}
+
+// DefaultBanks returns the built-in catalogue for the given country codes.
+func DefaultBanks(countries ...string) []Bank {
+	var banks []Bank
+	for _, country := range countries {
+		banks = append(banks, defaultBanks[strings.ToUpper(strings.TrimSpace(country))]...)
+	}
+	return banks
+}
+
+func LoadBanks(path string) ([]Bank, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var banks []Bank
+	if err := json.Unmarshal(data, &banks); err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
+
+type Catalogue struct {
+	mu    sync.RWMutex
+	banks []Bank
+}
+
+func NewCatalogue(banks []Bank) *Catalogue {
+	return &Catalogue{banks: append([]Bank(nil), banks...)}
+}
+
+func (c *Catalogue) List() []Bank {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]Bank{}, c.banks...)
+}
+
+// Lookup finds a bank by its legacy code (BCA) or Payouts v2 channel code
+// (ID_BCA).
+func (c *Catalogue) Lookup(code string) (Bank, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, b := range c.banks {
+		if b.Code == code || b.ChannelCode() == code {
+			return b, true
+		}
+	}
+	return Bank{}, false
+}
+
+// Validate checks that code names a bank in the catalogue that can receive
+// amount.
+func (c *Catalogue) Validate(code string, amount int) error {
+	b, ok := c.Lookup(code)
+	if !ok {
+		return ErrUnsupported
+	}
+	return b.Validate(amount)
+}
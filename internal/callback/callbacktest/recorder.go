@@ -0,0 +1,32 @@
+// Package callbacktest provides a callback.Sender for testing the services
+// that send callbacks.
+package callbacktest
+
+import (
+	"sync"
+
+	"xendit-api-mock/internal/callback"
+)
+
+// Recorder is a callback.Sender that keeps every event it is given. Deliver
+// returns Err, so a test can make callbacks fail.
+type Recorder struct {
+	Err error
+
+	mu     sync.Mutex
+	events []callback.Event
+}
+
+func (r *Recorder) Deliver(event callback.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return r.Err
+}
+
+// Events returns the events delivered so far, oldest first.
+func (r *Recorder) Events() []callback.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]callback.Event(nil), r.events...)
+}
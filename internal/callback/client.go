@@ -3,65 +3,233 @@ package callback
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"xendit-api-mock/internal/domain"
 )
 
+const maxResponseBody = 1024
+
+var (
+	ErrDeliveryNotFound = errors.New("callback delivery not found")
+	ErrNoCallbackURL    = errors.New("CALLBACK_URL is not set")
+)
+
+type CheckResult struct {
+	URL      string  `json:"url"`
+	Attempt  Attempt `json:"attempt"`
+	Rejected bool    `json:"rejected"`
+}
+
+type Sender interface {
+	Deliver(event Event) error
+}
+
 type Client struct {
-	callbackURL string
-	token       string
-	httpClient  *http.Client
+	router     *Router
+	httpClient *http.Client
+	policy     RetryPolicy
+	history    *History
+	tokenTicks atomic.Uint64
+	retries    sync.WaitGroup
 }
 
 func NewClient(callbackURL, token string, httpClient *http.Client) *Client {
 	if httpClient == nil {
 		httpClient = &http.Client{Timeout: 10 * time.Second}
 	}
-	return &Client{callbackURL: callbackURL, token: token, httpClient: httpClient}
+	return &Client{router: NewRouter(callbackURL, token), httpClient: httpClient, policy: SingleAttempt(), history: NewHistory()}
+}
+
+func (c *Client) WithRetryPolicy(policy RetryPolicy) *Client {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	c.policy = policy
+	return c
+}
+
+func (c *Client) History() *History {
+	return c.history
+}
+
+func (c *Client) Router() *Router {
+	return c.router
 }
 
 func (c *Client) Send(payload domain.CallbackPayload) error {
-	if c.callbackURL == "" {
-		return fmt.Errorf("CALLBACK_URL is not set")
+	return c.Deliver(DisbursementEvent(payload, ""))
+}
+
+func (c *Client) Deliver(event Event) error {
+	if c.router.Resolve(event.Target).URL == "" {
+		return ErrNoCallbackURL
 	}
 
-	body, err := json.Marshal(payload)
+	body, err := json.Marshal(event.Payload)
 	if err != nil {
 		return err
 	}
 
-	request, err := http.NewRequest(http.MethodPost, c.callbackURL, bytes.NewBuffer(body))
+	delivery := c.history.begin(Delivery{
+		WebhookID:  event.WebhookID,
+		ResourceID: event.ResourceID,
+		ExternalID: event.ExternalID,
+		Status:     event.Status,
+		Target:     event.Target,
+		TokenMode:  event.TokenMode,
+		Payload:    body,
+	})
+	return c.deliver(delivery)
+}
+
+// Check sends event once to its receiver, without retries or history, and
+// reports how the receiver answered.
+func (c *Client) Check(event Event) (CheckResult, error) {
+	route := c.router.Resolve(event.Target)
+	if route.URL == "" {
+		return CheckResult{}, ErrNoCallbackURL
+	}
+
+	body, err := json.Marshal(event.Payload)
 	if err != nil {
-		return err
+		return CheckResult{}, err
+	}
+
+	// Any answer outside 2xx is a rejection here, whatever the retry policy
+	// would retry: a 401 from a receiver with the wrong token must show.
+	attempt, _ := c.post(route, event.TokenMode, body)
+	rejected := attempt.Error != "" || attempt.StatusCode < 200 || attempt.StatusCode > 299
+	return CheckResult{URL: route.URL, Attempt: attempt, Rejected: rejected}, nil
+}
+
+// Resend delivers a previously recorded callback again with the same payload
+// and webhook ID, and returns the new delivery record.
+func (c *Client) Resend(deliveryID string) (Delivery, error) {
+	original, ok := c.history.Get(deliveryID)
+	if !ok {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	delivery := c.history.begin(Delivery{
+		WebhookID:  original.WebhookID,
+		ResourceID: original.ResourceID,
+		ExternalID: original.ExternalID,
+		Status:     original.Status,
+		Target:     original.Target,
+		TokenMode:  original.TokenMode,
+		ResendOf:   original.DeliveryID,
+		Payload:    original.Payload,
+	})
+	err := c.deliver(delivery)
+	resent, _ := c.history.Get(delivery.DeliveryID)
+	return resent, err
+}
+
+// Wait blocks until every scheduled retry has either been delivered or given
+// up on.
+func (c *Client) Wait() {
+	c.retries.Wait()
+}
+
+func (c *Client) deliver(delivery Delivery) error {
+	route := c.router.Resolve(delivery.Target)
+	delivery.URL = route.URL
+	if route.URL == "" {
+		delivery.State = DeliveryDead
+		c.history.update(delivery)
+		return fmt.Errorf("no callback URL for %s", delivery.Target.EventType)
+	}
+	return c.attempt(route, delivery)
+}
+
+// attempt posts delivery once. When it fails with attempts left, the next one
+// is scheduled on a timer and the delivery stays PENDING, so the caller (a
+// dispatcher worker, usually) is free to move on to other callbacks.
+func (c *Client) attempt(route Route, delivery Delivery) error {
+	result, failed := c.post(route, delivery.TokenMode, delivery.Payload)
+	delivery.Attempts = append(delivery.Attempts, result)
+	attempt := len(delivery.Attempts)
+	if !failed {
+		delivery.State = DeliveryDelivered
+		c.history.update(delivery)
+		return nil
+	}
+	if attempt >= c.policy.MaxAttempts {
+		delivery.State = DeliveryDead
+		c.history.update(delivery)
+		log.Printf("[callback.Send] giving up delivery=%s webhook_id=%s attempts=%d", delivery.DeliveryID, delivery.WebhookID, attempt)
+		return fmt.Errorf("callback failed after %d attempt(s): %s", attempt, describeAttempt(result))
+	}
+	c.history.update(delivery)
+
+	backoff := c.policy.Backoff(attempt)
+	log.Printf("[callback.Send] retrying delivery=%s webhook_id=%s attempt=%d backoff=%s last=%s", delivery.DeliveryID, delivery.WebhookID, attempt, backoff, describeAttempt(result))
+	c.retries.Add(1)
+	time.AfterFunc(backoff, func() {
+		defer c.retries.Done()
+		_ = c.attempt(route, delivery)
+	})
+	return nil
+}
+
+func (c *Client) post(route Route, tokenMode string, body []byte) (Attempt, bool) {
+	attempt := Attempt{At: time.Now()}
+
+	request, err := http.NewRequest(http.MethodPost, route.URL, bytes.NewBuffer(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, true
 	}
 	request.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		request.Header.Set("X-Callback-Token", c.token)
-	} else {
+	if token, ok := route.callbackToken(tokenMode, attempt.At, c.tokenTicks.Add(1)); ok {
+		request.Header.Set("X-Callback-Token", token)
+	} else if tokenMode != TokenMissing {
 		log.Printf("[callback.Send] CALLBACK_TOKEN is not set")
 	}
 	log.Printf("[callback.Send] request method=%s url=%s body=%s", request.Method, request.URL.String(), formatBody(body))
 
 	resp, err := c.httpClient.Do(request)
+	attempt.LatencyMS = time.Since(attempt.At).Milliseconds()
 	if err != nil {
-		return err
+		attempt.Error = err.Error()
+		return attempt, true
 	}
 	defer resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("[callback.Send] response read failed status=%d error=%v", resp.StatusCode, err)
-		return nil
+		attempt.Error = err.Error()
+		return attempt, c.policy.IsFailure(resp.StatusCode)
 	}
+	attempt.ResponseBody = truncate(string(respBody), maxResponseBody)
 
-	log.Printf("[callback.Send] response method=%s url=%s status=%d body=%s", request.Method, request.URL.String(), resp.StatusCode, formatBody(respBody))
+	log.Printf("[callback.Send] response method=%s url=%s status=%d latency_ms=%d body=%s", request.Method, request.URL.String(), resp.StatusCode, attempt.LatencyMS, formatBody(respBody))
 
-	return nil
+	return attempt, c.policy.IsFailure(resp.StatusCode)
+}
+
+func describeAttempt(attempt Attempt) string {
+	if attempt.Error != "" {
+		return attempt.Error
+	}
+	return fmt.Sprintf("status %d", attempt.StatusCode)
+}
+
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit]
 }
 
 func formatBody(body []byte) string {
@@ -1,7 +1,13 @@
 package callback
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"xendit-api-mock/internal/domain"
 )
@@ -12,3 +18,168 @@ func TestSendMissingURL(t *testing.T) {
 		t.Fatal("expected error when CALLBACK_URL is missing")
 	}
 }
+
+func TestSendRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "token", nil).WithRetryPolicy(RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond})
+	if err := client.Send(domain.CallbackPayload{ID: "disb_1", WebhookID: "wh_1"}); err != nil {
+		t.Fatalf("expected delivery to succeed, got %v", err)
+	}
+	client.Wait()
+
+	deliveries := client.History().List()
+	if len(deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(deliveries))
+	}
+	if deliveries[0].State != DeliveryDelivered || len(deliveries[0].Attempts) != 3 {
+		t.Fatalf("expected delivered after 3 attempts, got %s after %d", deliveries[0].State, len(deliveries[0].Attempts))
+	}
+}
+
+func TestSendDeadLettersAfterExhaustingRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "token", nil).WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	if err := client.Send(domain.CallbackPayload{ID: "disb_1"}); err != nil {
+		t.Fatalf("expected the retry to be scheduled, got %v", err)
+	}
+	client.Wait()
+
+	dead := client.History().DeadLetters()
+	if len(dead) != 1 || len(dead[0].Attempts) != 2 {
+		t.Fatalf("expected one dead letter with 2 attempts, got %+v", dead)
+	}
+}
+
+func TestSendReportsFailureWithoutRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "token", nil)
+	if err := client.Send(domain.CallbackPayload{ID: "disb_1"}); err == nil {
+		t.Fatal("expected error when the only attempt fails")
+	}
+}
+
+func TestRetriesDoNotBlockTheDispatcherShard(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		order = append(order, payload.ID)
+		mu.Unlock()
+		if payload.ID == "disb_failing" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "token", nil).WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: 50 * time.Millisecond})
+	dispatcher := NewDispatcher(client, DispatcherConfig{Workers: 1, QueueSize: 10})
+	for _, id := range []string{"disb_failing", "disb_ok"} {
+		if err := dispatcher.Deliver(DisbursementEvent(domain.CallbackPayload{ID: id}, "")); err != nil {
+			t.Fatalf("expected enqueue to succeed, got %v", err)
+		}
+	}
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected drain to succeed, got %v", err)
+	}
+
+	want := []string{"disb_failing", "disb_ok", "disb_failing"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, order)
+		}
+	}
+	if dead := client.History().DeadLetters(); len(dead) != 1 {
+		t.Fatalf("expected the failing callback to be dead lettered after draining, got %+v", dead)
+	}
+}
+
+func TestSendHonoursFailureStatuses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	statuses, err := ParseStatusRanges("5xx,429")
+	if err != nil {
+		t.Fatalf("expected valid status ranges, got %v", err)
+	}
+	client := NewClient(server.URL, "token", nil).WithRetryPolicy(RetryPolicy{MaxAttempts: 3, FailureStatuses: statuses})
+	if err := client.Send(domain.CallbackPayload{ID: "disb_1"}); err != nil {
+		t.Fatalf("expected 400 to count as delivered, got %v", err)
+	}
+}
+
+func TestCheckRejectsNon2xxWhateverThePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "token", nil).WithRetryPolicy(RetryPolicy{MaxAttempts: 3, FailureStatuses: []StatusRange{{Min: 500, Max: 599}}})
+	result, err := client.Check(DisbursementEvent(domain.CallbackPayload{ID: "disb_1"}, ""))
+	if err != nil {
+		t.Fatalf("expected the check to run, got %v", err)
+	}
+	if !result.Rejected || result.Attempt.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 to be a rejection, got %+v", result)
+	}
+}
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := ParseStatusRanges("5xx, 429, 400-404")
+	if err != nil {
+		t.Fatalf("expected valid ranges, got %v", err)
+	}
+	policy := RetryPolicy{FailureStatuses: ranges}
+	for _, status := range []int{500, 599, 429, 400, 404} {
+		if !policy.IsFailure(status) {
+			t.Fatalf("expected %d to be a failure", status)
+		}
+	}
+	for _, status := range []int{200, 405, 600} {
+		if policy.IsFailure(status) {
+			t.Fatalf("expected %d not to be a failure", status)
+		}
+	}
+	if _, err := ParseStatusRanges("abc"); err == nil {
+		t.Fatal("expected error for invalid status")
+	}
+}
+
+func TestRetryPolicyBackoffCapped(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, Multiplier: 2}
+	if got := policy.Backoff(1); got != time.Second {
+		t.Fatalf("expected 1s, got %s", got)
+	}
+	if got := policy.Backoff(2); got != 2*time.Second {
+		t.Fatalf("expected 2s, got %s", got)
+	}
+	if got := policy.Backoff(5); got != 3*time.Second {
+		t.Fatalf("expected capped 3s, got %s", got)
+	}
+}
@@ -0,0 +1,126 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull        = errors.New("callback queue is full")
+	ErrDispatcherClosed = errors.New("callback dispatcher is closed")
+)
+
+type DispatcherConfig struct {
+	Workers   int
+	QueueSize int
+	Delay     time.Duration
+}
+
+// Dispatcher delivers callbacks in the background. Callbacks for the same
+// resource ID always land on the same worker, so they are sent in the order
+// they were queued.
+type Dispatcher struct {
+	sender Sender
+	delay  time.Duration
+	queues []chan job
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// waiter is implemented by senders that keep working in the background after
+// Deliver returns, such as a Client with retries scheduled.
+type waiter interface {
+	Wait()
+}
+
+type job struct {
+	event Event
+	due   time.Time
+}
+
+func NewDispatcher(sender Sender, cfg DispatcherConfig) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < cfg.Workers {
+		cfg.QueueSize = cfg.Workers
+	}
+	perWorker := (cfg.QueueSize + cfg.Workers - 1) / cfg.Workers
+
+	d := &Dispatcher{sender: sender, delay: cfg.Delay, queues: make([]chan job, cfg.Workers)}
+	for i := range d.queues {
+		d.queues[i] = make(chan job, perWorker)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+func (d *Dispatcher) Deliver(event Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	select {
+	case d.queues[d.shard(event.ResourceID)] <- job{event: event, due: time.Now().Add(d.delay)}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting callbacks and waits for the queued ones, and any
+// retries they scheduled, to be delivered, or for ctx to be done.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		if w, ok := d.sender.(waiter); ok {
+			w.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work(queue chan job) {
+	defer d.wg.Done()
+
+	for j := range queue {
+		if wait := time.Until(j.due); wait > 0 {
+			time.Sleep(wait)
+		}
+		if err := d.sender.Deliver(j.event); err != nil {
+			log.Printf("[callback.dispatch] send failed type=%s id=%s status=%s error=%v", j.event.EventType, j.event.ResourceID, j.event.Status, err)
+		}
+	}
+}
+
+func (d *Dispatcher) shard(key string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(len(d.queues)))
+}
@@ -0,0 +1,97 @@
+package callback
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/domain"
+)
+
+type recordingSender struct {
+	mu       sync.Mutex
+	payloads []domain.CallbackPayload
+	block    chan struct{}
+}
+
+func (s *recordingSender) Deliver(event Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = append(s.payloads, event.Payload.(domain.CallbackPayload))
+	return nil
+}
+
+func (s *recordingSender) sent() []domain.CallbackPayload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.CallbackPayload(nil), s.payloads...)
+}
+
+func TestDispatcherKeepsOrderPerDisbursement(t *testing.T) {
+	sender := &recordingSender{}
+	dispatcher := NewDispatcher(sender, DispatcherConfig{Workers: 4, QueueSize: 100})
+
+	statuses := []string{"PENDING", "FAILED", "COMPLETED"}
+	for _, status := range statuses {
+		if err := dispatcher.Deliver(DisbursementEvent(domain.CallbackPayload{ID: "disb_1", Status: status}, "")); err != nil {
+			t.Fatalf("expected enqueue to succeed, got %v", err)
+		}
+	}
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected drain to succeed, got %v", err)
+	}
+
+	sent := sender.sent()
+	if len(sent) != len(statuses) {
+		t.Fatalf("expected %d callbacks, got %d", len(statuses), len(sent))
+	}
+	for i, status := range statuses {
+		if sent[i].Status != status {
+			t.Fatalf("expected callback %d to be %s, got %s", i, status, sent[i].Status)
+		}
+	}
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	sender := &recordingSender{block: make(chan struct{})}
+	dispatcher := NewDispatcher(sender, DispatcherConfig{Workers: 1, QueueSize: 1})
+
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = dispatcher.Deliver(DisbursementEvent(domain.CallbackPayload{ID: "disb_1"}, ""))
+	}
+	if err != ErrQueueFull {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(sender.block)
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected drain to succeed, got %v", err)
+	}
+}
+
+func TestDispatcherDelayAndClose(t *testing.T) {
+	sender := &recordingSender{}
+	dispatcher := NewDispatcher(sender, DispatcherConfig{Workers: 1, QueueSize: 1, Delay: 20 * time.Millisecond})
+
+	start := time.Now()
+	if err := dispatcher.Deliver(DisbursementEvent(domain.CallbackPayload{ID: "disb_1"}, "")); err != nil {
+		t.Fatalf("expected enqueue to succeed, got %v", err)
+	}
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected drain to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected delivery to wait for delay, took %s", elapsed)
+	}
+	if len(sender.sent()) != 1 {
+		t.Fatalf("expected queued callback to be drained")
+	}
+	if err := dispatcher.Deliver(DisbursementEvent(domain.CallbackPayload{ID: "disb_2"}, "")); err != ErrDispatcherClosed {
+		t.Fatalf("expected ErrDispatcherClosed, got %v", err)
+	}
+}
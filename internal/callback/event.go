@@ -0,0 +1,294 @@
+package callback
+
+import "xendit-api-mock/internal/domain"
+
+const (
+	EventDisbursement      = "disbursement"
+	EventBatchDisbursement = "batch_disbursement"
+	EventPayout            = "payout"
+	EventNameValidation    = "name_validation"
+	EventInvoice           = "invoice"
+	EventFVAStatus         = "fva_status"
+	EventFVAPaid           = "fva_paid"
+	EventEWallet           = "ewallet"
+	EventQRPayment         = "qr_payment"
+	EventRetailOutlet      = "retail_outlet"
+	EventPayment           = "payment"
+	EventPaymentMethod     = "payment_method"
+	EventRefund            = "refund"
+	EventRecurring         = "recurring"
+	EventCardCharge        = "credit_card_charge"
+)
+
+var eventTypes = map[string]bool{
+	EventDisbursement:      true,
+	EventBatchDisbursement: true,
+	EventPayout:            true,
+	EventNameValidation:    true,
+	EventInvoice:           true,
+	EventFVAStatus:         true,
+	EventFVAPaid:           true,
+	EventEWallet:           true,
+	EventQRPayment:         true,
+	EventRetailOutlet:      true,
+	EventPayment:           true,
+	EventPaymentMethod:     true,
+	EventRefund:            true,
+	EventRecurring:         true,
+	EventCardCharge:        true,
+}
+
+func IsEventType(eventType string) bool {
+	return eventTypes[eventType]
+}
+
+// Target carries the attributes a callback is routed by.
+type Target struct {
+	EventType     string `json:"event_type"`
+	UserID        string `json:"user_id,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	Session       string `json:"session,omitempty"`
+}
+
+type Event struct {
+	Target
+	ResourceID string
+	ExternalID string
+	Status     string
+	WebhookID  string
+	TokenMode  string
+	Payload    any
+}
+
+func DisbursementEvent(payload domain.CallbackPayload, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventDisbursement,
+			UserID:        payload.UserID,
+			AccountNumber: payload.AccountNumber,
+			Session:       session,
+		},
+		ResourceID: payload.ID,
+		ExternalID: payload.ExternalID,
+		Status:     payload.Status,
+		WebhookID:  payload.WebhookID,
+		Payload:    payload,
+	}
+}
+
+func BatchDisbursementEvent(payload domain.BatchCallbackPayload, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventBatchDisbursement,
+			UserID:    payload.UserID,
+			Session:   session,
+		},
+		ResourceID: payload.ID,
+		ExternalID: payload.Reference,
+		Status:     payload.Status,
+		WebhookID:  payload.WebhookID,
+		Payload:    payload,
+	}
+}
+
+func PayoutEvent(payload domain.PayoutWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventPayout,
+			UserID:        payload.BusinessID,
+			AccountNumber: payload.Data.ChannelProperties.AccountNumber,
+			Session:       session,
+		},
+		ResourceID: payload.Data.ID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+func NameValidationEvent(payload domain.NameValidation, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventNameValidation,
+			UserID:        payload.UserID,
+			AccountNumber: payload.BankAccountNumber,
+			Session:       session,
+		},
+		ResourceID: payload.ID,
+		Status:     payload.Status,
+		WebhookID:  domain.WebhookID(payload.ID, payload.Status),
+		Payload:    payload,
+	}
+}
+
+func InvoiceEvent(payload domain.InvoiceCallbackPayload, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventInvoice,
+			UserID:    payload.UserID,
+			Session:   session,
+		},
+		ResourceID: payload.ID,
+		ExternalID: payload.ExternalID,
+		Status:     payload.Status,
+		WebhookID:  domain.WebhookID(payload.ID, payload.Status),
+		Payload:    payload,
+	}
+}
+
+func VirtualAccountEvent(payload domain.VirtualAccount, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventFVAStatus,
+			UserID:        payload.OwnerID,
+			AccountNumber: payload.AccountNumber,
+			Session:       session,
+		},
+		ResourceID: payload.ID,
+		ExternalID: payload.ExternalID,
+		Status:     payload.Status,
+		WebhookID:  domain.WebhookID(payload.ID, payload.Status+":"+payload.Updated),
+		Payload:    payload,
+	}
+}
+
+func VirtualAccountPaymentEvent(payload domain.VirtualAccountPayment, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventFVAPaid,
+			UserID:        payload.OwnerID,
+			AccountNumber: payload.AccountNumber,
+			Session:       session,
+		},
+		ResourceID: payload.CallbackVirtualAccountID,
+		ExternalID: payload.ExternalID,
+		Status:     "PAID",
+		WebhookID:  domain.WebhookID(payload.PaymentID, "PAID"),
+		Payload:    payload,
+	}
+}
+
+func EWalletEvent(payload domain.EWalletWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType:     EventEWallet,
+			UserID:        payload.BusinessID,
+			AccountNumber: payload.Data.ChannelProperties.MobileNumber,
+			Session:       session,
+		},
+		ResourceID: payload.Data.ID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+func QRPaymentEvent(payload domain.QRPaymentWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventQRPayment,
+			UserID:    payload.BusinessID,
+			Session:   session,
+		},
+		ResourceID: payload.Data.QRID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+func FixedPaymentCodePaymentEvent(payload domain.FixedPaymentCodePayment, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventRetailOutlet,
+			UserID:    payload.OwnerID,
+			Session:   session,
+		},
+		ResourceID: payload.FixedPaymentCodeID,
+		ExternalID: payload.ExternalID,
+		Status:     payload.Status,
+		WebhookID:  domain.WebhookID(payload.PaymentID, payload.Status),
+		Payload:    payload,
+	}
+}
+
+func PaymentEvent(payload domain.PaymentWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventPayment,
+			UserID:    payload.BusinessID,
+			Session:   session,
+		},
+		ResourceID: payload.Data.PaymentRequestID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+func PaymentMethodEvent(payload domain.PaymentMethodWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventPaymentMethod,
+			UserID:    payload.BusinessID,
+			Session:   session,
+		},
+		ResourceID: payload.Data.ID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+func RefundEvent(payload domain.RefundWebhook, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventRefund,
+			UserID:    payload.BusinessID,
+			Session:   session,
+		},
+		ResourceID: payload.Data.ID,
+		ExternalID: payload.Data.ReferenceID,
+		Status:     payload.Data.Status,
+		WebhookID:  domain.WebhookID(payload.Data.ID, payload.Data.Status),
+		Payload:    payload,
+	}
+}
+
+// RecurringEvent wraps a recurring.* webhook about the plan or cycle with id.
+// A cycle is retried under the same status, so the webhook ID also depends on
+// when the event happened.
+func RecurringEvent(payload domain.RecurringWebhook, id, referenceID, status, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventRecurring,
+			UserID:    payload.BusinessID,
+			Session:   session,
+		},
+		ResourceID: id,
+		ExternalID: referenceID,
+		Status:     status,
+		WebhookID:  domain.WebhookID(id, payload.Event+":"+payload.Created),
+		Payload:    payload,
+	}
+}
+
+func CardChargeEvent(charge domain.CardCharge, session string) Event {
+	return Event{
+		Target: Target{
+			EventType: EventCardCharge,
+			UserID:    charge.BusinessID,
+			Session:   session,
+		},
+		ResourceID: charge.ID,
+		ExternalID: charge.ExternalID,
+		Status:     charge.Status,
+		WebhookID:  domain.WebhookID(charge.ID, charge.Status),
+		Payload:    charge,
+	}
+}
@@ -0,0 +1,154 @@
+package callback
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryDead      = "DEAD_LETTER"
+)
+
+const historyLimit = 1000
+
+type Attempt struct {
+	At           time.Time `json:"at"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	LatencyMS    int64     `json:"latency_ms"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type Delivery struct {
+	DeliveryID string          `json:"delivery_id"`
+	WebhookID  string          `json:"webhook_id"`
+	ResourceID string          `json:"resource_id"`
+	ExternalID string          `json:"external_id"`
+	Status     string          `json:"status"`
+	Target     Target          `json:"target"`
+	TokenMode  string          `json:"token_mode,omitempty"`
+	URL        string          `json:"url"`
+	State      string          `json:"state"`
+	Attempts   []Attempt       `json:"attempts"`
+	LastResult *Attempt        `json:"last_result,omitempty"`
+	ResendOf   string          `json:"resend_of,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Created    time.Time       `json:"created"`
+	Updated    time.Time       `json:"updated"`
+}
+
+type History struct {
+	mu         sync.Mutex
+	seq        int
+	deliveries []Delivery
+}
+
+type historyState struct {
+	Seq        int        `json:"seq"`
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+func NewHistory() *History {
+	return &History{}
+}
+
+func (h *History) begin(d Delivery) Delivery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	now := time.Now()
+	d.DeliveryID = fmt.Sprintf("cbd_%06d", h.seq)
+	d.State = DeliveryPending
+	d.Created = now
+	d.Updated = now
+	h.deliveries = append(h.deliveries, d)
+	if len(h.deliveries) > historyLimit {
+		h.deliveries = h.deliveries[len(h.deliveries)-historyLimit:]
+	}
+	return d
+}
+
+func (h *History) update(d Delivery) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d.Updated = time.Now()
+	d.Attempts = append([]Attempt(nil), d.Attempts...)
+	if len(d.Attempts) > 0 {
+		last := d.Attempts[len(d.Attempts)-1]
+		d.LastResult = &last
+	}
+	for i := len(h.deliveries) - 1; i >= 0; i-- {
+		if h.deliveries[i].DeliveryID == d.DeliveryID {
+			h.deliveries[i] = d
+			return
+		}
+	}
+}
+
+func (h *History) List() []Delivery {
+	return h.filter(func(Delivery) bool { return true })
+}
+
+func (h *History) DeadLetters() []Delivery {
+	return h.filter(func(d Delivery) bool { return d.State == DeliveryDead })
+}
+
+func (h *History) Get(deliveryID string) (Delivery, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, d := range h.deliveries {
+		if d.DeliveryID == deliveryID {
+			return d, true
+		}
+	}
+	return Delivery{}, false
+}
+
+func (h *History) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq = 0
+	h.deliveries = nil
+}
+
+func (h *History) Snapshot() any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return historyState{Seq: h.seq, Deliveries: append([]Delivery(nil), h.deliveries...)}
+}
+
+func (h *History) Restore(data json.RawMessage) error {
+	var state historyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq = state.Seq
+	h.deliveries = state.Deliveries
+	return nil
+}
+
+func (h *History) filter(keep func(Delivery) bool) []Delivery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]Delivery, 0, len(h.deliveries))
+	for _, d := range h.deliveries {
+		if keep(d) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
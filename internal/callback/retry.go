@@ -0,0 +1,93 @@
+package callback
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	Multiplier      float64
+	Jitter          float64
+	FailureStatuses []StatusRange
+}
+
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+func SingleAttempt() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// IsFailure reports whether a receiver status code should be retried. With no
+// FailureStatuses configured, anything outside 2xx is a failure.
+func (p RetryPolicy) IsFailure(status int) bool {
+	if len(p.FailureStatuses) == 0 {
+		return status < 200 || status > 299
+	}
+	for _, r := range p.FailureStatuses {
+		if status >= r.Min && status <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the wait before the attempt following the given one.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if backoff < 0 {
+		return 0
+	}
+	return time.Duration(backoff)
+}
+
+// ParseStatusRanges parses a comma separated list such as "5xx,429,400-404".
+func ParseStatusRanges(spec string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if len(part) == 3 && strings.HasSuffix(part, "xx") {
+			class, err := strconv.Atoi(part[:1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid status class %q", part)
+			}
+			ranges = append(ranges, StatusRange{Min: class * 100, Max: class*100 + 99})
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		min, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q", part)
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.Atoi(bounds[1]); err != nil || max < min {
+				return nil, fmt.Errorf("invalid status range %q", part)
+			}
+		}
+		ranges = append(ranges, StatusRange{Min: min, Max: max})
+	}
+	return ranges, nil
+}
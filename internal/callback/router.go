@@ -0,0 +1,150 @@
+package callback
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Route sends callbacks matching all of its non-empty selectors to URL. A
+// route without selectors is the default.
+type Route struct {
+	URL           string         `json:"url"`
+	Token         string         `json:"token,omitempty"`
+	Rotation      *TokenRotation `json:"rotation,omitempty"`
+	UserID        string         `json:"user_id,omitempty"`
+	AccountNumber string         `json:"account_number,omitempty"`
+	Session       string         `json:"session,omitempty"`
+	EventType     string         `json:"event_type,omitempty"`
+}
+
+type RoutingTable struct {
+	Routes []Route `json:"routes"`
+}
+
+type Router struct {
+	mu     sync.RWMutex
+	routes []Route
+}
+
+func NewRouter(defaultURL, defaultToken string) *Router {
+	return &Router{routes: []Route{{URL: defaultURL, Token: defaultToken}}}
+}
+
+func LoadRoutingTable(path string) (RoutingTable, error) {
+	var table RoutingTable
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table, err
+	}
+	err = json.Unmarshal(data, &table)
+	return table, err
+}
+
+// Resolve picks the most specific matching route. Session outranks account,
+// account outranks user_id and user_id outranks event type; among equally
+// specific routes the one set last wins. A route without a token uses the
+// default route's token and rotation.
+func (r *Router) Resolve(target Target) Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best, fallback Route
+	bestScore := -1
+	for _, route := range r.routes {
+		score, ok := route.match(target)
+		if !ok {
+			continue
+		}
+		if score == 0 {
+			fallback = route
+		}
+		if score >= bestScore {
+			best = route
+			bestScore = score
+		}
+	}
+	if best.Token == "" && best.Rotation == nil {
+		best.Token = fallback.Token
+		best.Rotation = fallback.Rotation
+	}
+	return best
+}
+
+// Set adds route, replacing an existing route with the same selectors.
+func (r *Router) Set(route Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route = route.normalized(time.Now())
+
+	for i, existing := range r.routes {
+		if existing.sameSelectors(route) {
+			r.routes[i] = route
+			return
+		}
+	}
+	r.routes = append(r.routes, route)
+}
+
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Route(nil), r.routes...)
+}
+
+func (r *Router) Replace(routes []Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.routes = append([]Route(nil), routes...)
+	for i := range r.routes {
+		r.routes[i] = r.routes[i].normalized(now)
+	}
+}
+
+func (r *Router) Snapshot() any {
+	return RoutingTable{Routes: r.Routes()}
+}
+
+func (r *Router) Restore(data json.RawMessage) error {
+	var table RoutingTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	r.Replace(table.Routes)
+	return nil
+}
+
+func (route Route) match(target Target) (int, bool) {
+	score := 0
+	selectors := []struct {
+		want, got string
+		weight    int
+	}{
+		{route.Session, target.Session, 8},
+		{route.AccountNumber, target.AccountNumber, 4},
+		{route.UserID, target.UserID, 2},
+		{route.EventType, target.EventType, 1},
+	}
+	for _, selector := range selectors {
+		if selector.want == "" {
+			continue
+		}
+		if selector.want != selector.got {
+			return 0, false
+		}
+		score += selector.weight
+	}
+	return score, true
+}
+
+func (route Route) sameSelectors(other Route) bool {
+	return route.UserID == other.UserID &&
+		route.AccountNumber == other.AccountNumber &&
+		route.Session == other.Session &&
+		route.EventType == other.EventType
+}
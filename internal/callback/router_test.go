@@ -0,0 +1,42 @@
+package callback
+
+import "testing"
+
+func TestRouterResolvePrecedence(t *testing.T) {
+	router := NewRouter("https://default", "default-token")
+	router.Set(Route{URL: "https://event", EventType: EventDisbursement})
+	router.Set(Route{URL: "https://user", UserID: "user-1", Token: "user-token"})
+	router.Set(Route{URL: "https://account", AccountNumber: "123"})
+	router.Set(Route{URL: "https://session", Session: "qa-1"})
+
+	cases := []struct {
+		target Target
+		url    string
+		token  string
+	}{
+		{Target{EventType: "other"}, "https://default", "default-token"},
+		{Target{EventType: EventDisbursement}, "https://event", "default-token"},
+		{Target{EventType: EventDisbursement, UserID: "user-1"}, "https://user", "user-token"},
+		{Target{EventType: EventDisbursement, UserID: "user-1", AccountNumber: "123"}, "https://account", "default-token"},
+		{Target{EventType: EventDisbursement, UserID: "user-1", AccountNumber: "123", Session: "qa-1"}, "https://session", "default-token"},
+	}
+	for _, tc := range cases {
+		route := router.Resolve(tc.target)
+		if route.URL != tc.url || route.Token != tc.token {
+			t.Fatalf("expected %s with %s for %+v, got %+v", tc.url, tc.token, tc.target, route)
+		}
+	}
+}
+
+func TestRouterSetReplacesSameSelectors(t *testing.T) {
+	router := NewRouter("https://default", "")
+	router.Set(Route{URL: "https://first", UserID: "user-1"})
+	router.Set(Route{URL: "https://second", UserID: "user-1"})
+
+	if got := len(router.Routes()); got != 2 {
+		t.Fatalf("expected default and one user route, got %d", got)
+	}
+	if got := router.Resolve(Target{UserID: "user-1"}).URL; got != "https://second" {
+		t.Fatalf("expected replaced route, got %s", got)
+	}
+}
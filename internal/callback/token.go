@@ -0,0 +1,69 @@
+package callback
+
+import (
+	"time"
+
+	"xendit-api-mock/internal/domain"
+)
+
+const (
+	TokenMissing  = "missing"
+	TokenWrong    = "wrong"
+	TokenPrevious = "previous"
+	TokenNext     = "next"
+)
+
+// TokenRotation switches a route from its token to NextToken at RotateAt.
+// During the window after RotateAt both tokens are sent, alternating, so a
+// receiver must accept either.
+type TokenRotation struct {
+	NextToken          string    `json:"next_token"`
+	RotateAt           time.Time `json:"rotate_at"`
+	RotateAfterSeconds int       `json:"rotate_after_seconds,omitempty"`
+	WindowSeconds      int       `json:"window_seconds,omitempty"`
+}
+
+// callbackToken returns the X-Callback-Token to send for route at now, and
+// false when the header must be omitted. tick alternates tokens during a
+// rotation window.
+func (route Route) callbackToken(mode string, now time.Time, tick uint64) (string, bool) {
+	current, previous := route.Token, route.Token
+	next := route.Token
+	if rotation := route.Rotation; rotation != nil && rotation.NextToken != "" {
+		next = rotation.NextToken
+		windowEnd := rotation.RotateAt.Add(time.Duration(rotation.WindowSeconds) * time.Second)
+		switch {
+		case now.Before(rotation.RotateAt):
+		case now.Before(windowEnd) && tick%2 == 1:
+		default:
+			current = rotation.NextToken
+		}
+	}
+
+	switch mode {
+	case TokenMissing:
+		return "", false
+	case TokenWrong:
+		return "wrong_" + domain.ShortHash(current), true
+	case TokenPrevious:
+		return previous, previous != ""
+	case TokenNext:
+		return next, next != ""
+	default:
+		return current, current != ""
+	}
+}
+
+// normalized resolves rotate_after_seconds against now, on a copy of the
+// rotation so the caller's route is left untouched.
+func (route Route) normalized(now time.Time) Route {
+	if route.Rotation == nil {
+		return route
+	}
+	rotation := *route.Rotation
+	if rotation.RotateAt.IsZero() && rotation.RotateAfterSeconds > 0 {
+		rotation.RotateAt = now.Add(time.Duration(rotation.RotateAfterSeconds) * time.Second)
+	}
+	route.Rotation = &rotation
+	return route
+}
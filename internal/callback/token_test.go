@@ -0,0 +1,57 @@
+package callback
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallbackTokenRotation(t *testing.T) {
+	rotateAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	route := Route{Token: "old", Rotation: &TokenRotation{NextToken: "new", RotateAt: rotateAt, WindowSeconds: 60}}
+
+	if token, _ := route.callbackToken("", rotateAt.Add(-time.Second), 1); token != "old" {
+		t.Fatalf("expected old token before rotation, got %s", token)
+	}
+	seen := map[string]bool{}
+	for tick := uint64(0); tick < 2; tick++ {
+		token, _ := route.callbackToken("", rotateAt.Add(30*time.Second), tick)
+		seen[token] = true
+	}
+	if !seen["old"] || !seen["new"] {
+		t.Fatalf("expected both tokens during rotation window, got %v", seen)
+	}
+	if token, _ := route.callbackToken("", rotateAt.Add(2*time.Minute), 1); token != "new" {
+		t.Fatalf("expected new token after window, got %s", token)
+	}
+	if token, _ := route.callbackToken(TokenPrevious, rotateAt.Add(2*time.Minute), 0); token != "old" {
+		t.Fatalf("expected previous token, got %s", token)
+	}
+	if token, _ := route.callbackToken(TokenNext, rotateAt.Add(-time.Hour), 0); token != "new" {
+		t.Fatalf("expected next token, got %s", token)
+	}
+}
+
+func TestCallbackTokenModes(t *testing.T) {
+	route := Route{Token: "secret"}
+	if _, ok := route.callbackToken(TokenMissing, time.Now(), 0); ok {
+		t.Fatal("expected no token header for missing mode")
+	}
+	token, ok := route.callbackToken(TokenWrong, time.Now(), 0)
+	if !ok || token == "secret" || token == "" {
+		t.Fatalf("expected a wrong token, got %q", token)
+	}
+}
+
+func TestRouterNormalizesRotateAfter(t *testing.T) {
+	router := NewRouter("https://default", "old")
+	rotation := &TokenRotation{NextToken: "new", RotateAfterSeconds: 60}
+	router.Set(Route{URL: "https://default", Token: "old", Rotation: rotation})
+
+	route := router.Resolve(Target{})
+	if route.Rotation == nil || route.Rotation.RotateAt.IsZero() {
+		t.Fatalf("expected rotate_at to be resolved, got %+v", route.Rotation)
+	}
+	if !rotation.RotateAt.IsZero() {
+		t.Fatal("expected caller's rotation to be left untouched")
+	}
+}
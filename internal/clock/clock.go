@@ -0,0 +1,171 @@
+package clock
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the mock's virtual time: real time plus an offset. Advance moves
+// it forward; Reset and Restore may set it back. Work scheduled with At runs
+// once the virtual time reaches it, whether real time gets there or the clock
+// is advanced past it. A single real timer is kept for the earliest work.
+type Clock struct {
+	mu     sync.Mutex
+	fireMu sync.Mutex
+	offset time.Duration
+	timers []timer
+	seq    int
+	real   *time.Timer
+}
+
+type timer struct {
+	at  time.Time
+	seq int
+	fn  func()
+}
+
+type State struct {
+	Now string `json:"now"`
+}
+
+func New() *Clock {
+	return &Clock{}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Now().Add(c.offset)
+}
+
+// At runs fn once the clock reaches at: in the background when real time
+// gets there, or in the caller of Advance when it moves the clock past at.
+func (c *Clock) At(at time.Time, fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	c.timers = append(c.timers, timer{at: at, seq: c.seq, fn: fn})
+	c.arm()
+}
+
+// Advance moves the clock forward by d and runs the work that falls due on
+// the way, in order, each seeing the clock at its own due time.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.fireMu.Lock()
+	defer c.fireMu.Unlock()
+
+	c.mu.Lock()
+	target := time.Now().Add(c.offset + d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		next, ok := c.next(target)
+		if !ok {
+			c.offset = time.Until(target)
+			c.arm()
+			c.mu.Unlock()
+			return target
+		}
+		if now := time.Now().Add(c.offset); next.at.After(now) {
+			c.offset += next.at.Sub(now)
+		}
+		c.mu.Unlock()
+		next.fn()
+	}
+}
+
+// fire runs the work that is due at the current time.
+func (c *Clock) fire() {
+	c.fireMu.Lock()
+	defer c.fireMu.Unlock()
+
+	for {
+		c.mu.Lock()
+		next, ok := c.next(time.Now().Add(c.offset))
+		if !ok {
+			c.arm()
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+		next.fn()
+	}
+}
+
+// arm points the real timer at the earliest scheduled work, or stops it when
+// there is none. The caller holds c.mu.
+func (c *Clock) arm() {
+	if c.real != nil {
+		c.real.Stop()
+		c.real = nil
+	}
+	if len(c.timers) == 0 {
+		return
+	}
+	earliest := c.timers[0].at
+	for _, t := range c.timers[1:] {
+		if t.at.Before(earliest) {
+			earliest = t.at
+		}
+	}
+	c.real = time.AfterFunc(earliest.Sub(time.Now().Add(c.offset)), c.fire)
+}
+
+// next removes and returns the earliest timer due by until. The caller holds
+// c.mu.
+func (c *Clock) next(until time.Time) (timer, bool) {
+	if len(c.timers) == 0 {
+		return timer{}, false
+	}
+	sort.Slice(c.timers, func(i, j int) bool {
+		if !c.timers[i].at.Equal(c.timers[j].at) {
+			return c.timers[i].at.Before(c.timers[j].at)
+		}
+		return c.timers[i].seq < c.timers[j].seq
+	})
+	if c.timers[0].at.After(until) {
+		return timer{}, false
+	}
+	next := c.timers[0]
+	c.timers = c.timers[1:]
+	return next, true
+}
+
+// Reset goes back to real time and drops scheduled work.
+func (c *Clock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = 0
+	c.timers = nil
+	c.arm()
+}
+
+func (c *Clock) Snapshot() any {
+	return State{Now: c.Now().Format(time.RFC3339Nano)}
+}
+
+// Restore sets the clock to the snapshot's time and drops scheduled work;
+// services restored after it schedule theirs again.
+func (c *Clock) Restore(data json.RawMessage) error {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	now, err := time.Parse(time.RFC3339Nano, state.Now)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = time.Until(now)
+	c.timers = nil
+	c.arm()
+	return nil
+}
@@ -0,0 +1,67 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdvanceRunsDueWorkInOrder(t *testing.T) {
+	c := New()
+	start := c.Now()
+
+	var ran []string
+	c.At(start.Add(2*time.Hour), func() { ran = append(ran, "second") })
+	c.At(start.Add(time.Hour), func() { ran = append(ran, "first") })
+	c.At(start.Add(3*time.Hour), func() { ran = append(ran, "later") })
+
+	now := c.Advance(2 * time.Hour)
+	if len(ran) != 2 || ran[0] != "first" || ran[1] != "second" {
+		t.Fatalf("expected first and second to run in order, got %v", ran)
+	}
+	if now.Sub(start) < 2*time.Hour {
+		t.Fatalf("expected the clock to move 2h, got %s", now.Sub(start))
+	}
+}
+
+func TestRealTimeFiresTheEarliestWork(t *testing.T) {
+	c := New()
+
+	var mu sync.Mutex
+	var ran []string
+	done := make(chan struct{})
+	c.At(c.Now().Add(time.Hour), func() {
+		mu.Lock()
+		ran = append(ran, "hour")
+		mu.Unlock()
+	})
+	c.At(c.Now().Add(10*time.Millisecond), func() {
+		mu.Lock()
+		ran = append(ran, "soon")
+		mu.Unlock()
+		close(done)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the earliest work to run in real time")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 1 || ran[0] != "soon" {
+		t.Fatalf("expected only the earliest work to run, got %v", ran)
+	}
+}
+
+func TestResetDropsScheduledWork(t *testing.T) {
+	c := New()
+	ran := false
+	c.At(c.Now().Add(10*time.Millisecond), func() { ran = true })
+	c.Reset()
+
+	c.Advance(time.Hour)
+	if ran {
+		t.Fatal("expected reset to drop scheduled work")
+	}
+}
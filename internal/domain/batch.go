@@ -0,0 +1,122 @@
+package domain
+
+import "time"
+
+const (
+	BatchStatusNeedsApproval      = "NEEDS_APPROVAL"
+	BatchStatusApproved           = "APPROVED"
+	BatchStatusUploading          = "UPLOADING"
+	BatchStatusCompleted          = "COMPLETED"
+	BatchStatusPartiallyCompleted = "PARTIALLY_COMPLETED"
+	BatchStatusFailed             = "FAILED"
+)
+
+type BatchDisbursementRequest struct {
+	Reference     string                  `json:"reference"`
+	Disbursements []BatchDisbursementItem `json:"disbursements"`
+	ForUserID     string                  `json:"-"`
+	Session       string                  `json:"-"`
+}
+
+type BatchDisbursementItem struct {
+	ExternalID        string   `json:"external_id"`
+	Amount            int      `json:"amount"`
+	BankCode          string   `json:"bank_code"`
+	BankAccountName   string   `json:"bank_account_name"`
+	BankAccountNumber string   `json:"bank_account_number"`
+	Description       string   `json:"description"`
+	EmailTo           []string `json:"email_to,omitempty"`
+	EmailCC           []string `json:"email_cc,omitempty"`
+	EmailBCC          []string `json:"email_bcc,omitempty"`
+}
+
+type BatchDisbursementResponse struct {
+	ID                  string `json:"id"`
+	Created             string `json:"created"`
+	Reference           string `json:"reference"`
+	TotalUploadedAmount int    `json:"total_uploaded_amount"`
+	TotalUploadedCount  int    `json:"total_uploaded_count"`
+	Status              string `json:"status"`
+}
+
+type BatchDisbursementItemResult struct {
+	ID                string   `json:"id"`
+	Created           string   `json:"created"`
+	Updated           string   `json:"updated"`
+	ExternalID        string   `json:"external_id"`
+	Amount            int      `json:"amount"`
+	BankCode          string   `json:"bank_code"`
+	BankAccountName   string   `json:"bank_account_name"`
+	BankAccountNumber string   `json:"bank_account_number"`
+	Description       string   `json:"description"`
+	Status            string   `json:"status"`
+	FailureCode       string   `json:"failure_code,omitempty"`
+	BankReference     string   `json:"bank_reference,omitempty"`
+	ValidName         string   `json:"valid_name,omitempty"`
+	EmailTo           []string `json:"email_to,omitempty"`
+	EmailCC           []string `json:"email_cc,omitempty"`
+	EmailBCC          []string `json:"email_bcc,omitempty"`
+}
+
+type BatchCallbackPayload struct {
+	ID                   string                        `json:"id"`
+	Created              string                        `json:"created"`
+	Updated              string                        `json:"updated"`
+	Reference            string                        `json:"reference"`
+	UserID               string                        `json:"user_id"`
+	Status               string                        `json:"status"`
+	ApprovedAt           string                        `json:"approved_at,omitempty"`
+	TotalUploadedCount   int                           `json:"total_uploaded_count"`
+	TotalUploadedAmount  int                           `json:"total_uploaded_amount"`
+	TotalDisbursedCount  int                           `json:"total_disbursed_count"`
+	TotalDisbursedAmount int                           `json:"total_disbursed_amount"`
+	TotalErrorCount      int                           `json:"total_error_count"`
+	TotalErrorAmount     int                           `json:"total_error_amount"`
+	Disbursements        []BatchDisbursementItemResult `json:"disbursements"`
+	WebhookID            string                        `json:"webhookId"`
+}
+
+func BatchDisbursementID(reference string) string {
+	return "batch_" + ShortHash(reference+":"+time.Now().Format(time.RFC3339Nano))
+}
+
+func (item BatchDisbursementItem) DisbursementRequest() DisbursementRequest {
+	return DisbursementRequest{
+		ExternalID:        item.ExternalID,
+		Amount:            item.Amount,
+		BankCode:          item.BankCode,
+		AccountHolderName: item.BankAccountName,
+		AccountNumber:     item.BankAccountNumber,
+		Description:       item.Description,
+		EmailTo:           item.EmailTo,
+		EmailCC:           item.EmailCC,
+		EmailBCC:          item.EmailBCC,
+	}
+}
+
+func BuildBatchItemResult(item BatchDisbursementItem, status string) BatchDisbursementItemResult {
+	status = NormalizeStatus(status)
+	now := time.Now().Format(time.RFC3339)
+	result := BatchDisbursementItemResult{
+		ID:                DisbursementID(item.ExternalID),
+		Created:           now,
+		Updated:           now,
+		ExternalID:        item.ExternalID,
+		Amount:            item.Amount,
+		BankCode:          item.BankCode,
+		BankAccountName:   item.BankAccountName,
+		BankAccountNumber: item.BankAccountNumber,
+		Description:       item.Description,
+		Status:            status,
+		EmailTo:           item.EmailTo,
+		EmailCC:           item.EmailCC,
+		EmailBCC:          item.EmailBCC,
+	}
+	if status == StatusCompleted {
+		result.BankReference = "xamock_ref_" + ShortHash(result.ID)
+		result.ValidName = item.BankAccountName
+	} else {
+		result.FailureCode = FailureUnknownBankNetworkError
+	}
+	return result
+}
@@ -0,0 +1,242 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	CardTokenVerified = "VERIFIED"
+	CardTokenInReview = "IN_REVIEW"
+	CardTokenFailed   = "FAILED"
+)
+
+const (
+	CardChargeAuthorized = "AUTHORIZED"
+	CardChargeCaptured   = "CAPTURED"
+	CardChargeFailed     = "FAILED"
+)
+
+const (
+	CardFailureDeclined       = "CARD_DECLINED"
+	CardFailureInsufficient   = "INSUFFICIENT_BALANCE"
+	CardFailureAuthentication = "AUTHENTICATION_FAILED"
+	CardThreeDSPath           = "/xendit/credit_card_3ds/"
+)
+
+// TestCard is how the mock treats one of the test card numbers.
+type TestCard struct {
+	ThreeDS     bool
+	FailureCode string
+}
+
+// TestCards are the card numbers with scripted behaviour. Any other number
+// that passes the Luhn check charges successfully without 3DS.
+var TestCards = map[string]TestCard{
+	"4000000000000002": {},
+	"5200000000000007": {},
+	"4000000000001091": {ThreeDS: true},
+	"5200000000001096": {ThreeDS: true},
+	"4000000000000036": {FailureCode: CardFailureDeclined},
+	"4000000000000069": {FailureCode: CardFailureInsufficient},
+}
+
+type CardTokenRequest struct {
+	CardNumber         string `json:"card_number"`
+	CardExpMonth       string `json:"card_exp_month"`
+	CardExpYear        string `json:"card_exp_year"`
+	CardCVN            string `json:"card_cvn,omitempty"`
+	IsMultipleUse      bool   `json:"is_multiple_use"`
+	ShouldAuthenticate *bool  `json:"should_authenticate,omitempty"`
+	Amount             int    `json:"amount,omitempty"`
+	Currency           string `json:"currency,omitempty"`
+	ForUserID          string `json:"-"`
+}
+
+type CardInfo struct {
+	Bank    string `json:"bank"`
+	Country string `json:"country"`
+	Type    string `json:"type"`
+	Brand   string `json:"brand"`
+}
+
+type CardToken struct {
+	ID                     string   `json:"id"`
+	BusinessID             string   `json:"business_id"`
+	Status                 string   `json:"status"`
+	AuthenticationID       string   `json:"authentication_id,omitempty"`
+	MaskedCardNumber       string   `json:"masked_card_number"`
+	PayerAuthenticationURL string   `json:"payer_authentication_url,omitempty"`
+	IsMultipleUse          bool     `json:"is_multiple_use"`
+	CardInfo               CardInfo `json:"card_info"`
+	FailureReason          string   `json:"failure_reason,omitempty"`
+	Created                string   `json:"created"`
+}
+
+type CardAuthenticationRequest struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type CardAuthentication struct {
+	ID                     string `json:"id"`
+	CreditCardTokenID      string `json:"credit_card_token_id"`
+	Status                 string `json:"status"`
+	Amount                 int    `json:"amount"`
+	Currency               string `json:"currency"`
+	PayerAuthenticationURL string `json:"payer_authentication_url,omitempty"`
+	FailureReason          string `json:"failure_reason,omitempty"`
+	Created                string `json:"created"`
+}
+
+type CardChargeRequest struct {
+	TokenID          string         `json:"token_id"`
+	ExternalID       string         `json:"external_id"`
+	Amount           int            `json:"amount"`
+	AuthenticationID string         `json:"authentication_id,omitempty"`
+	Capture          *bool          `json:"capture,omitempty"`
+	CardCVN          string         `json:"card_cvn,omitempty"`
+	Descriptor       string         `json:"descriptor,omitempty"`
+	Currency         string         `json:"currency,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	ForUserID        string         `json:"-"`
+	Session          string         `json:"-"`
+}
+
+type CardCaptureRequest struct {
+	Amount int `json:"amount"`
+}
+
+type CardCharge struct {
+	ID                    string         `json:"id"`
+	ExternalID            string         `json:"external_id"`
+	BusinessID            string         `json:"business_id"`
+	CreditCardTokenID     string         `json:"credit_card_token_id"`
+	Status                string         `json:"status"`
+	ChargeType            string         `json:"charge_type"`
+	MerchantReferenceCode string         `json:"merchant_reference_code"`
+	MaskedCardNumber      string         `json:"masked_card_number"`
+	CardBrand             string         `json:"card_brand"`
+	CardType              string         `json:"card_type"`
+	AuthorizedAmount      int            `json:"authorized_amount"`
+	CaptureAmount         int            `json:"capture_amount,omitempty"`
+	Currency              string         `json:"currency"`
+	Descriptor            string         `json:"descriptor,omitempty"`
+	FailureReason         string         `json:"failure_reason,omitempty"`
+	ECI                   string         `json:"eci,omitempty"`
+	Metadata              map[string]any `json:"metadata,omitempty"`
+	Created               string         `json:"created"`
+	Updated               string         `json:"updated"`
+}
+
+// LuhnValid reports whether number is all digits and passes the Luhn check.
+func LuhnValid(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range number {
+		c := number[len(number)-1-i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// CardBrand guesses the network from the card number's prefix.
+func CardBrand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "AMEX"
+	case strings.HasPrefix(number, "35"):
+		return "JCB"
+	case strings.HasPrefix(number, "4"):
+		return "VISA"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "MASTERCARD"
+	}
+	return "UNKNOWN"
+}
+
+func MaskCardNumber(number string) string {
+	if len(number) < 10 {
+		return number
+	}
+	return number[:6] + strings.Repeat("X", len(number)-10) + number[len(number)-4:]
+}
+
+func BuildCardToken(req CardTokenRequest, businessID string) CardToken {
+	now := time.Now()
+	return CardToken{
+		ID:               "cctok_" + ShortHash(req.CardNumber+":"+now.Format(time.RFC3339Nano)),
+		BusinessID:       businessID,
+		Status:           CardTokenVerified,
+		MaskedCardNumber: MaskCardNumber(req.CardNumber),
+		IsMultipleUse:    req.IsMultipleUse,
+		CardInfo:         CardInfo{Bank: "BCA", Country: "ID", Type: "CREDIT", Brand: CardBrand(req.CardNumber)},
+		Created:          now.Format(time.RFC3339),
+	}
+}
+
+// BuildCardAuthentication starts 3DS for the token when the card needs it;
+// otherwise the authentication is VERIFIED straight away.
+func BuildCardAuthentication(tokenID string, amount int, currency string, threeDS bool, baseURL string) CardAuthentication {
+	now := time.Now()
+	if currency == "" {
+		currency = "IDR"
+	}
+	id := "ccauth_" + ShortHash(tokenID+":"+now.Format(time.RFC3339Nano))
+	auth := CardAuthentication{
+		ID:                id,
+		CreditCardTokenID: tokenID,
+		Status:            CardTokenVerified,
+		Amount:            amount,
+		Currency:          currency,
+		Created:           now.Format(time.RFC3339),
+	}
+	if threeDS {
+		auth.Status = CardTokenInReview
+		auth.PayerAuthenticationURL = baseURL + CardThreeDSPath + id
+	}
+	return auth
+}
+
+func BuildCardCharge(req CardChargeRequest, token CardToken, businessID string) CardCharge {
+	now := time.Now()
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	chargeType := "SINGLE_USE_TOKEN"
+	if token.IsMultipleUse {
+		chargeType = "MULTIPLE_USE_TOKEN"
+	}
+	id := "ccchg_" + ShortHash(req.ExternalID+":"+now.Format(time.RFC3339Nano))
+	return CardCharge{
+		ID:                    id,
+		ExternalID:            req.ExternalID,
+		BusinessID:            businessID,
+		CreditCardTokenID:     token.ID,
+		Status:                CardChargeAuthorized,
+		ChargeType:            chargeType,
+		MerchantReferenceCode: NumericCode(id, 12),
+		MaskedCardNumber:      token.MaskedCardNumber,
+		CardBrand:             token.CardInfo.Brand,
+		CardType:              token.CardInfo.Type,
+		AuthorizedAmount:      req.Amount,
+		Currency:              currency,
+		Descriptor:            req.Descriptor,
+		Metadata:              req.Metadata,
+		Created:               now.Format(time.RFC3339),
+		Updated:               now.Format(time.RFC3339),
+	}
+}
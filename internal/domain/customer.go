@@ -0,0 +1,102 @@
+package domain
+
+import "time"
+
+const (
+	CustomerIndividual = "INDIVIDUAL"
+	CustomerBusiness   = "BUSINESS"
+)
+
+type CustomerIndividualDetail struct {
+	GivenNames   string `json:"given_names"`
+	Surname      string `json:"surname,omitempty"`
+	Nationality  string `json:"nationality,omitempty"`
+	PlaceOfBirth string `json:"place_of_birth,omitempty"`
+	DateOfBirth  string `json:"date_of_birth,omitempty"`
+	Gender       string `json:"gender,omitempty"`
+}
+
+type CustomerBusinessDetail struct {
+	BusinessName       string `json:"business_name"`
+	BusinessType       string `json:"business_type,omitempty"`
+	NatureOfBusiness   string `json:"nature_of_business,omitempty"`
+	DateOfRegistration string `json:"date_of_registration,omitempty"`
+}
+
+type CustomerAddress struct {
+	Country     string `json:"country"`
+	StreetLine1 string `json:"street_line1,omitempty"`
+	StreetLine2 string `json:"street_line2,omitempty"`
+	City        string `json:"city,omitempty"`
+	Province    string `json:"province_state,omitempty"`
+	PostalCode  string `json:"postal_code,omitempty"`
+	Category    string `json:"category,omitempty"`
+	IsPrimary   bool   `json:"is_primary"`
+}
+
+type CustomerRequest struct {
+	ReferenceID      string                    `json:"reference_id"`
+	Type             string                    `json:"type"`
+	IndividualDetail *CustomerIndividualDetail `json:"individual_detail,omitempty"`
+	BusinessDetail   *CustomerBusinessDetail   `json:"business_detail,omitempty"`
+	Email            string                    `json:"email,omitempty"`
+	MobileNumber     string                    `json:"mobile_number,omitempty"`
+	PhoneNumber      string                    `json:"phone_number,omitempty"`
+	Description      string                    `json:"description,omitempty"`
+	Addresses        []CustomerAddress         `json:"addresses,omitempty"`
+	Metadata         map[string]any            `json:"metadata,omitempty"`
+	ForUserID        string                    `json:"-"`
+}
+
+// CustomerUpdate holds the fields PATCH may change; nil fields are kept.
+type CustomerUpdate struct {
+	IndividualDetail *CustomerIndividualDetail `json:"individual_detail"`
+	BusinessDetail   *CustomerBusinessDetail   `json:"business_detail"`
+	Email            *string                   `json:"email"`
+	MobileNumber     *string                   `json:"mobile_number"`
+	PhoneNumber      *string                   `json:"phone_number"`
+	Description      *string                   `json:"description"`
+	Addresses        []CustomerAddress         `json:"addresses"`
+	Metadata         map[string]any            `json:"metadata"`
+}
+
+type Customer struct {
+	ID               string                    `json:"id"`
+	BusinessID       string                    `json:"business_id"`
+	ReferenceID      string                    `json:"reference_id"`
+	Type             string                    `json:"type"`
+	IndividualDetail *CustomerIndividualDetail `json:"individual_detail"`
+	BusinessDetail   *CustomerBusinessDetail   `json:"business_detail"`
+	Email            string                    `json:"email,omitempty"`
+	MobileNumber     string                    `json:"mobile_number,omitempty"`
+	PhoneNumber      string                    `json:"phone_number,omitempty"`
+	Description      string                    `json:"description,omitempty"`
+	Addresses        []CustomerAddress         `json:"addresses"`
+	Metadata         map[string]any            `json:"metadata,omitempty"`
+	Created          string                    `json:"created"`
+	Updated          string                    `json:"updated"`
+}
+
+func BuildCustomer(req CustomerRequest, businessID string) Customer {
+	now := time.Now()
+	addresses := req.Addresses
+	if addresses == nil {
+		addresses = []CustomerAddress{}
+	}
+	return Customer{
+		ID:               "cust-" + ShortHash(req.ReferenceID+":"+now.Format(time.RFC3339Nano)),
+		BusinessID:       businessID,
+		ReferenceID:      req.ReferenceID,
+		Type:             req.Type,
+		IndividualDetail: req.IndividualDetail,
+		BusinessDetail:   req.BusinessDetail,
+		Email:            req.Email,
+		MobileNumber:     req.MobileNumber,
+		PhoneNumber:      req.PhoneNumber,
+		Description:      req.Description,
+		Addresses:        addresses,
+		Metadata:         req.Metadata,
+		Created:          now.Format(time.RFC3339),
+		Updated:          now.Format(time.RFC3339),
+	}
+}
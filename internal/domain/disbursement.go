@@ -10,6 +10,12 @@ import (
 const (
 	StatusCompleted = "COMPLETED"
 	StatusFailed    = "FAILED"
+	StatusReversed  = "REVERSED"
+)
+
+const (
+	FailureUnknownBankNetworkError = "UNKNOWN_BANK_NETWORK_ERROR"
+	FailureInsufficientBalance     = "INSUFFICIENT_BALANCE"
 )
 
 type DisbursementRequest struct {
@@ -22,6 +28,8 @@ type DisbursementRequest struct {
 	EmailTo           []string `json:"email_to,omitempty"`
 	EmailCC           []string `json:"email_cc,omitempty"`
 	EmailBCC          []string `json:"email_bcc,omitempty"`
+	ForUserID         string   `json:"-"`
+	Session           string   `json:"-"`
 }
 
 type DisbursementResponse struct {
@@ -65,6 +73,9 @@ func NormalizeStatus(status string) string {
 	if status == StatusFailed {
 		return StatusFailed
 	}
+	if status == StatusReversed {
+		return StatusReversed
+	}
 	return StatusFailed
 }
 
@@ -81,6 +92,10 @@ func WebhookID(disbursementID, status string) string {
 	return "wh_" + ShortHash(disbursementID+":"+status)
 }
 
+func DuplicateWebhookID(disbursementID, status string, n int) string {
+	return WebhookID(disbursementID, fmt.Sprintf("%s:%d", status, n))
+}
+
 func DefaultDisbursementRequest() DisbursementRequest {
 	return DisbursementRequest{
 		ExternalID:        fmt.Sprintf("xamock_ext_%s", ShortHash(time.Now().Format(time.RFC3339Nano))),
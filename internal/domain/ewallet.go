@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	EWalletStatusPending   = "PENDING"
+	EWalletStatusSucceeded = "SUCCEEDED"
+	EWalletStatusFailed    = "FAILED"
+	EWalletStatusVoided    = "VOIDED"
+	EWalletStatusRefunded  = "REFUNDED"
+)
+
+const (
+	EWalletOneTimePayment   = "ONE_TIME_PAYMENT"
+	EWalletTokenizedPayment = "TOKENIZED_PAYMENT"
+)
+
+const (
+	EWalletChannelOVO       = "ID_OVO"
+	EWalletChannelDANA      = "ID_DANA"
+	EWalletChannelShopeePay = "ID_SHOPEEPAY"
+	EWalletChannelLinkAja   = "ID_LINKAJA"
+)
+
+const (
+	EWalletEventCapture        = "ewallet.capture"
+	EWalletFailureUserDeclined = "USER_DECLINED_PAYMENT"
+	EWalletCheckoutPath        = "/xendit/ewallets/checkout/"
+)
+
+// EWalletRedirectChannels lists the supported channels and whether a one time
+// payment sends the payer to the channel's checkout page. OVO pushes the
+// payment to the payer's app instead.
+var EWalletRedirectChannels = map[string]bool{
+	EWalletChannelOVO:       false,
+	EWalletChannelDANA:      true,
+	EWalletChannelShopeePay: true,
+	EWalletChannelLinkAja:   true,
+}
+
+type EWalletChannelProperties struct {
+	MobileNumber       string `json:"mobile_number,omitempty"`
+	SuccessRedirectURL string `json:"success_redirect_url,omitempty"`
+	FailureRedirectURL string `json:"failure_redirect_url,omitempty"`
+	CancelRedirectURL  string `json:"cancel_redirect_url,omitempty"`
+	RedirectURL        string `json:"redirect_url,omitempty"`
+}
+
+type EWalletChargeRequest struct {
+	ReferenceID       string                   `json:"reference_id"`
+	Currency          string                   `json:"currency"`
+	Amount            int                      `json:"amount"`
+	CheckoutMethod    string                   `json:"checkout_method"`
+	ChannelCode       string                   `json:"channel_code"`
+	ChannelProperties EWalletChannelProperties `json:"channel_properties"`
+	PaymentMethodID   string                   `json:"payment_method_id,omitempty"`
+	CustomerID        string                   `json:"customer_id,omitempty"`
+	Basket            []map[string]any         `json:"basket,omitempty"`
+	Metadata          map[string]any           `json:"metadata,omitempty"`
+	ForUserID         string                   `json:"-"`
+	Session           string                   `json:"-"`
+}
+
+type EWalletActions struct {
+	DesktopWebCheckoutURL     string `json:"desktop_web_checkout_url"`
+	MobileWebCheckoutURL      string `json:"mobile_web_checkout_url"`
+	MobileDeeplinkCheckoutURL string `json:"mobile_deeplink_checkout_url"`
+	QRCheckoutString          string `json:"qr_checkout_string"`
+}
+
+type EWalletCharge struct {
+	ID                 string                   `json:"id"`
+	BusinessID         string                   `json:"business_id"`
+	ReferenceID        string                   `json:"reference_id"`
+	Status             string                   `json:"status"`
+	Currency           string                   `json:"currency"`
+	ChargeAmount       int                      `json:"charge_amount"`
+	CaptureAmount      int                      `json:"capture_amount"`
+	RefundedAmount     int                      `json:"refunded_amount"`
+	CheckoutMethod     string                   `json:"checkout_method"`
+	ChannelCode        string                   `json:"channel_code"`
+	ChannelProperties  EWalletChannelProperties `json:"channel_properties"`
+	Actions            *EWalletActions          `json:"actions"`
+	IsRedirectRequired bool                     `json:"is_redirect_required"`
+	CallbackURL        string                   `json:"callback_url"`
+	Created            string                   `json:"created"`
+	Updated            string                   `json:"updated"`
+	VoidStatus         string                   `json:"void_status,omitempty"`
+	VoidedAt           string                   `json:"voided_at,omitempty"`
+	CaptureNow         bool                     `json:"capture_now"`
+	CustomerID         string                   `json:"customer_id,omitempty"`
+	PaymentMethodID    string                   `json:"payment_method_id,omitempty"`
+	FailureCode        string                   `json:"failure_code,omitempty"`
+	Basket             []map[string]any         `json:"basket,omitempty"`
+	Metadata           map[string]any           `json:"metadata,omitempty"`
+}
+
+type EWalletRefundRequest struct {
+	ReferenceID string `json:"reference_id,omitempty"`
+	Amount      int    `json:"amount"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type EWalletRefund struct {
+	ID            string `json:"id"`
+	ChargeID      string `json:"charge_id"`
+	Status        string `json:"status"`
+	Currency      string `json:"currency"`
+	ChannelCode   string `json:"channel_code"`
+	CaptureAmount int    `json:"capture_amount"`
+	RefundAmount  int    `json:"refund_amount"`
+	Reason        string `json:"reason,omitempty"`
+	Created       string `json:"created"`
+	Updated       string `json:"updated"`
+}
+
+type EWalletWebhook struct {
+	Event      string        `json:"event"`
+	BusinessID string        `json:"business_id"`
+	Created    string        `json:"created"`
+	Data       EWalletCharge `json:"data"`
+}
+
+// Country is the country prefix of the charge's channel code, "ID" for
+// ID_DANA.
+func (c EWalletCharge) Country() string {
+	return strings.SplitN(c.ChannelCode, "_", 2)[0]
+}
+
+func EWalletChargeID(referenceID string) string {
+	return "ewc_" + ShortHash(referenceID+":"+time.Now().Format(time.RFC3339Nano))
+}
+
+// BuildEWalletCharge builds the PENDING charge. Redirect channels get
+// checkout actions pointing at the mock's own checkout page under baseURL.
+func BuildEWalletCharge(req EWalletChargeRequest, businessID, baseURL string) EWalletCharge {
+	now := time.Now().Format(time.RFC3339)
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	charge := EWalletCharge{
+		ID:                EWalletChargeID(req.ReferenceID),
+		BusinessID:        businessID,
+		ReferenceID:       req.ReferenceID,
+		Status:            EWalletStatusPending,
+		Currency:          currency,
+		ChargeAmount:      req.Amount,
+		CaptureAmount:     req.Amount,
+		CheckoutMethod:    req.CheckoutMethod,
+		ChannelCode:       req.ChannelCode,
+		ChannelProperties: req.ChannelProperties,
+		Created:           now,
+		Updated:           now,
+		CaptureNow:        true,
+		CustomerID:        req.CustomerID,
+		PaymentMethodID:   req.PaymentMethodID,
+		Basket:            req.Basket,
+		Metadata:          req.Metadata,
+	}
+	if req.CheckoutMethod == EWalletOneTimePayment && EWalletRedirectChannels[req.ChannelCode] {
+		checkoutURL := baseURL + EWalletCheckoutPath + charge.ID
+		charge.IsRedirectRequired = true
+		charge.Actions = &EWalletActions{}
+		switch req.ChannelCode {
+		case EWalletChannelShopeePay:
+			charge.Actions.MobileDeeplinkCheckoutURL = checkoutURL + "?flow=deeplink"
+			charge.Actions.QRCheckoutString = "00020101021226" + NumericCode(charge.ID, 20)
+		default:
+			charge.Actions.DesktopWebCheckoutURL = checkoutURL
+			charge.Actions.MobileWebCheckoutURL = checkoutURL + "?flow=mobile"
+		}
+	}
+	return charge
+}
+
+// BuildEWalletRefund shows a refund of charge in the legacy e-wallet refund
+// format.
+func BuildEWalletRefund(charge EWalletCharge, refund Refund) EWalletRefund {
+	return EWalletRefund{
+		ID:            refund.ID,
+		ChargeID:      charge.ID,
+		Status:        refund.Status,
+		Currency:      refund.Currency,
+		ChannelCode:   refund.ChannelCode,
+		CaptureAmount: charge.CaptureAmount,
+		RefundAmount:  refund.Amount,
+		Reason:        refund.Reason,
+		Created:       refund.Created,
+		Updated:       refund.Updated,
+	}
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"crypto/md5"
+	"time"
+)
+
+const (
+	InvoiceStatusPending = "PENDING"
+	InvoiceStatusPaid    = "PAID"
+	InvoiceStatusSettled = "SETTLED"
+	InvoiceStatusExpired = "EXPIRED"
+)
+
+const (
+	DefaultInvoiceDuration = 86400
+	InvoiceCheckoutURL     = "https://checkout-staging.xendit.co/web/"
+)
+
+type InvoiceItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
+	Category string `json:"category,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type InvoiceRequest struct {
+	ExternalID         string         `json:"external_id"`
+	Amount             int            `json:"amount"`
+	PayerEmail         string         `json:"payer_email,omitempty"`
+	Description        string         `json:"description,omitempty"`
+	InvoiceDuration    int            `json:"invoice_duration,omitempty"`
+	Currency           string         `json:"currency,omitempty"`
+	SuccessRedirectURL string         `json:"success_redirect_url,omitempty"`
+	FailureRedirectURL string         `json:"failure_redirect_url,omitempty"`
+	PaymentMethods     []string       `json:"payment_methods,omitempty"`
+	Items              []InvoiceItem  `json:"items,omitempty"`
+	Customer           map[string]any `json:"customer,omitempty"`
+	Metadata           map[string]any `json:"metadata,omitempty"`
+	ForUserID          string         `json:"-"`
+	Session            string         `json:"-"`
+}
+
+type Invoice struct {
+	ID                 string         `json:"id"`
+	ExternalID         string         `json:"external_id"`
+	UserID             string         `json:"user_id"`
+	Status             string         `json:"status"`
+	MerchantName       string         `json:"merchant_name"`
+	Amount             int            `json:"amount"`
+	PayerEmail         string         `json:"payer_email,omitempty"`
+	Description        string         `json:"description,omitempty"`
+	ExpiryDate         string         `json:"expiry_date"`
+	InvoiceURL         string         `json:"invoice_url"`
+	Currency           string         `json:"currency"`
+	SuccessRedirectURL string         `json:"success_redirect_url,omitempty"`
+	FailureRedirectURL string         `json:"failure_redirect_url,omitempty"`
+	PaymentMethods     []string       `json:"payment_methods,omitempty"`
+	Items              []InvoiceItem  `json:"items,omitempty"`
+	Customer           map[string]any `json:"customer,omitempty"`
+	Metadata           map[string]any `json:"metadata,omitempty"`
+	PaymentMethod      string         `json:"payment_method,omitempty"`
+	PaymentChannel     string         `json:"payment_channel,omitempty"`
+	PaymentDestination string         `json:"payment_destination,omitempty"`
+	PaidAmount         int            `json:"paid_amount,omitempty"`
+	PaidAt             string         `json:"paid_at,omitempty"`
+	Created            string         `json:"created"`
+	Updated            string         `json:"updated"`
+}
+
+// InvoicePayment is how a simulated payment settles an invoice.
+type InvoicePayment struct {
+	PaymentMethod  string `json:"payment_method"`
+	PaymentChannel string `json:"payment_channel"`
+	Amount         int    `json:"amount"`
+}
+
+type InvoiceCallbackPayload struct {
+	ID                     string `json:"id"`
+	ExternalID             string `json:"external_id"`
+	UserID                 string `json:"user_id"`
+	IsHigh                 bool   `json:"is_high"`
+	Status                 string `json:"status"`
+	MerchantName           string `json:"merchant_name"`
+	Amount                 int    `json:"amount"`
+	PayerEmail             string `json:"payer_email,omitempty"`
+	Description            string `json:"description,omitempty"`
+	Currency               string `json:"currency"`
+	PaymentMethod          string `json:"payment_method,omitempty"`
+	PaymentChannel         string `json:"payment_channel,omitempty"`
+	PaymentDestination     string `json:"payment_destination,omitempty"`
+	BankCode               string `json:"bank_code,omitempty"`
+	PaidAmount             int    `json:"paid_amount,omitempty"`
+	AdjustedReceivedAmount int    `json:"adjusted_received_amount,omitempty"`
+	PaidAt                 string `json:"paid_at,omitempty"`
+	Created                string `json:"created"`
+	Updated                string `json:"updated"`
+}
+
+func InvoiceID(externalID string) string {
+	return "inv_" + ShortHash(externalID+":"+time.Now().Format(time.RFC3339Nano))
+}
+
+// NumericCode derives a stable string of length digits from value, for VA
+// numbers and payment codes.
+func NumericCode(value string, length int) string {
+	digits := make([]byte, 0, length)
+	seed := []byte(value)
+	for len(digits) < length {
+		hash := md5.Sum(seed)
+		for _, b := range hash {
+			if len(digits) == length {
+				break
+			}
+			digits = append(digits, '0'+b%10)
+		}
+		seed = hash[:]
+	}
+	return string(digits)
+}
+
+func BuildInvoice(req InvoiceRequest, userID, merchantName string) Invoice {
+	now := time.Now()
+	duration := req.InvoiceDuration
+	if duration <= 0 {
+		duration = DefaultInvoiceDuration
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	id := InvoiceID(req.ExternalID)
+	return Invoice{
+		ID:                 id,
+		ExternalID:         req.ExternalID,
+		UserID:             userID,
+		Status:             InvoiceStatusPending,
+		MerchantName:       merchantName,
+		Amount:             req.Amount,
+		PayerEmail:         req.PayerEmail,
+		Description:        req.Description,
+		ExpiryDate:         now.Add(time.Duration(duration) * time.Second).Format(time.RFC3339),
+		InvoiceURL:         InvoiceCheckoutURL + id,
+		Currency:           currency,
+		SuccessRedirectURL: req.SuccessRedirectURL,
+		FailureRedirectURL: req.FailureRedirectURL,
+		PaymentMethods:     req.PaymentMethods,
+		Items:              req.Items,
+		Customer:           req.Customer,
+		Metadata:           req.Metadata,
+		Created:            now.Format(time.RFC3339),
+		Updated:            now.Format(time.RFC3339),
+	}
+}
+
+func BuildInvoiceCallbackPayload(invoice Invoice) InvoiceCallbackPayload {
+	payload := InvoiceCallbackPayload{
+		ID:                     invoice.ID,
+		ExternalID:             invoice.ExternalID,
+		UserID:                 invoice.UserID,
+		Status:                 invoice.Status,
+		MerchantName:           invoice.MerchantName,
+		Amount:                 invoice.Amount,
+		PayerEmail:             invoice.PayerEmail,
+		Description:            invoice.Description,
+		Currency:               invoice.Currency,
+		PaymentMethod:          invoice.PaymentMethod,
+		PaymentChannel:         invoice.PaymentChannel,
+		PaymentDestination:     invoice.PaymentDestination,
+		PaidAmount:             invoice.PaidAmount,
+		AdjustedReceivedAmount: invoice.PaidAmount,
+		PaidAt:                 invoice.PaidAt,
+		Created:                invoice.Created,
+		Updated:                invoice.Updated,
+	}
+	if invoice.PaymentMethod == "BANK_TRANSFER" {
+		payload.BankCode = invoice.PaymentChannel
+	}
+	return payload
+}
@@ -0,0 +1,301 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	PaymentRequestRequiresAction = "REQUIRES_ACTION"
+	PaymentRequestPending        = "PENDING"
+	PaymentRequestSucceeded      = "SUCCEEDED"
+	PaymentRequestFailed         = "FAILED"
+	PaymentRequestCanceled       = "CANCELED"
+)
+
+const (
+	PaymentMethodRequiresAction = "REQUIRES_ACTION"
+	PaymentMethodActive         = "ACTIVE"
+	PaymentMethodFailed         = "FAILED"
+)
+
+const (
+	PaymentMethodEWallet        = "EWALLET"
+	PaymentMethodDirectDebit    = "DIRECT_DEBIT"
+	PaymentMethodCard           = "CARD"
+	PaymentMethodVirtualAccount = "VIRTUAL_ACCOUNT"
+	PaymentMethodQRCode         = "QR_CODE"
+	PaymentMethodOverTheCounter = "OVER_THE_COUNTER"
+)
+
+const (
+	PaymentMethodOneTimeUse  = "ONE_TIME_USE"
+	PaymentMethodMultipleUse = "MULTIPLE_USE"
+)
+
+const (
+	PaymentEventSucceeded       = "payment.succeeded"
+	PaymentEventFailed          = "payment.failed"
+	PaymentMethodEventActivated = "payment_method.activated"
+	PaymentFailureDeclined      = "AUTHENTICATION_FAILED"
+	PaymentActionPath           = "/xendit/payment_actions/"
+)
+
+type PaymentChannel struct {
+	ChannelCode       string         `json:"channel_code"`
+	ChannelProperties map[string]any `json:"channel_properties,omitempty"`
+}
+
+type PaymentMethodRequest struct {
+	Type           string          `json:"type"`
+	Reusability    string          `json:"reusability"`
+	Country        string          `json:"country,omitempty"`
+	ReferenceID    string          `json:"reference_id,omitempty"`
+	CustomerID     string          `json:"customer_id,omitempty"`
+	Description    string          `json:"description,omitempty"`
+	EWallet        *PaymentChannel `json:"ewallet,omitempty"`
+	DirectDebit    *PaymentChannel `json:"direct_debit,omitempty"`
+	Card           *PaymentChannel `json:"card,omitempty"`
+	VirtualAccount *PaymentChannel `json:"virtual_account,omitempty"`
+	QRCode         *PaymentChannel `json:"qr_code,omitempty"`
+	OverTheCounter *PaymentChannel `json:"over_the_counter,omitempty"`
+	Metadata       map[string]any  `json:"metadata,omitempty"`
+	ForUserID      string          `json:"-"`
+	Session        string          `json:"-"`
+}
+
+// Channel returns the channel object that matches the method's type.
+func (req PaymentMethodRequest) Channel() *PaymentChannel {
+	switch req.Type {
+	case PaymentMethodEWallet:
+		return req.EWallet
+	case PaymentMethodDirectDebit:
+		return req.DirectDebit
+	case PaymentMethodCard:
+		return req.Card
+	case PaymentMethodVirtualAccount:
+		return req.VirtualAccount
+	case PaymentMethodQRCode:
+		return req.QRCode
+	case PaymentMethodOverTheCounter:
+		return req.OverTheCounter
+	}
+	return nil
+}
+
+type PaymentAction struct {
+	Action  string `json:"action"`
+	URLType string `json:"url_type"`
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+}
+
+type PaymentMethod struct {
+	ID             string          `json:"id"`
+	BusinessID     string          `json:"business_id"`
+	Type           string          `json:"type"`
+	Reusability    string          `json:"reusability"`
+	Status         string          `json:"status"`
+	Country        string          `json:"country"`
+	ReferenceID    string          `json:"reference_id,omitempty"`
+	CustomerID     string          `json:"customer_id,omitempty"`
+	Description    string          `json:"description,omitempty"`
+	EWallet        *PaymentChannel `json:"ewallet,omitempty"`
+	DirectDebit    *PaymentChannel `json:"direct_debit,omitempty"`
+	Card           *PaymentChannel `json:"card,omitempty"`
+	VirtualAccount *PaymentChannel `json:"virtual_account,omitempty"`
+	QRCode         *PaymentChannel `json:"qr_code,omitempty"`
+	OverTheCounter *PaymentChannel `json:"over_the_counter,omitempty"`
+	Actions        []PaymentAction `json:"actions"`
+	FailureCode    string          `json:"failure_code,omitempty"`
+	Metadata       map[string]any  `json:"metadata,omitempty"`
+	Created        string          `json:"created"`
+	Updated        string          `json:"updated"`
+}
+
+// ChannelCode returns the code of the method's channel.
+func (pm PaymentMethod) ChannelCode() string {
+	for _, channel := range []*PaymentChannel{pm.EWallet, pm.DirectDebit, pm.Card, pm.VirtualAccount, pm.QRCode, pm.OverTheCounter} {
+		if channel != nil {
+			return channel.ChannelCode
+		}
+	}
+	return ""
+}
+
+// NeedsAuthentication reports whether paying with the method sends the
+// customer through an authentication step: an e-wallet redirect (OVO pushes
+// to the app instead), a direct debit OTP or card 3DS.
+func (pm PaymentMethod) NeedsAuthentication() bool {
+	switch pm.Type {
+	case PaymentMethodEWallet:
+		return pm.ChannelCode() != "OVO"
+	case PaymentMethodDirectDebit, PaymentMethodCard:
+		return true
+	}
+	return false
+}
+
+// NeedsLinking reports whether a new method must be authorised by the
+// customer before it can be used, as reusable e-wallets and direct debits are.
+func (pm PaymentMethod) NeedsLinking() bool {
+	return pm.Reusability == PaymentMethodMultipleUse && (pm.Type == PaymentMethodEWallet || pm.Type == PaymentMethodDirectDebit)
+}
+
+type PaymentRequestRequest struct {
+	ReferenceID     string                `json:"reference_id"`
+	Amount          int                   `json:"amount"`
+	Currency        string                `json:"currency"`
+	Country         string                `json:"country,omitempty"`
+	PaymentMethod   *PaymentMethodRequest `json:"payment_method,omitempty"`
+	PaymentMethodID string                `json:"payment_method_id,omitempty"`
+	CustomerID      string                `json:"customer_id,omitempty"`
+	Description     string                `json:"description,omitempty"`
+	CaptureMethod   string                `json:"capture_method,omitempty"`
+	Metadata        map[string]any        `json:"metadata,omitempty"`
+	ForUserID       string                `json:"-"`
+	Session         string                `json:"-"`
+}
+
+type PaymentRequest struct {
+	ID            string          `json:"id"`
+	BusinessID    string          `json:"business_id"`
+	ReferenceID   string          `json:"reference_id"`
+	CustomerID    string          `json:"customer_id,omitempty"`
+	Amount        int             `json:"amount"`
+	Currency      string          `json:"currency"`
+	Country       string          `json:"country"`
+	Description   string          `json:"description,omitempty"`
+	CaptureMethod string          `json:"capture_method"`
+	PaymentMethod PaymentMethod   `json:"payment_method"`
+	Status        string          `json:"status"`
+	Actions       []PaymentAction `json:"actions"`
+	FailureCode   string          `json:"failure_code,omitempty"`
+	Metadata      map[string]any  `json:"metadata,omitempty"`
+	Created       string          `json:"created"`
+	Updated       string          `json:"updated"`
+}
+
+type Payment struct {
+	ID               string         `json:"id"`
+	PaymentRequestID string         `json:"payment_request_id"`
+	ReferenceID      string         `json:"reference_id"`
+	BusinessID       string         `json:"business_id"`
+	CustomerID       string         `json:"customer_id,omitempty"`
+	Amount           int            `json:"amount"`
+	Currency         string         `json:"currency"`
+	Country          string         `json:"country"`
+	Status           string         `json:"status"`
+	PaymentMethod    PaymentMethod  `json:"payment_method"`
+	FailureCode      string         `json:"failure_code,omitempty"`
+	Description      string         `json:"description,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	Created          string         `json:"created"`
+	Updated          string         `json:"updated"`
+}
+
+type PaymentWebhook struct {
+	Event      string  `json:"event"`
+	BusinessID string  `json:"business_id"`
+	Created    string  `json:"created"`
+	Data       Payment `json:"data"`
+}
+
+type PaymentMethodWebhook struct {
+	Event      string        `json:"event"`
+	BusinessID string        `json:"business_id"`
+	Created    string        `json:"created"`
+	Data       PaymentMethod `json:"data"`
+}
+
+func BuildPaymentMethod(req PaymentMethodRequest, businessID string) PaymentMethod {
+	now := time.Now().Format(time.RFC3339)
+	country := req.Country
+	if country == "" {
+		country = "ID"
+	}
+	reusability := req.Reusability
+	if reusability == "" {
+		reusability = PaymentMethodOneTimeUse
+	}
+	return PaymentMethod{
+		ID:             "pm-" + ShortHash(req.Type+":"+req.ReferenceID+":"+time.Now().Format(time.RFC3339Nano)),
+		BusinessID:     businessID,
+		Type:           req.Type,
+		Reusability:    reusability,
+		Status:         PaymentMethodActive,
+		Country:        country,
+		ReferenceID:    req.ReferenceID,
+		CustomerID:     req.CustomerID,
+		Description:    req.Description,
+		EWallet:        req.EWallet,
+		DirectDebit:    req.DirectDebit,
+		Card:           req.Card,
+		VirtualAccount: req.VirtualAccount,
+		QRCode:         req.QRCode,
+		OverTheCounter: req.OverTheCounter,
+		Actions:        []PaymentAction{},
+		Metadata:       req.Metadata,
+		Created:        now,
+		Updated:        now,
+	}
+}
+
+func BuildPaymentRequest(req PaymentRequestRequest, method PaymentMethod, businessID string) PaymentRequest {
+	now := time.Now().Format(time.RFC3339)
+	country := req.Country
+	if country == "" {
+		country = method.Country
+	}
+	captureMethod := req.CaptureMethod
+	if captureMethod == "" {
+		captureMethod = "AUTOMATIC"
+	}
+	return PaymentRequest{
+		ID:            "pr-" + ShortHash(req.ReferenceID+":"+time.Now().Format(time.RFC3339Nano)),
+		BusinessID:    businessID,
+		ReferenceID:   req.ReferenceID,
+		CustomerID:    req.CustomerID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Country:       country,
+		Description:   req.Description,
+		CaptureMethod: captureMethod,
+		PaymentMethod: method,
+		Status:        PaymentRequestPending,
+		Actions:       []PaymentAction{},
+		Metadata:      req.Metadata,
+		Created:       now,
+		Updated:       now,
+	}
+}
+
+// AuthActions are the actions that send the customer to the mock's
+// authentication page for resource id.
+func AuthActions(baseURL, id string) []PaymentAction {
+	url := baseURL + PaymentActionPath + id
+	return []PaymentAction{
+		{Action: "AUTH", URLType: "WEB", Method: "GET", URL: url},
+		{Action: "AUTH", URLType: "MOBILE", Method: "GET", URL: url + "?flow=mobile"},
+	}
+}
+
+func BuildPayment(pr PaymentRequest) Payment {
+	return Payment{
+		ID:               "py-" + ShortHash(fmt.Sprintf("%s:%s", pr.ID, pr.Status)),
+		PaymentRequestID: pr.ID,
+		ReferenceID:      pr.ReferenceID,
+		BusinessID:       pr.BusinessID,
+		CustomerID:       pr.CustomerID,
+		Amount:           pr.Amount,
+		Currency:         pr.Currency,
+		Country:          pr.Country,
+		Status:           pr.Status,
+		PaymentMethod:    pr.PaymentMethod,
+		FailureCode:      pr.FailureCode,
+		Description:      pr.Description,
+		Metadata:         pr.Metadata,
+		Created:          pr.Updated,
+		Updated:          pr.Updated,
+	}
+}
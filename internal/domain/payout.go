@@ -0,0 +1,106 @@
+package domain
+
+import "time"
+
+const (
+	PayoutStatusAccepted  = "ACCEPTED"
+	PayoutStatusRequested = "REQUESTED"
+	PayoutStatusSucceeded = "SUCCEEDED"
+	PayoutStatusFailed    = "FAILED"
+	PayoutStatusCancelled = "CANCELLED"
+	PayoutStatusReversed  = "REVERSED"
+)
+
+const (
+	PayoutEventSucceeded = "payout.succeeded"
+	PayoutEventFailed    = "payout.failed"
+	PayoutEventReversed  = "payout.reversed"
+)
+
+type PayoutChannelProperties struct {
+	AccountHolderName string `json:"account_holder_name"`
+	AccountNumber     string `json:"account_number"`
+	AccountType       string `json:"account_type,omitempty"`
+}
+
+type PayoutReceiptNotification struct {
+	EmailTo  []string `json:"email_to,omitempty"`
+	EmailCC  []string `json:"email_cc,omitempty"`
+	EmailBCC []string `json:"email_bcc,omitempty"`
+}
+
+type PayoutRequest struct {
+	ReferenceID         string                     `json:"reference_id"`
+	ChannelCode         string                     `json:"channel_code"`
+	ChannelProperties   PayoutChannelProperties    `json:"channel_properties"`
+	Amount              int                        `json:"amount"`
+	Description         string                     `json:"description,omitempty"`
+	Currency            string                     `json:"currency"`
+	ReceiptNotification *PayoutReceiptNotification `json:"receipt_notification,omitempty"`
+	Metadata            map[string]any             `json:"metadata,omitempty"`
+	IdempotencyKey      string                     `json:"-"`
+	ForUserID           string                     `json:"-"`
+	Session             string                     `json:"-"`
+}
+
+type Payout struct {
+	ID                   string                     `json:"id"`
+	Amount               int                        `json:"amount"`
+	ChannelCode          string                     `json:"channel_code"`
+	Currency             string                     `json:"currency"`
+	Description          string                     `json:"description,omitempty"`
+	ReferenceID          string                     `json:"reference_id"`
+	Status               string                     `json:"status"`
+	Created              string                     `json:"created"`
+	Updated              string                     `json:"updated"`
+	EstimatedArrivalTime string                     `json:"estimated_arrival_time"`
+	BusinessID           string                     `json:"business_id"`
+	ChannelProperties    PayoutChannelProperties    `json:"channel_properties"`
+	ReceiptNotification  *PayoutReceiptNotification `json:"receipt_notification,omitempty"`
+	Metadata             map[string]any             `json:"metadata,omitempty"`
+	FailureCode          string                     `json:"failure_code,omitempty"`
+}
+
+type PayoutWebhook struct {
+	Event      string `json:"event"`
+	BusinessID string `json:"business_id"`
+	Created    string `json:"created"`
+	Data       Payout `json:"data"`
+}
+
+func PayoutID(businessID, idempotencyKey, referenceID string) string {
+	return "disb-" + ShortHash(businessID+":"+idempotencyKey+":"+referenceID)
+}
+
+// DisbursementRequest maps a payout onto the legacy disbursement request so
+// the same scenario rules decide both APIs.
+func (req PayoutRequest) DisbursementRequest() DisbursementRequest {
+	return DisbursementRequest{
+		ExternalID:        req.ReferenceID,
+		Amount:            req.Amount,
+		BankCode:          req.ChannelCode,
+		AccountHolderName: req.ChannelProperties.AccountHolderName,
+		AccountNumber:     req.ChannelProperties.AccountNumber,
+		Description:       req.Description,
+	}
+}
+
+func BuildPayout(req PayoutRequest, businessID string) Payout {
+	now := time.Now()
+	return Payout{
+		ID:                   PayoutID(businessID, req.IdempotencyKey, req.ReferenceID),
+		Amount:               req.Amount,
+		ChannelCode:          req.ChannelCode,
+		Currency:             req.Currency,
+		Description:          req.Description,
+		ReferenceID:          req.ReferenceID,
+		Status:               PayoutStatusAccepted,
+		Created:              now.Format(time.RFC3339),
+		Updated:              now.Format(time.RFC3339),
+		EstimatedArrivalTime: now.Add(time.Hour).Format(time.RFC3339),
+		BusinessID:           businessID,
+		ChannelProperties:    req.ChannelProperties,
+		ReceiptNotification:  req.ReceiptNotification,
+		Metadata:             req.Metadata,
+	}
+}
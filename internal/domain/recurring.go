@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RecurringPlanActive   = "ACTIVE"
+	RecurringPlanInactive = "INACTIVE"
+)
+
+const (
+	RecurringCycleScheduled = "SCHEDULED"
+	RecurringCycleRetrying  = "RETRYING"
+	RecurringCycleSucceeded = "SUCCEEDED"
+	RecurringCycleFailed    = "FAILED"
+	RecurringCycleCancelled = "CANCELLED"
+)
+
+const (
+	RecurringIntervalDay   = "DAY"
+	RecurringIntervalWeek  = "WEEK"
+	RecurringIntervalMonth = "MONTH"
+)
+
+const (
+	RecurringFailedCycleResume = "RESUME"
+	RecurringFailedCycleStop   = "STOP"
+)
+
+const (
+	RecurringPlanEventActivated   = "recurring.plan.activated"
+	RecurringPlanEventInactivated = "recurring.plan.inactivated"
+	RecurringCycleEventCreated    = "recurring.cycle.created"
+	RecurringCycleEventSucceeded  = "recurring.cycle.succeeded"
+	RecurringCycleEventRetrying   = "recurring.cycle.retrying"
+	RecurringCycleEventFailed     = "recurring.cycle.failed"
+	RecurringFailureDeclined      = "PAYMENT_DECLINED"
+)
+
+type RecurringPaymentMethod struct {
+	PaymentMethodID string `json:"payment_method_id"`
+	Rank            int    `json:"rank"`
+}
+
+type RecurringSchedule struct {
+	ReferenceID        string `json:"reference_id,omitempty"`
+	Interval           string `json:"interval"`
+	IntervalCount      int    `json:"interval_count"`
+	TotalRecurrence    int    `json:"total_recurrence,omitempty"`
+	AnchorDate         string `json:"anchor_date,omitempty"`
+	RetryInterval      string `json:"retry_interval,omitempty"`
+	RetryIntervalCount int    `json:"retry_interval_count,omitempty"`
+	TotalRetry         int    `json:"total_retry,omitempty"`
+}
+
+// Next returns the time interval_count intervals after from.
+func (s RecurringSchedule) Next(from time.Time) time.Time {
+	return addInterval(from, s.Interval, s.IntervalCount)
+}
+
+// NextRetry returns when a failed attempt at from is retried.
+func (s RecurringSchedule) NextRetry(from time.Time) time.Time {
+	return addInterval(from, s.RetryInterval, s.RetryIntervalCount)
+}
+
+func addInterval(from time.Time, interval string, count int) time.Time {
+	switch interval {
+	case RecurringIntervalWeek:
+		return from.AddDate(0, 0, 7*count)
+	case RecurringIntervalMonth:
+		return from.AddDate(0, count, 0)
+	}
+	return from.AddDate(0, 0, count)
+}
+
+type RecurringPlanRequest struct {
+	ReferenceID       string                   `json:"reference_id"`
+	CustomerID        string                   `json:"customer_id"`
+	RecurringAction   string                   `json:"recurring_action"`
+	Currency          string                   `json:"currency"`
+	Amount            int                      `json:"amount"`
+	PaymentMethods    []RecurringPaymentMethod `json:"payment_methods"`
+	Schedule          RecurringSchedule        `json:"schedule"`
+	FailedCycleAction string                   `json:"failed_cycle_action,omitempty"`
+	Description       string                   `json:"description,omitempty"`
+	Metadata          map[string]any           `json:"metadata,omitempty"`
+	ForUserID         string                   `json:"-"`
+	Session           string                   `json:"-"`
+}
+
+type RecurringPlan struct {
+	ID                string                   `json:"id"`
+	BusinessID        string                   `json:"business_id"`
+	ReferenceID       string                   `json:"reference_id"`
+	CustomerID        string                   `json:"customer_id"`
+	RecurringAction   string                   `json:"recurring_action"`
+	Currency          string                   `json:"currency"`
+	Amount            int                      `json:"amount"`
+	PaymentMethods    []RecurringPaymentMethod `json:"payment_methods"`
+	Schedule          RecurringSchedule        `json:"schedule"`
+	FailedCycleAction string                   `json:"failed_cycle_action"`
+	Status            string                   `json:"status"`
+	Description       string                   `json:"description,omitempty"`
+	Metadata          map[string]any           `json:"metadata,omitempty"`
+	Created           string                   `json:"created"`
+	Updated           string                   `json:"updated"`
+}
+
+type RecurringAttempt struct {
+	AttemptNumber      int    `json:"attempt_number"`
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	FailureCode        string `json:"failure_code,omitempty"`
+	NextRetryTimestamp string `json:"next_retry_timestamp,omitempty"`
+	Created            string `json:"created"`
+}
+
+type RecurringCycle struct {
+	ID                 string             `json:"id"`
+	PlanID             string             `json:"plan_id"`
+	BusinessID         string             `json:"business_id"`
+	ReferenceID        string             `json:"reference_id"`
+	CustomerID         string             `json:"customer_id"`
+	RecurringAction    string             `json:"recurring_action"`
+	Type               string             `json:"type"`
+	Status             string             `json:"status"`
+	CycleNumber        int                `json:"cycle_number"`
+	AttemptCount       int                `json:"attempt_count"`
+	AttemptDetails     []RecurringAttempt `json:"attempt_details"`
+	ScheduledTimestamp string             `json:"scheduled_timestamp"`
+	Currency           string             `json:"currency"`
+	Amount             int                `json:"amount"`
+	Metadata           map[string]any     `json:"metadata,omitempty"`
+	Created            string             `json:"created"`
+	Updated            string             `json:"updated"`
+}
+
+// RecurringWebhook is the envelope of recurring.* callbacks; Data is the plan
+// or the cycle.
+type RecurringWebhook struct {
+	Event      string `json:"event"`
+	BusinessID string `json:"business_id"`
+	Created    string `json:"created"`
+	Data       any    `json:"data"`
+}
+
+func BuildRecurringPlan(req RecurringPlanRequest, businessID string, now time.Time) RecurringPlan {
+	failedCycleAction := req.FailedCycleAction
+	if failedCycleAction == "" {
+		failedCycleAction = RecurringFailedCycleResume
+	}
+	schedule := req.Schedule
+	if schedule.IntervalCount <= 0 {
+		schedule.IntervalCount = 1
+	}
+	if schedule.RetryInterval == "" {
+		schedule.RetryInterval = RecurringIntervalDay
+	}
+	if schedule.RetryIntervalCount <= 0 {
+		schedule.RetryIntervalCount = 1
+	}
+	if schedule.AnchorDate == "" {
+		schedule.AnchorDate = now.Format(time.RFC3339)
+	}
+	recurringAction := req.RecurringAction
+	if recurringAction == "" {
+		recurringAction = "PAYMENT"
+	}
+	return RecurringPlan{
+		ID:                "repl_" + ShortHash(req.ReferenceID+":"+now.Format(time.RFC3339Nano)),
+		BusinessID:        businessID,
+		ReferenceID:       req.ReferenceID,
+		CustomerID:        req.CustomerID,
+		RecurringAction:   recurringAction,
+		Currency:          req.Currency,
+		Amount:            req.Amount,
+		PaymentMethods:    req.PaymentMethods,
+		Schedule:          schedule,
+		FailedCycleAction: failedCycleAction,
+		Status:            RecurringPlanActive,
+		Description:       req.Description,
+		Metadata:          req.Metadata,
+		Created:           now.Format(time.RFC3339),
+		Updated:           now.Format(time.RFC3339),
+	}
+}
+
+func BuildRecurringCycle(plan RecurringPlan, number int, scheduled, now time.Time) RecurringCycle {
+	return RecurringCycle{
+		ID:                 "rccy_" + ShortHash(fmt.Sprintf("%s:%d", plan.ID, number)),
+		PlanID:             plan.ID,
+		BusinessID:         plan.BusinessID,
+		ReferenceID:        plan.Schedule.ReferenceID,
+		CustomerID:         plan.CustomerID,
+		RecurringAction:    plan.RecurringAction,
+		Type:               "SCHEDULED",
+		Status:             RecurringCycleScheduled,
+		CycleNumber:        number,
+		AttemptDetails:     []RecurringAttempt{},
+		ScheduledTimestamp: scheduled.Format(time.RFC3339),
+		Currency:           plan.Currency,
+		Amount:             plan.Amount,
+		Metadata:           plan.Metadata,
+		Created:            now.Format(time.RFC3339),
+		Updated:            now.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import "time"
+
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusFailed    = "FAILED"
+)
+
+const (
+	RefundEventSucceeded = "refund.succeeded"
+	RefundEventFailed    = "refund.failed"
+	RefundFailureUnknown = "UNKNOWN_ERROR"
+)
+
+// RefundReasons are the reasons Xendit accepts.
+var RefundReasons = map[string]bool{
+	"FRAUDULENT":            true,
+	"DUPLICATE":             true,
+	"REQUESTED_BY_CUSTOMER": true,
+	"CANCELLATION":          true,
+	"OTHERS":                true,
+}
+
+type RefundRequest struct {
+	PaymentRequestID string         `json:"payment_request_id,omitempty"`
+	InvoiceID        string         `json:"invoice_id,omitempty"`
+	EWalletChargeID  string         `json:"ewallet_charge_id,omitempty"`
+	ReferenceID      string         `json:"reference_id,omitempty"`
+	Amount           int            `json:"amount,omitempty"`
+	Currency         string         `json:"currency,omitempty"`
+	Reason           string         `json:"reason"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	ForUserID        string         `json:"-"`
+	Session          string         `json:"-"`
+}
+
+// RefundablePayment is what a refund is taken from: a paid invoice, a
+// succeeded payment request or a succeeded e-wallet charge.
+type RefundablePayment struct {
+	PaymentID   string
+	BusinessID  string
+	Amount      int
+	Currency    string
+	Country     string
+	ChannelCode string
+}
+
+type Refund struct {
+	ID               string         `json:"id"`
+	PaymentRequestID string         `json:"payment_request_id,omitempty"`
+	InvoiceID        string         `json:"invoice_id,omitempty"`
+	EWalletChargeID  string         `json:"ewallet_charge_id,omitempty"`
+	PaymentID        string         `json:"payment_id"`
+	BusinessID       string         `json:"business_id"`
+	ReferenceID      string         `json:"reference_id,omitempty"`
+	Amount           int            `json:"amount"`
+	Currency         string         `json:"currency"`
+	Country          string         `json:"country"`
+	ChannelCode      string         `json:"channel_code"`
+	Status           string         `json:"status"`
+	Reason           string         `json:"reason"`
+	FailureCode      string         `json:"failure_code,omitempty"`
+	RefundFeeAmount  int            `json:"refund_fee_amount"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	Created          string         `json:"created"`
+	Updated          string         `json:"updated"`
+}
+
+type RefundWebhook struct {
+	Event      string `json:"event"`
+	BusinessID string `json:"business_id"`
+	Created    string `json:"created"`
+	Data       Refund `json:"data"`
+}
+
+func BuildRefund(req RefundRequest, payment RefundablePayment, amount int) Refund {
+	now := time.Now()
+	return Refund{
+		ID:               "rfd-" + ShortHash(payment.PaymentID+":"+req.ReferenceID+":"+now.Format(time.RFC3339Nano)),
+		PaymentRequestID: req.PaymentRequestID,
+		InvoiceID:        req.InvoiceID,
+		EWalletChargeID:  req.EWalletChargeID,
+		PaymentID:        payment.PaymentID,
+		BusinessID:       payment.BusinessID,
+		ReferenceID:      req.ReferenceID,
+		Amount:           amount,
+		Currency:         payment.Currency,
+		Country:          payment.Country,
+		ChannelCode:      payment.ChannelCode,
+		Status:           RefundStatusPending,
+		Reason:           req.Reason,
+		Metadata:         req.Metadata,
+		Created:          now.Format(time.RFC3339),
+		Updated:          now.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RetailOutletAlfamart  = "ALFAMART"
+	RetailOutletIndomaret = "INDOMARET"
+)
+
+const (
+	FixedPaymentCodeActive   = "ACTIVE"
+	FixedPaymentCodeInactive = "INACTIVE"
+)
+
+// RetailOutletPrefixes are the prefixes of generated payment codes, as in
+// Xendit's test mode.
+var RetailOutletPrefixes = map[string]string{
+	RetailOutletAlfamart:  "TEST",
+	RetailOutletIndomaret: "TEST",
+}
+
+type FixedPaymentCodeRequest struct {
+	ExternalID       string `json:"external_id"`
+	RetailOutletName string `json:"retail_outlet_name"`
+	Name             string `json:"name"`
+	ExpectedAmount   int    `json:"expected_amount"`
+	PaymentCode      string `json:"payment_code,omitempty"`
+	ExpirationDate   string `json:"expiration_date,omitempty"`
+	IsSingleUse      bool   `json:"is_single_use"`
+	Description      string `json:"description,omitempty"`
+	ForUserID        string `json:"-"`
+	Session          string `json:"-"`
+}
+
+// FixedPaymentCodeUpdate holds the fields PATCH may change; nil fields are
+// kept.
+type FixedPaymentCodeUpdate struct {
+	Name           *string `json:"name"`
+	ExpectedAmount *int    `json:"expected_amount"`
+	ExpirationDate *string `json:"expiration_date"`
+	Description    *string `json:"description"`
+}
+
+type FixedPaymentCode struct {
+	ID               string `json:"id"`
+	OwnerID          string `json:"owner_id"`
+	ExternalID       string `json:"external_id"`
+	RetailOutletName string `json:"retail_outlet_name"`
+	Prefix           string `json:"prefix"`
+	Name             string `json:"name"`
+	PaymentCode      string `json:"payment_code"`
+	Type             string `json:"type"`
+	ExpectedAmount   int    `json:"expected_amount"`
+	ExpirationDate   string `json:"expiration_date"`
+	IsSingleUse      bool   `json:"is_single_use"`
+	Status           string `json:"status"`
+	Description      string `json:"description,omitempty"`
+}
+
+type FixedPaymentCodePayment struct {
+	ID                        string `json:"id"`
+	ExternalID                string `json:"external_id"`
+	OwnerID                   string `json:"owner_id"`
+	FixedPaymentCodeID        string `json:"fixed_payment_code_id"`
+	FixedPaymentCodePaymentID string `json:"fixed_payment_code_payment_id"`
+	PaymentID                 string `json:"payment_id"`
+	Prefix                    string `json:"prefix"`
+	PaymentCode               string `json:"payment_code"`
+	RetailOutletName          string `json:"retail_outlet_name"`
+	Name                      string `json:"name"`
+	Amount                    int    `json:"amount"`
+	Status                    string `json:"status"`
+	TransactionTimestamp      string `json:"transaction_timestamp"`
+	Created                   string `json:"created"`
+	Updated                   string `json:"updated"`
+}
+
+func BuildFixedPaymentCode(req FixedPaymentCodeRequest, ownerID string) FixedPaymentCode {
+	now := time.Now()
+	prefix := RetailOutletPrefixes[req.RetailOutletName]
+	code := req.PaymentCode
+	if code == "" {
+		code = prefix + NumericCode(req.RetailOutletName+":"+req.ExternalID, 6)
+	}
+	expiration := req.ExpirationDate
+	if expiration == "" {
+		expiration = now.AddDate(31, 0, 0).Format(time.RFC3339)
+	}
+	return FixedPaymentCode{
+		ID:               "fpc_" + ShortHash(req.ExternalID+":"+now.Format(time.RFC3339Nano)),
+		OwnerID:          ownerID,
+		ExternalID:       req.ExternalID,
+		RetailOutletName: req.RetailOutletName,
+		Prefix:           prefix,
+		Name:             req.Name,
+		PaymentCode:      code,
+		Type:             "USER",
+		ExpectedAmount:   req.ExpectedAmount,
+		ExpirationDate:   expiration,
+		IsSingleUse:      req.IsSingleUse,
+		Status:           FixedPaymentCodeActive,
+		Description:      req.Description,
+	}
+}
+
+func BuildFixedPaymentCodePayment(code FixedPaymentCode, amount, sequence int) FixedPaymentCodePayment {
+	now := time.Now().Format(time.RFC3339)
+	hash := ShortHash(fmt.Sprintf("%s:%d", code.ID, sequence))
+	return FixedPaymentCodePayment{
+		ID:                        "fpcp_" + hash,
+		ExternalID:                code.ExternalID,
+		OwnerID:                   code.OwnerID,
+		FixedPaymentCodeID:        code.ID,
+		FixedPaymentCodePaymentID: "fpcp_" + hash,
+		PaymentID:                 "pay_" + hash,
+		Prefix:                    code.Prefix,
+		PaymentCode:               code.PaymentCode,
+		RetailOutletName:          code.RetailOutletName,
+		Name:                      code.Name,
+		Amount:                    amount,
+		Status:                    "COMPLETED",
+		TransactionTimestamp:      now,
+		Created:                   now,
+		Updated:                   now,
+	}
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	VirtualAccountPending  = "PENDING"
+	VirtualAccountActive   = "ACTIVE"
+	VirtualAccountInactive = "INACTIVE"
+)
+
+// VirtualAccountMerchantCodes are the prefixes of generated VA numbers.
+var VirtualAccountMerchantCodes = map[string]string{
+	"BCA":               "10766",
+	"BNI":               "8808",
+	"BRI":               "26215",
+	"MANDIRI":           "88608",
+	"PERMATA":           "8214",
+	"BSI":               "6849",
+	"CIMB":              "5919",
+	"BJB":               "1234",
+	"SAHABAT_SAMPOERNA": "4567",
+}
+
+type VirtualAccountRequest struct {
+	ExternalID           string `json:"external_id"`
+	BankCode             string `json:"bank_code"`
+	Name                 string `json:"name"`
+	VirtualAccountNumber string `json:"virtual_account_number,omitempty"`
+	SuggestedAmount      int    `json:"suggested_amount,omitempty"`
+	IsClosed             bool   `json:"is_closed"`
+	ExpectedAmount       int    `json:"expected_amount,omitempty"`
+	ExpirationDate       string `json:"expiration_date,omitempty"`
+	IsSingleUse          bool   `json:"is_single_use"`
+	Description          string `json:"description,omitempty"`
+	Currency             string `json:"currency,omitempty"`
+	ForUserID            string `json:"-"`
+	Session              string `json:"-"`
+}
+
+// VirtualAccountUpdate holds the fields PATCH may change; nil fields are kept.
+type VirtualAccountUpdate struct {
+	SuggestedAmount *int    `json:"suggested_amount"`
+	ExpectedAmount  *int    `json:"expected_amount"`
+	ExpirationDate  *string `json:"expiration_date"`
+	IsSingleUse     *bool   `json:"is_single_use"`
+	Description     *string `json:"description"`
+	Name            *string `json:"name"`
+}
+
+type VirtualAccount struct {
+	ID              string `json:"id"`
+	OwnerID         string `json:"owner_id"`
+	ExternalID      string `json:"external_id"`
+	BankCode        string `json:"bank_code"`
+	MerchantCode    string `json:"merchant_code"`
+	Name            string `json:"name"`
+	AccountNumber   string `json:"account_number"`
+	SuggestedAmount int    `json:"suggested_amount,omitempty"`
+	IsClosed        bool   `json:"is_closed"`
+	ExpectedAmount  int    `json:"expected_amount,omitempty"`
+	ExpirationDate  string `json:"expiration_date"`
+	IsSingleUse     bool   `json:"is_single_use"`
+	Status          string `json:"status"`
+	Currency        string `json:"currency"`
+	Country         string `json:"country"`
+	Description     string `json:"description,omitempty"`
+	Created         string `json:"created"`
+	Updated         string `json:"updated"`
+}
+
+type VirtualAccountPayment struct {
+	ID                       string `json:"id"`
+	PaymentID                string `json:"payment_id"`
+	CallbackVirtualAccountID string `json:"callback_virtual_account_id"`
+	OwnerID                  string `json:"owner_id"`
+	ExternalID               string `json:"external_id"`
+	AccountNumber            string `json:"account_number"`
+	BankCode                 string `json:"bank_code"`
+	MerchantCode             string `json:"merchant_code"`
+	Amount                   int    `json:"amount"`
+	Currency                 string `json:"currency"`
+	SenderName               string `json:"sender_name,omitempty"`
+	TransactionTimestamp     string `json:"transaction_timestamp"`
+	Created                  string `json:"created"`
+	Updated                  string `json:"updated"`
+}
+
+func BuildVirtualAccount(req VirtualAccountRequest, ownerID string) VirtualAccount {
+	now := time.Now()
+	merchantCode := VirtualAccountMerchantCodes[req.BankCode]
+	suffix := req.VirtualAccountNumber
+	if suffix == "" {
+		suffix = NumericCode(req.BankCode+":"+req.ExternalID, 10)
+	}
+	expiration := req.ExpirationDate
+	if expiration == "" {
+		expiration = now.AddDate(31, 0, 0).Format(time.RFC3339)
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	return VirtualAccount{
+		ID:              "va_" + ShortHash(req.ExternalID+":"+now.Format(time.RFC3339Nano)),
+		OwnerID:         ownerID,
+		ExternalID:      req.ExternalID,
+		BankCode:        req.BankCode,
+		MerchantCode:    merchantCode,
+		Name:            req.Name,
+		AccountNumber:   merchantCode + suffix,
+		SuggestedAmount: req.SuggestedAmount,
+		IsClosed:        req.IsClosed,
+		ExpectedAmount:  req.ExpectedAmount,
+		ExpirationDate:  expiration,
+		IsSingleUse:     req.IsSingleUse,
+		Status:          VirtualAccountPending,
+		Currency:        currency,
+		Country:         "ID",
+		Description:     req.Description,
+		Created:         now.Format(time.RFC3339),
+		Updated:         now.Format(time.RFC3339),
+	}
+}
+
+func BuildVirtualAccountPayment(va VirtualAccount, amount int, sequence int) VirtualAccountPayment {
+	now := time.Now().Format(time.RFC3339)
+	paymentID := ShortHash(fmt.Sprintf("%s:%d", va.ID, sequence))
+	return VirtualAccountPayment{
+		ID:                       "vap_" + paymentID,
+		PaymentID:                "pay_" + paymentID,
+		CallbackVirtualAccountID: va.ID,
+		OwnerID:                  va.OwnerID,
+		ExternalID:               va.ExternalID,
+		AccountNumber:            va.AccountNumber,
+		BankCode:                 va.BankCode,
+		MerchantCode:             va.MerchantCode,
+		Amount:                   amount,
+		Currency:                 va.Currency,
+		TransactionTimestamp:     now,
+		Created:                  now,
+		Updated:                  now,
+	}
+}
@@ -6,9 +6,19 @@ import (
 )
 
 type Config struct {
-	RetryTimeoutMinutes int               `json:"retry_timeout_minutes"`
-	Accounts            []AccountScenario `json:"accounts"`
-	Batches             []BatchScenario   `json:"batches"`
+	RetryTimeoutMinutes int                  `json:"retry_timeout_minutes"`
+	Callback            *CallbackBehavior    `json:"callback,omitempty"`
+	Accounts            []AccountScenario    `json:"accounts"`
+	Batches             []BatchScenario      `json:"batches"`
+	NameValidations     []NameValidation     `json:"name_validations,omitempty"`
+	Invoices            []InvoiceRule        `json:"invoices,omitempty"`
+	VirtualAccounts     []VirtualAccountRule `json:"virtual_accounts,omitempty"`
+	EWallets            []EWalletRule        `json:"ewallets,omitempty"`
+	QRCodes             []QRCodeRule         `json:"qr_codes,omitempty"`
+	RetailOutlets       []RetailOutletRule   `json:"retail_outlets,omitempty"`
+	PaymentRequests     []PaymentRequestRule `json:"payment_requests,omitempty"`
+	Refunds             []RefundRule         `json:"refunds,omitempty"`
+	Recurring           []RecurringRule      `json:"recurring,omitempty"`
 }
 
 type AccountScenario struct {
@@ -17,9 +27,34 @@ type AccountScenario struct {
 }
 
 type Rule struct {
-	ExternalID     string `json:"external_id"`
-	Outcome        string `json:"outcome"`
-	RetrySuccessAt int    `json:"retry_success_at"`
+	ExternalID     string            `json:"external_id"`
+	Outcome        string            `json:"outcome"`
+	RetrySuccessAt int               `json:"retry_success_at"`
+	ReverseAfterMS int               `json:"reverse_after_ms,omitempty"`
+	Callback       *CallbackBehavior `json:"callback,omitempty"`
+}
+
+const (
+	OutcomeSuccess            = "success"
+	OutcomeSuccessThenReverse = "success_then_reverse"
+	OutcomeFailThenSucceed    = "fail_then_succeed"
+	OutcomeFailUntilTimeout   = "fail_until_timeout"
+)
+
+const (
+	WebhookIDSame = "same"
+	WebhookIDNew  = "new"
+)
+
+// CallbackBehavior bends callback delivery for a rule so receivers can be
+// tested against duplicates, stale statuses, delays and lost callbacks.
+type CallbackBehavior struct {
+	Repeat      int    `json:"repeat,omitempty"`
+	WebhookID   string `json:"webhook_id,omitempty"`
+	StaleStatus string `json:"stale_status,omitempty"`
+	DelayMS     int    `json:"delay_ms,omitempty"`
+	Drop        bool   `json:"drop,omitempty"`
+	Token       string `json:"token,omitempty"`
 }
 
 type BatchScenario struct {
@@ -28,6 +63,144 @@ type BatchScenario struct {
 	Disbursements []Rule `json:"disbursements"`
 }
 
+// NameValidation is a known account for the name validation endpoint. An
+// entry without an outcome validates successfully to HolderName.
+type NameValidation struct {
+	BankCode      string `json:"bank_code,omitempty"`
+	AccountNumber string `json:"account_number"`
+	HolderName    string `json:"holder_name,omitempty"`
+	Outcome       string `json:"outcome,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Pending       bool   `json:"pending,omitempty"`
+	DelayMS       int    `json:"delay_ms,omitempty"`
+}
+
+const (
+	InvoicePay    = "pay"
+	InvoiceExpire = "expire"
+)
+
+// InvoiceRule scripts what happens to an invoice after it is created: paid
+// or expired after DelayMS, or left PENDING. A rule without an external_id
+// applies to every invoice without its own rule.
+type InvoiceRule struct {
+	ExternalID     string `json:"external_id,omitempty"`
+	Outcome        string `json:"outcome,omitempty"`
+	DelayMS        int    `json:"delay_ms,omitempty"`
+	PaymentMethod  string `json:"payment_method,omitempty"`
+	PaymentChannel string `json:"payment_channel,omitempty"`
+	PaidAmount     int    `json:"paid_amount,omitempty"`
+	SettleAfterMS  int    `json:"settle_after_ms,omitempty"`
+}
+
+const (
+	VirtualAccountPay     = "pay"
+	VirtualAccountPartial = "partial"
+	VirtualAccountExpire  = "expire"
+)
+
+// VirtualAccountRule scripts a payment into, or the expiry of, a fixed VA
+// DelayMS after it is created. A rule without an external_id applies to
+// every VA without its own rule.
+type VirtualAccountRule struct {
+	ExternalID string `json:"external_id,omitempty"`
+	Outcome    string `json:"outcome,omitempty"`
+	DelayMS    int    `json:"delay_ms,omitempty"`
+	PaidAmount int    `json:"paid_amount,omitempty"`
+}
+
+const (
+	EWalletSucceed = "succeed"
+	EWalletFail    = "fail"
+)
+
+// EWalletRule settles e-wallet charges on a channel DelayMS after they are
+// created, as if the payer approved or declined them. A rule without a
+// channel_code applies to every channel without its own rule.
+type EWalletRule struct {
+	ChannelCode string `json:"channel_code,omitempty"`
+	Outcome     string `json:"outcome,omitempty"`
+	DelayMS     int    `json:"delay_ms,omitempty"`
+	FailureCode string `json:"failure_code,omitempty"`
+}
+
+const (
+	QRCodePay    = "pay"
+	QRCodeExpire = "expire"
+)
+
+// QRCodeRule scripts a scan of, or the expiry of, a QR code DelayMS after it
+// is created. A rule without a reference_id applies to every QR code without
+// its own rule.
+type QRCodeRule struct {
+	ReferenceID string `json:"reference_id,omitempty"`
+	Outcome     string `json:"outcome,omitempty"`
+	DelayMS     int    `json:"delay_ms,omitempty"`
+	PaidAmount  int    `json:"paid_amount,omitempty"`
+}
+
+const (
+	RetailOutletPay     = "pay"
+	RetailOutletPartial = "partial"
+	RetailOutletExpire  = "expire"
+)
+
+// RetailOutletRule scripts a payment at the outlet, or the expiry of, a
+// fixed payment code DelayMS after it is created. A rule without an
+// external_id applies to every code without its own rule.
+type RetailOutletRule struct {
+	ExternalID string `json:"external_id,omitempty"`
+	Outcome    string `json:"outcome,omitempty"`
+	DelayMS    int    `json:"delay_ms,omitempty"`
+	PaidAmount int    `json:"paid_amount,omitempty"`
+}
+
+const (
+	PaymentRequestSucceed = "succeed"
+	PaymentRequestFail    = "fail"
+)
+
+// PaymentRequestRule settles payment requests on a channel DelayMS after they
+// are created, skipping any authentication step. A rule without a
+// channel_code applies to every channel without its own rule.
+type PaymentRequestRule struct {
+	ChannelCode string `json:"channel_code,omitempty"`
+	Outcome     string `json:"outcome,omitempty"`
+	DelayMS     int    `json:"delay_ms,omitempty"`
+	FailureCode string `json:"failure_code,omitempty"`
+}
+
+const (
+	RefundSucceed = "succeed"
+	RefundFail    = "fail"
+)
+
+// RefundRule settles refunds DelayMS after they are created. A rule without a
+// reference_id applies to every refund without its own rule; refunds without
+// a rule succeed straight away.
+type RefundRule struct {
+	ReferenceID string `json:"reference_id,omitempty"`
+	Outcome     string `json:"outcome,omitempty"`
+	DelayMS     int    `json:"delay_ms,omitempty"`
+	FailureCode string `json:"failure_code,omitempty"`
+}
+
+const (
+	RecurringSucceed = "succeed"
+	RecurringFail    = "fail"
+)
+
+// RecurringRule scripts the payment attempts of a recurring plan's cycles:
+// every attempt fails with Outcome fail, or the first FailedAttempts of each
+// cycle fail before one succeeds. A rule without a reference_id applies to
+// every plan without its own rule; without a rule attempts succeed.
+type RecurringRule struct {
+	ReferenceID    string `json:"reference_id,omitempty"`
+	Outcome        string `json:"outcome,omitempty"`
+	FailedAttempts int    `json:"failed_attempts,omitempty"`
+	FailureCode    string `json:"failure_code,omitempty"`
+}
+
 func ParseConfig(data []byte) (*Config, error) {
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
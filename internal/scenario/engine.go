@@ -47,19 +47,112 @@ func (e *Engine) Reset() {
 	e.accountIdx = make(map[string]int)
 }
 
+type State struct {
+	FirstFail    bool                 `json:"first_fail"`
+	Seen         map[string]bool      `json:"seen"`
+	Attempts     map[string]int       `json:"attempts"`
+	FirstSeen    map[string]time.Time `json:"first_seen"`
+	AccountIdx   map[string]int       `json:"account_index"`
+	RandomStatus bool                 `json:"random_status"`
+	Scenario     *Config              `json:"scenario"`
+}
+
+func (e *Engine) Snapshot() State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state := State{
+		FirstFail:    e.firstFail,
+		Seen:         make(map[string]bool, len(e.seen)),
+		Attempts:     make(map[string]int, len(e.attempts)),
+		FirstSeen:    make(map[string]time.Time, len(e.firstSeen)),
+		AccountIdx:   make(map[string]int, len(e.accountIdx)),
+		RandomStatus: e.useRandom,
+		Scenario:     e.scenario,
+	}
+	for key, value := range e.seen {
+		state.Seen[key] = value
+	}
+	for key, value := range e.attempts {
+		state.Attempts[key] = value
+	}
+	for key, value := range e.firstSeen {
+		state.FirstSeen[key] = value
+	}
+	for key, value := range e.accountIdx {
+		state.AccountIdx[key] = value
+	}
+	return state
+}
+
+func (e *Engine) Restore(state State) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.firstFail = state.FirstFail
+	e.seen = make(map[string]bool, len(state.Seen))
+	e.attempts = make(map[string]int, len(state.Attempts))
+	e.firstSeen = make(map[string]time.Time, len(state.FirstSeen))
+	e.accountIdx = make(map[string]int, len(state.AccountIdx))
+	for key, value := range state.Seen {
+		e.seen[key] = value
+	}
+	for key, value := range state.Attempts {
+		e.attempts[key] = value
+	}
+	for key, value := range state.FirstSeen {
+		e.firstSeen[key] = value
+	}
+	for key, value := range state.AccountIdx {
+		e.accountIdx[key] = value
+	}
+	e.useRandom = state.RandomStatus
+	e.scenario = state.Scenario
+}
+
+type Decision struct {
+	Status   string
+	Callback *CallbackBehavior
+	// Reverse marks a COMPLETED outcome that the bank returns ReverseAfter
+	// later.
+	Reverse      bool
+	ReverseAfter time.Duration
+}
+
 func (e *Engine) PickStatus(req domain.DisbursementRequest) string {
+	return e.Decide(req).Status
+}
+
+func (e *Engine) Decide(req domain.DisbursementRequest) Decision {
+	return e.decideFor(req, req.Description)
+}
+
+// DecideBatchItem decides a disbursement that is part of a batch, matching
+// batch rules by the batch reference instead of the item description.
+func (e *Engine) DecideBatchItem(reference string, req domain.DisbursementRequest) Decision {
+	return e.decideFor(req, reference)
+}
+
+func (e *Engine) decideFor(req domain.DisbursementRequest, topupID string) Decision {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if e.useRandom {
-		return e.pickStatusRandom()
+		return Decision{Status: e.pickStatusRandom(), Callback: e.defaultCallback()}
 	}
 
 	if e.scenario == nil {
-		return e.pickStatusDefault(req.ExternalID)
+		return Decision{Status: e.pickStatusDefault(req.ExternalID)}
 	}
 
-	return e.pickStatusScenario(req)
+	return e.pickStatusScenario(req, topupID)
+}
+
+func (e *Engine) defaultCallback() *CallbackBehavior {
+	if e.scenario == nil {
+		return nil
+	}
+	return e.scenario.Callback
 }
 
 func (e *Engine) pickStatusRandom() string {
@@ -83,12 +176,12 @@ func (e *Engine) pickStatusDefault(externalID string) string {
 	return domain.StatusCompleted
 }
 
-func (e *Engine) pickStatusScenario(req domain.DisbursementRequest) string {
+func (e *Engine) pickStatusScenario(req domain.DisbursementRequest, topupID string) Decision {
 	for _, batch := range e.scenario.Batches {
 		if batch.AccountNumber != req.AccountNumber {
 			continue
 		}
-		if batch.TopupID != "" && batch.TopupID != req.Description {
+		if batch.TopupID != "" && batch.TopupID != topupID {
 			continue
 		}
 		if result, ok := e.applyRules(req, batch.Disbursements, "batch:"+batch.TopupID+":"+batch.AccountNumber); ok {
@@ -105,16 +198,16 @@ func (e *Engine) pickStatusScenario(req domain.DisbursementRequest) string {
 		}
 	}
 
-	return domain.StatusCompleted
+	return Decision{Status: domain.StatusCompleted, Callback: e.defaultCallback()}
 }
 
-func (e *Engine) applyRules(req domain.DisbursementRequest, rules []Rule, key string) (string, bool) {
+func (e *Engine) applyRules(req domain.DisbursementRequest, rules []Rule, key string) (Decision, bool) {
 	for _, rule := range rules {
 		if rule.ExternalID == "" {
 			continue
 		}
 		if rule.ExternalID == req.ExternalID {
-			return e.applyRule(req.ExternalID, rule), true
+			return e.decide(req.ExternalID, rule), true
 		}
 	}
 
@@ -122,10 +215,22 @@ func (e *Engine) applyRules(req domain.DisbursementRequest, rules []Rule, key st
 	if idx < len(rules) {
 		rule := rules[idx]
 		e.accountIdx[key] = idx + 1
-		return e.applyRule(req.ExternalID, rule), true
+		return e.decide(req.ExternalID, rule), true
 	}
 
-	return "", false
+	return Decision{}, false
+}
+
+func (e *Engine) decide(externalID string, rule Rule) Decision {
+	decision := Decision{Status: e.applyRule(externalID, rule), Callback: rule.Callback}
+	if rule.Outcome == OutcomeSuccessThenReverse {
+		decision.Reverse = true
+		decision.ReverseAfter = time.Duration(rule.ReverseAfterMS) * time.Millisecond
+	}
+	if decision.Callback == nil {
+		decision.Callback = e.defaultCallback()
+	}
+	return decision
 }
 
 func (e *Engine) applyRule(externalID string, rule Rule) string {
@@ -135,16 +240,133 @@ func (e *Engine) applyRule(externalID string, rule Rule) string {
 	e.attempts[externalID]++
 
 	switch rule.Outcome {
-	case "success":
+	case OutcomeSuccess, OutcomeSuccessThenReverse:
 		return domain.StatusCompleted
-	case "fail_then_succeed":
+	case OutcomeFailThenSucceed:
 		if rule.RetrySuccessAt > 0 && e.attempts[externalID] > rule.RetrySuccessAt {
 			return domain.StatusCompleted
 		}
 		return domain.StatusFailed
-	case "fail_until_timeout":
+	case OutcomeFailUntilTimeout:
 		return domain.StatusFailed
 	default:
 		return domain.StatusCompleted
 	}
 }
+
+// NameValidation returns the configured entry for an account, matching
+// entries without a bank code on any bank.
+func (e *Engine) NameValidation(bankCode, accountNumber string) (NameValidation, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return NameValidation{}, false
+	}
+	for _, entry := range e.scenario.NameValidations {
+		if entry.AccountNumber != accountNumber {
+			continue
+		}
+		if entry.BankCode != "" && entry.BankCode != bankCode {
+			continue
+		}
+		return entry, true
+	}
+	return NameValidation{}, false
+}
+
+func (e *Engine) InvoiceRule(externalID string) (InvoiceRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return InvoiceRule{}, false
+	}
+	return matchRule(e.scenario.Invoices, externalID, func(rule InvoiceRule) string { return rule.ExternalID })
+}
+
+func (e *Engine) VirtualAccountRule(externalID string) (VirtualAccountRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return VirtualAccountRule{}, false
+	}
+	return matchRule(e.scenario.VirtualAccounts, externalID, func(rule VirtualAccountRule) string { return rule.ExternalID })
+}
+
+func (e *Engine) EWalletRule(channelCode string) (EWalletRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return EWalletRule{}, false
+	}
+	return matchRule(e.scenario.EWallets, channelCode, func(rule EWalletRule) string { return rule.ChannelCode })
+}
+
+func (e *Engine) QRCodeRule(referenceID string) (QRCodeRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return QRCodeRule{}, false
+	}
+	return matchRule(e.scenario.QRCodes, referenceID, func(rule QRCodeRule) string { return rule.ReferenceID })
+}
+
+func (e *Engine) RetailOutletRule(externalID string) (RetailOutletRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return RetailOutletRule{}, false
+	}
+	return matchRule(e.scenario.RetailOutlets, externalID, func(rule RetailOutletRule) string { return rule.ExternalID })
+}
+
+func (e *Engine) PaymentRequestRule(channelCode string) (PaymentRequestRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return PaymentRequestRule{}, false
+	}
+	return matchRule(e.scenario.PaymentRequests, channelCode, func(rule PaymentRequestRule) string { return rule.ChannelCode })
+}
+
+func (e *Engine) RefundRule(referenceID string) (RefundRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return RefundRule{}, false
+	}
+	return matchRule(e.scenario.Refunds, referenceID, func(rule RefundRule) string { return rule.ReferenceID })
+}
+
+func (e *Engine) RecurringRule(referenceID string) (RecurringRule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.scenario == nil {
+		return RecurringRule{}, false
+	}
+	return matchRule(e.scenario.Recurring, referenceID, func(rule RecurringRule) string { return rule.ReferenceID })
+}
+
+// matchRule returns the rule keyed by key, or else the first rule without a
+// key.
+func matchRule[T any](rules []T, key string, keyOf func(T) string) (T, bool) {
+	var fallback T
+	found := false
+	for _, rule := range rules {
+		if keyOf(rule) == key {
+			return rule, true
+		}
+		if keyOf(rule) == "" && !found {
+			fallback, found = rule, true
+		}
+	}
+	return fallback, found
+}
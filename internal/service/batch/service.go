@@ -0,0 +1,261 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound      = errors.New("batch disbursement not found")
+	ErrNotApprovable = errors.New("batch disbursement is not waiting for approval")
+	ErrDuplicateRef  = errors.New("a batch disbursement with this reference already exists")
+)
+
+type Service struct {
+	engine          *scenario.Engine
+	cb              callback.Sender
+	userID          string
+	autoApprove     bool
+	processingDelay time.Duration
+	ledger          *balance.Ledger
+	mu              sync.Mutex
+	batches         map[string]Record
+}
+
+type Record struct {
+	Request domain.BatchDisbursementRequest `json:"request"`
+	Batch   domain.BatchCallbackPayload     `json:"batch"`
+	Session string                          `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, batches: make(map[string]Record)}
+}
+
+func (s *Service) WithAutoApprove(enabled bool) *Service {
+	s.autoApprove = enabled
+	return s
+}
+
+func (s *Service) WithProcessingDelay(delay time.Duration) *Service {
+	s.processingDelay = delay
+	return s
+}
+
+// WithLedger makes batch items spend from the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create uploads a batch. Like Xendit it starts in NEEDS_APPROVAL; with auto
+// approval the items are processed right after the response is built. The
+// reference is unique per user.
+func (s *Service) Create(req domain.BatchDisbursementRequest) (domain.BatchDisbursementResponse, error) {
+	userID := req.ForUserID
+	if userID == "" {
+		userID = s.userID
+	}
+	now := time.Now().Format(time.RFC3339)
+	batch := domain.BatchCallbackPayload{
+		ID:        domain.BatchDisbursementID(req.Reference),
+		Created:   now,
+		Updated:   now,
+		Reference: req.Reference,
+		UserID:    userID,
+		Status:    domain.BatchStatusNeedsApproval,
+	}
+	for _, item := range req.Disbursements {
+		batch.TotalUploadedCount++
+		batch.TotalUploadedAmount += item.Amount
+	}
+
+	s.mu.Lock()
+	for _, existing := range s.batches {
+		if existing.Batch.UserID == userID && existing.Batch.Reference == req.Reference {
+			s.mu.Unlock()
+			return domain.BatchDisbursementResponse{}, ErrDuplicateRef
+		}
+	}
+	s.batches[batch.ID] = Record{Request: req, Batch: batch, Session: req.Session}
+	s.mu.Unlock()
+
+	resp := domain.BatchDisbursementResponse{
+		ID:                  batch.ID,
+		Created:             batch.Created,
+		Reference:           batch.Reference,
+		TotalUploadedAmount: batch.TotalUploadedAmount,
+		TotalUploadedCount:  batch.TotalUploadedCount,
+		Status:              batch.Status,
+	}
+	if !s.autoApprove {
+		return resp, nil
+	}
+	_, err := s.Approve(batch.ID)
+	return resp, err
+}
+
+// Approve marks a batch APPROVED and processes it: the batch is UPLOADING for
+// the processing delay, then every item is decided with the scenario batch
+// rules for its reference and the batch callback is sent with the results.
+func (s *Service) Approve(id string) (domain.BatchCallbackPayload, error) {
+	s.mu.Lock()
+	record, ok := s.batches[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.BatchCallbackPayload{}, ErrNotFound
+	}
+	if record.Batch.Status != domain.BatchStatusNeedsApproval {
+		s.mu.Unlock()
+		return record.Batch, ErrNotApprovable
+	}
+	now := time.Now().Format(time.RFC3339)
+	record.Batch.Status = domain.BatchStatusApproved
+	record.Batch.ApprovedAt = now
+	record.Batch.Updated = now
+	s.batches[id] = record
+	s.mu.Unlock()
+
+	s.setStatus(id, domain.BatchStatusUploading)
+	if s.processingDelay <= 0 {
+		return s.process(id)
+	}
+	time.AfterFunc(s.processingDelay, func() {
+		if _, err := s.process(id); err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("[batch.Approve] callback failed id=%s error=%v", id, err)
+		}
+	})
+	return record.Batch, nil
+}
+
+// process decides the items of an UPLOADING batch and sends the batch
+// callback. A batch removed by a reset or a restored snapshot in the meantime
+// is not written back.
+func (s *Service) process(id string) (domain.BatchCallbackPayload, error) {
+	s.mu.Lock()
+	record, ok := s.batches[id]
+	s.mu.Unlock()
+	if !ok {
+		return domain.BatchCallbackPayload{}, ErrNotFound
+	}
+	if record.Batch.Status != domain.BatchStatusUploading {
+		return record.Batch, nil
+	}
+
+	batch := record.Batch
+	batch.Disbursements = make([]domain.BatchDisbursementItemResult, 0, len(record.Request.Disbursements))
+	for _, item := range record.Request.Disbursements {
+		result := s.processItem(record, item)
+		if result.Status == domain.StatusCompleted {
+			batch.TotalDisbursedCount++
+			batch.TotalDisbursedAmount += item.Amount
+		} else {
+			batch.TotalErrorCount++
+			batch.TotalErrorAmount += item.Amount
+		}
+		batch.Disbursements = append(batch.Disbursements, result)
+	}
+	switch {
+	case batch.TotalErrorCount == 0:
+		batch.Status = domain.BatchStatusCompleted
+	case batch.TotalDisbursedCount == 0:
+		batch.Status = domain.BatchStatusFailed
+	default:
+		batch.Status = domain.BatchStatusPartiallyCompleted
+	}
+	batch.Updated = time.Now().Format(time.RFC3339)
+	batch.WebhookID = domain.WebhookID(batch.ID, batch.Status)
+
+	s.mu.Lock()
+	if _, ok := s.batches[id]; !ok {
+		s.mu.Unlock()
+		return domain.BatchCallbackPayload{}, ErrNotFound
+	}
+	record.Batch = batch
+	s.batches[id] = record
+	s.mu.Unlock()
+
+	err := s.cb.Deliver(callback.BatchDisbursementEvent(batch, record.Session))
+	return batch, err
+}
+
+func (s *Service) processItem(record Record, item domain.BatchDisbursementItem) domain.BatchDisbursementItemResult {
+	if s.ledger != nil {
+		if err := s.ledger.Withdraw(record.Batch.UserID, item.Amount); err != nil {
+			result := domain.BuildBatchItemResult(item, domain.StatusFailed)
+			result.FailureCode = domain.FailureInsufficientBalance
+			return result
+		}
+	}
+
+	decision := s.engine.DecideBatchItem(record.Request.Reference, item.DisbursementRequest())
+	result := domain.BuildBatchItemResult(item, decision.Status)
+	if result.Status != domain.StatusCompleted && s.ledger != nil {
+		s.ledger.Refund(record.Batch.UserID, item.Amount)
+	}
+	return result
+}
+
+func (s *Service) setStatus(id, status string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.batches[id]
+	if !ok {
+		return record, false
+	}
+	record.Batch.Status = status
+	record.Batch.Updated = time.Now().Format(time.RFC3339)
+	s.batches[id] = record
+	return record, true
+}
+
+func (s *Service) Get(id string) (domain.BatchCallbackPayload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.batches[id]
+	return record.Batch, ok
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batches := make(map[string]Record, len(s.batches))
+	for id, record := range s.batches {
+		batches[id] = record
+	}
+	return batches
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var batches map[string]Record
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = make(map[string]Record, len(batches))
+	for id, record := range batches {
+		s.batches[id] = record
+	}
+	return nil
+}
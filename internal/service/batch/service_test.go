@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func item(externalID, accountNumber string, amount int) domain.BatchDisbursementItem {
+	return domain.BatchDisbursementItem{ExternalID: externalID, Amount: amount, BankCode: "BCA", BankAccountName: "Budi", BankAccountNumber: accountNumber, Description: "payroll"}
+}
+
+func TestApprovedBatchesSettleEachItem(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{Batches: []scenario.BatchScenario{
+		{TopupID: "batch-1", AccountNumber: "acct-2", Disbursements: []scenario.Rule{{ExternalID: "item-2", Outcome: "fail_until_timeout"}}},
+	}})
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{Default: map[string]int{balance.AccountCash: 100000}})
+	service := NewService(engine, sender, "user_mock").WithLedger(ledger)
+	resp, err := service.Create(domain.BatchDisbursementRequest{Reference: "batch-1", Disbursements: []domain.BatchDisbursementItem{
+		item("item-1", "acct-1", 30000),
+		item("item-2", "acct-2", 20000),
+		item("item-3", "acct-3", 90000),
+	}})
+	if err != nil || resp.Status != domain.BatchStatusNeedsApproval || resp.TotalUploadedAmount != 140000 {
+		t.Fatalf("expected the batch to wait for approval, got %+v %v", resp, err)
+	}
+
+	batch, err := service.Approve(resp.ID)
+	if err != nil || batch.Status != domain.BatchStatusPartiallyCompleted {
+		t.Fatalf("expected the batch PARTIALLY_COMPLETED, got %s %v", batch.Status, err)
+	}
+	if batch.TotalDisbursedAmount != 30000 || batch.TotalErrorCount != 2 {
+		t.Fatalf("expected one item disbursed and two failed, got %+v", batch)
+	}
+	if got := batch.Disbursements[2].FailureCode; got != domain.FailureInsufficientBalance {
+		t.Fatalf("expected the item the balance could not cover to fail with INSUFFICIENT_BALANCE, got %q", got)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 70000 {
+		t.Fatalf("expected only the disbursed item debited from CASH, got %d", got)
+	}
+	if len(sender.Events()) != 1 {
+		t.Fatalf("expected one batch callback, got %d", len(sender.Events()))
+	}
+	if _, err := service.Approve(resp.ID); !errors.Is(err, ErrNotApprovable) {
+		t.Fatalf("expected ErrNotApprovable approving twice, got %v", err)
+	}
+}
+
+func TestReferenceIsUniquePerUser(t *testing.T) {
+	service := NewService(scenario.NewEngine(&scenario.Config{}), &callbacktest.Recorder{}, "user_mock")
+	req := domain.BatchDisbursementRequest{Reference: "batch-1", Disbursements: []domain.BatchDisbursementItem{item("item-1", "acct-1", 30000)}}
+	if _, err := service.Create(req); err != nil {
+		t.Fatalf("expected the batch to be created, got %v", err)
+	}
+
+	if _, err := service.Create(req); !errors.Is(err, ErrDuplicateRef) {
+		t.Fatalf("expected ErrDuplicateRef, got %v", err)
+	}
+	req.ForUserID = "sub-account-1"
+	if _, err := service.Create(req); err != nil {
+		t.Fatalf("expected another user to reuse the reference, got %v", err)
+	}
+	if _, err := service.Approve("batch_missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResetDropsBatchesStillUploading(t *testing.T) {
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{Default: map[string]int{balance.AccountCash: 100000}})
+	service := NewService(scenario.NewEngine(&scenario.Config{}), sender, "user_mock").WithLedger(ledger).WithProcessingDelay(30 * time.Millisecond)
+	resp, _ := service.Create(domain.BatchDisbursementRequest{Reference: "batch-1", Disbursements: []domain.BatchDisbursementItem{item("item-1", "acct-1", 30000)}})
+	if _, err := service.Approve(resp.ID); err != nil {
+		t.Fatalf("expected the batch to be approved, got %v", err)
+	}
+
+	service.Reset()
+	time.Sleep(80 * time.Millisecond)
+	if _, ok := service.Get(resp.ID); ok {
+		t.Fatal("expected the reset batch not to be written back")
+	}
+	if len(sender.Events()) != 0 {
+		t.Fatalf("expected no batch callback after a reset, got %d", len(sender.Events()))
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 100000 {
+		t.Fatalf("expected the balance untouched, got %d", got)
+	}
+}
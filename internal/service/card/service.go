@@ -0,0 +1,337 @@
+package card
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+)
+
+var (
+	ErrTokenNotFound          = errors.New("credit card token not found")
+	ErrTokenUsed              = errors.New("single use token has already been charged")
+	ErrAuthenticationNotFound = errors.New("authentication not found for this token")
+	ErrAuthenticationRequired = errors.New("card requires a VERIFIED authentication_id")
+	ErrAuthenticationAmount   = errors.New("charge amount does not match the authenticated amount")
+	ErrAuthenticationUsed     = errors.New("authentication has already been used for a charge")
+	ErrNotInReview            = errors.New("authentication is not IN_REVIEW")
+	ErrNotFound               = errors.New("credit card charge not found")
+	ErrNotAuthorized          = errors.New("credit card charge is not AUTHORIZED")
+	ErrCaptureAmount          = errors.New("capture amount is greater than the authorized amount")
+	ErrCallback               = errors.New("credit card charge callback failed")
+)
+
+type Service struct {
+	cb              callback.Sender
+	userID          string
+	baseURL         string
+	ledger          *balance.Ledger
+	mu              sync.Mutex
+	tokens          map[string]TokenRecord
+	authentications map[string]AuthenticationRecord
+	charges         map[string]ChargeRecord
+}
+
+// TokenRecord keeps how the tokenized test card behaves instead of the card
+// number.
+type TokenRecord struct {
+	Token    domain.CardToken `json:"token"`
+	TestCard domain.TestCard  `json:"test_card"`
+	Used     bool             `json:"used,omitempty"`
+}
+
+// AuthenticationRecord keeps an authentication and whether a charge has used
+// it; each authentication is good for one charge.
+type AuthenticationRecord struct {
+	Authentication domain.CardAuthentication `json:"authentication"`
+	Used           bool                      `json:"used,omitempty"`
+}
+
+type ChargeRecord struct {
+	Request domain.CardChargeRequest `json:"request"`
+	Charge  domain.CardCharge        `json:"charge"`
+	Session string                   `json:"session,omitempty"`
+}
+
+type State struct {
+	Tokens          map[string]TokenRecord          `json:"tokens"`
+	Authentications map[string]AuthenticationRecord `json:"authentications"`
+	Charges         map[string]ChargeRecord         `json:"charges"`
+}
+
+func NewService(cb callback.Sender, userID string) *Service {
+	return &Service{
+		cb:              cb,
+		userID:          userID,
+		baseURL:         "http://localhost:8080",
+		tokens:          make(map[string]TokenRecord),
+		authentications: make(map[string]AuthenticationRecord),
+		charges:         make(map[string]ChargeRecord),
+	}
+}
+
+// WithBaseURL sets where the mock is reachable, for the 3DS page URLs.
+func (s *Service) WithBaseURL(url string) *Service {
+	s.baseURL = url
+	return s
+}
+
+// WithLedger credits captured charges to the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// CreateToken tokenizes a card. When the token is authenticated for an
+// amount and the card needs 3DS, it waits IN_REVIEW for the 3DS page.
+func (s *Service) CreateToken(req domain.CardTokenRequest) domain.CardToken {
+	testCard := domain.TestCards[req.CardNumber]
+	token := domain.BuildCardToken(req, s.businessID(req.ForUserID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if (req.ShouldAuthenticate == nil || *req.ShouldAuthenticate) && req.Amount > 0 {
+		auth := domain.BuildCardAuthentication(token.ID, req.Amount, req.Currency, testCard.ThreeDS, s.baseURL)
+		s.authentications[auth.ID] = AuthenticationRecord{Authentication: auth}
+		token.Status = auth.Status
+		token.AuthenticationID = auth.ID
+		token.PayerAuthenticationURL = auth.PayerAuthenticationURL
+	}
+	s.tokens[token.ID] = TokenRecord{Token: token, TestCard: testCard}
+	return token
+}
+
+// Authenticate starts a new authentication of a token for an amount, as done
+// before charging a multiple use token.
+func (s *Service) Authenticate(tokenID string, req domain.CardAuthenticationRequest) (domain.CardAuthentication, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tokens[tokenID]
+	if !ok {
+		return domain.CardAuthentication{}, ErrTokenNotFound
+	}
+	auth := domain.BuildCardAuthentication(tokenID, req.Amount, req.Currency, record.TestCard.ThreeDS, s.baseURL)
+	s.authentications[auth.ID] = AuthenticationRecord{Authentication: auth}
+	return auth, nil
+}
+
+// CompleteThreeDS finishes an IN_REVIEW authentication as the cardholder
+// would on the 3DS page. A token created with the authentication follows
+// its status.
+func (s *Service) CompleteThreeDS(authID string, approved bool) (domain.CardAuthentication, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authRecord, ok := s.authentications[authID]
+	if !ok {
+		return domain.CardAuthentication{}, ErrAuthenticationNotFound
+	}
+	auth := authRecord.Authentication
+	if auth.Status != domain.CardTokenInReview {
+		return auth, ErrNotInReview
+	}
+	auth.Status = domain.CardTokenVerified
+	if !approved {
+		auth.Status = domain.CardTokenFailed
+		auth.FailureReason = domain.CardFailureAuthentication
+	}
+	authRecord.Authentication = auth
+	s.authentications[authID] = authRecord
+	if record, ok := s.tokens[auth.CreditCardTokenID]; ok && record.Token.AuthenticationID == authID {
+		record.Token.Status = auth.Status
+		record.Token.FailureReason = auth.FailureReason
+		s.tokens[auth.CreditCardTokenID] = record
+	}
+	return auth, nil
+}
+
+// Charge charges a token. Cards that need 3DS need a VERIFIED
+// authentication_id; declining test cards give a FAILED charge. Charges are
+// captured unless capture is false, and send a callback either way.
+func (s *Service) Charge(req domain.CardChargeRequest) (domain.CardCharge, error) {
+	s.mu.Lock()
+	record, ok := s.tokens[req.TokenID]
+	if !ok {
+		s.mu.Unlock()
+		return domain.CardCharge{}, ErrTokenNotFound
+	}
+	if record.Used && !record.Token.IsMultipleUse {
+		s.mu.Unlock()
+		return domain.CardCharge{}, ErrTokenUsed
+	}
+	authenticated := false
+	if req.AuthenticationID != "" {
+		authRecord, ok := s.authentications[req.AuthenticationID]
+		if !ok || authRecord.Authentication.CreditCardTokenID != req.TokenID {
+			s.mu.Unlock()
+			return domain.CardCharge{}, ErrAuthenticationNotFound
+		}
+		if authRecord.Used {
+			s.mu.Unlock()
+			return domain.CardCharge{}, ErrAuthenticationUsed
+		}
+		if req.Amount != authRecord.Authentication.Amount {
+			s.mu.Unlock()
+			return domain.CardCharge{}, ErrAuthenticationAmount
+		}
+		authenticated = authRecord.Authentication.Status == domain.CardTokenVerified
+	}
+	if record.TestCard.ThreeDS && !authenticated {
+		s.mu.Unlock()
+		return domain.CardCharge{}, ErrAuthenticationRequired
+	}
+	if authRecord, ok := s.authentications[req.AuthenticationID]; ok {
+		authRecord.Used = true
+		s.authentications[req.AuthenticationID] = authRecord
+	}
+	record.Used = true
+	s.tokens[req.TokenID] = record
+
+	charge := domain.BuildCardCharge(req, record.Token, s.businessID(req.ForUserID))
+	charge.ECI = "07"
+	if authenticated {
+		charge.ECI = "05"
+	}
+	switch {
+	case record.TestCard.FailureCode != "":
+		charge.Status = domain.CardChargeFailed
+		charge.FailureReason = record.TestCard.FailureCode
+	case req.Capture == nil || *req.Capture:
+		charge.Status = domain.CardChargeCaptured
+		charge.CaptureAmount = charge.AuthorizedAmount
+	}
+	s.charges[charge.ID] = ChargeRecord{Request: req, Charge: charge, Session: req.Session}
+	s.mu.Unlock()
+
+	return charge, s.notify(charge, req.Session)
+}
+
+// Capture captures an AUTHORIZED charge, the whole authorized amount when
+// amount is not set.
+func (s *Service) Capture(id string, amount int) (domain.CardCharge, error) {
+	s.mu.Lock()
+	record, ok := s.charges[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.CardCharge{}, ErrNotFound
+	}
+	if record.Charge.Status != domain.CardChargeAuthorized {
+		s.mu.Unlock()
+		return record.Charge, ErrNotAuthorized
+	}
+	if amount <= 0 {
+		amount = record.Charge.AuthorizedAmount
+	}
+	if amount > record.Charge.AuthorizedAmount {
+		s.mu.Unlock()
+		return record.Charge, ErrCaptureAmount
+	}
+	record.Charge.Status = domain.CardChargeCaptured
+	record.Charge.CaptureAmount = amount
+	record.Charge.Updated = time.Now().Format(time.RFC3339)
+	s.charges[id] = record
+	s.mu.Unlock()
+
+	return record.Charge, s.notify(record.Charge, record.Session)
+}
+
+func (s *Service) notify(charge domain.CardCharge, session string) error {
+	if charge.Status == domain.CardChargeCaptured && s.ledger != nil {
+		s.ledger.TopUp(charge.BusinessID, balance.AccountCash, charge.CaptureAmount)
+	}
+	if err := s.cb.Deliver(callback.CardChargeEvent(charge, session)); err != nil {
+		return fmt.Errorf("%w: %v", ErrCallback, err)
+	}
+	return nil
+}
+
+func (s *Service) GetToken(id string) (domain.CardToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.tokens[id]
+	return record.Token, ok
+}
+
+func (s *Service) GetAuthentication(authID string) (domain.CardAuthentication, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.authentications[authID]
+	return record.Authentication, ok
+}
+
+func (s *Service) Get(id string) (domain.CardCharge, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.charges[id]
+	return record.Charge, ok
+}
+
+func (s *Service) businessID(forUserID string) string {
+	if forUserID != "" {
+		return forUserID
+	}
+	return s.userID
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = make(map[string]TokenRecord)
+	s.authentications = make(map[string]AuthenticationRecord)
+	s.charges = make(map[string]ChargeRecord)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		Tokens:          make(map[string]TokenRecord, len(s.tokens)),
+		Authentications: make(map[string]AuthenticationRecord, len(s.authentications)),
+		Charges:         make(map[string]ChargeRecord, len(s.charges)),
+	}
+	for id, record := range s.tokens {
+		state.Tokens[id] = record
+	}
+	for id, record := range s.authentications {
+		state.Authentications[id] = record
+	}
+	for id, record := range s.charges {
+		state.Charges[id] = record
+	}
+	return state
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = make(map[string]TokenRecord, len(state.Tokens))
+	for id, record := range state.Tokens {
+		s.tokens[id] = record
+	}
+	s.authentications = make(map[string]AuthenticationRecord, len(state.Authentications))
+	for id, record := range state.Authentications {
+		s.authentications[id] = record
+	}
+	s.charges = make(map[string]ChargeRecord, len(state.Charges))
+	for id, record := range state.Charges {
+		s.charges[id] = record
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package card
+
+import (
+	"errors"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+)
+
+func TestChargeUsesEachAuthenticationOnce(t *testing.T) {
+	service := NewService(&callbacktest.Recorder{}, "user_mock")
+	token := service.CreateToken(domain.CardTokenRequest{CardNumber: "4000000000001091", IsMultipleUse: true})
+	auth, err := service.Authenticate(token.ID, domain.CardAuthenticationRequest{Amount: 50000})
+	if err != nil {
+		t.Fatalf("expected the token to be authenticated, got %v", err)
+	}
+
+	charge := domain.CardChargeRequest{TokenID: token.ID, ExternalID: "order-1", Amount: 50000, AuthenticationID: auth.ID}
+	if _, err := service.Charge(charge); !errors.Is(err, ErrAuthenticationRequired) {
+		t.Fatalf("expected ErrAuthenticationRequired before 3DS, got %v", err)
+	}
+	if _, err := service.CompleteThreeDS(auth.ID, true); err != nil {
+		t.Fatalf("expected 3DS to complete, got %v", err)
+	}
+	wrongAmount := charge
+	wrongAmount.Amount = 60000
+	if _, err := service.Charge(wrongAmount); !errors.Is(err, ErrAuthenticationAmount) {
+		t.Fatalf("expected ErrAuthenticationAmount, got %v", err)
+	}
+	captured, err := service.Charge(charge)
+	if err != nil || captured.Status != domain.CardChargeCaptured || captured.ECI != "05" {
+		t.Fatalf("expected an authenticated CAPTURED charge, got %+v %v", captured, err)
+	}
+	if _, err := service.Charge(charge); !errors.Is(err, ErrAuthenticationUsed) {
+		t.Fatalf("expected ErrAuthenticationUsed charging twice, got %v", err)
+	}
+}
+
+func TestCaptureAuthorizedCharge(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(&callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+	token := service.CreateToken(domain.CardTokenRequest{CardNumber: "4000000000000002"})
+	capture := false
+	authorized, err := service.Charge(domain.CardChargeRequest{TokenID: token.ID, ExternalID: "order-1", Amount: 50000, Capture: &capture})
+	if err != nil || authorized.Status != domain.CardChargeAuthorized {
+		t.Fatalf("expected an AUTHORIZED charge, got %+v %v", authorized, err)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 0 {
+		t.Fatalf("expected nothing credited before capture, got %d", got)
+	}
+
+	if _, err := service.Capture(authorized.ID, 60000); !errors.Is(err, ErrCaptureAmount) {
+		t.Fatalf("expected ErrCaptureAmount, got %v", err)
+	}
+	captured, err := service.Capture(authorized.ID, 0)
+	if err != nil || captured.CaptureAmount != 50000 {
+		t.Fatalf("expected the authorized amount captured, got %+v %v", captured, err)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 50000 {
+		t.Fatalf("expected the capture credited to CASH, got %d", got)
+	}
+	if _, err := service.Capture(authorized.ID, 0); !errors.Is(err, ErrNotAuthorized) {
+		t.Fatalf("expected ErrNotAuthorized capturing twice, got %v", err)
+	}
+	if _, err := service.Charge(domain.CardChargeRequest{TokenID: token.ID, ExternalID: "order-2", Amount: 50000}); !errors.Is(err, ErrTokenUsed) {
+		t.Fatalf("expected ErrTokenUsed charging a single use token twice, got %v", err)
+	}
+}
+
+func TestChargeKeepsTheChargeWhenTheCallbackFails(t *testing.T) {
+	service := NewService(&callbacktest.Recorder{Err: errors.New("connection refused")}, "user_mock")
+	token := service.CreateToken(domain.CardTokenRequest{CardNumber: "4000000000000036"})
+
+	charge, err := service.Charge(domain.CardChargeRequest{TokenID: token.ID, ExternalID: "order-1", Amount: 50000})
+	if !errors.Is(err, ErrCallback) {
+		t.Fatalf("expected ErrCallback, got %v", err)
+	}
+	if stored, ok := service.Get(charge.ID); !ok || stored.Status != domain.CardChargeFailed || stored.FailureReason != domain.CardFailureDeclined {
+		t.Fatalf("expected the declined charge stored as FAILED, got %+v", stored)
+	}
+}
@@ -0,0 +1,177 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/domain"
+)
+
+var (
+	ErrNotFound          = errors.New("customer not found")
+	ErrDuplicateRef      = errors.New("a customer with this reference_id already exists")
+	ErrDetailTypeInvalid = errors.New("detail does not match the customer type")
+)
+
+type Service struct {
+	userID    string
+	mu        sync.Mutex
+	customers map[string]domain.Customer
+}
+
+func NewService(userID string) *Service {
+	return &Service{userID: userID, customers: make(map[string]domain.Customer)}
+}
+
+// Create stores a customer. reference_id is unique per business.
+func (s *Service) Create(req domain.CustomerRequest) (domain.Customer, error) {
+	businessID := s.businessID(req.ForUserID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.customers {
+		if existing.BusinessID == businessID && existing.ReferenceID == req.ReferenceID {
+			return existing, ErrDuplicateRef
+		}
+	}
+	customer := domain.BuildCustomer(req, businessID)
+	s.customers[customer.ID] = customer
+	return customer, nil
+}
+
+// Update changes the fields set in update on one of the business's
+// customers. The customer's type cannot change, so only the matching detail
+// may be updated. An empty businessID is the mock's own user.
+func (s *Service) Update(businessID, id string, update domain.CustomerUpdate) (domain.Customer, error) {
+	businessID = s.businessID(businessID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customer, ok := s.customers[id]
+	if !ok || customer.BusinessID != businessID {
+		return domain.Customer{}, ErrNotFound
+	}
+	if update.IndividualDetail != nil {
+		if customer.Type != domain.CustomerIndividual {
+			return customer, ErrDetailTypeInvalid
+		}
+		customer.IndividualDetail = update.IndividualDetail
+	}
+	if update.BusinessDetail != nil {
+		if customer.Type != domain.CustomerBusiness {
+			return customer, ErrDetailTypeInvalid
+		}
+		customer.BusinessDetail = update.BusinessDetail
+	}
+	if update.Email != nil {
+		customer.Email = *update.Email
+	}
+	if update.MobileNumber != nil {
+		customer.MobileNumber = *update.MobileNumber
+	}
+	if update.PhoneNumber != nil {
+		customer.PhoneNumber = *update.PhoneNumber
+	}
+	if update.Description != nil {
+		customer.Description = *update.Description
+	}
+	if update.Addresses != nil {
+		customer.Addresses = update.Addresses
+	}
+	if update.Metadata != nil {
+		customer.Metadata = update.Metadata
+	}
+	customer.Updated = time.Now().Format(time.RFC3339)
+	s.customers[id] = customer
+	return customer, nil
+}
+
+// Get returns the business's customer with the ID. An empty businessID is
+// the mock's own user.
+func (s *Service) Get(businessID, id string) (domain.Customer, bool) {
+	businessID = s.businessID(businessID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customer, ok := s.customers[id]
+	if !ok || customer.BusinessID != businessID {
+		return domain.Customer{}, false
+	}
+	return customer, true
+}
+
+// Exists reports whether the business has a customer with the ID, for
+// endpoints that reference one by customer_id.
+func (s *Service) Exists(businessID, id string) bool {
+	_, ok := s.Get(businessID, id)
+	return ok
+}
+
+// Search returns the business's customers with the reference_id, newest
+// first. An empty businessID is the mock's own user.
+func (s *Service) Search(businessID, referenceID string) []domain.Customer {
+	businessID = s.businessID(businessID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customers := make([]domain.Customer, 0)
+	for _, customer := range s.customers {
+		if customer.BusinessID == businessID && customer.ReferenceID == referenceID {
+			customers = append(customers, customer)
+		}
+	}
+	sort.Slice(customers, func(i, j int) bool {
+		if customers[i].Created != customers[j].Created {
+			return customers[i].Created > customers[j].Created
+		}
+		return customers[i].ID < customers[j].ID
+	})
+	return customers
+}
+
+func (s *Service) businessID(forUserID string) string {
+	if forUserID != "" {
+		return forUserID
+	}
+	return s.userID
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.customers = make(map[string]domain.Customer)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customers := make(map[string]domain.Customer, len(s.customers))
+	for id, customer := range s.customers {
+		customers[id] = customer
+	}
+	return customers
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var customers map[string]domain.Customer
+	if err := json.Unmarshal(data, &customers); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.customers = make(map[string]domain.Customer, len(customers))
+	for id, customer := range customers {
+		s.customers[id] = customer
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"xendit-api-mock/internal/domain"
+)
+
+func individual(referenceID, forUserID string) domain.CustomerRequest {
+	return domain.CustomerRequest{
+		ReferenceID:      referenceID,
+		Type:             domain.CustomerIndividual,
+		IndividualDetail: &domain.CustomerIndividualDetail{GivenNames: "Budi"},
+		ForUserID:        forUserID,
+	}
+}
+
+func TestReferenceIDIsUniquePerBusiness(t *testing.T) {
+	service := NewService("user_mock")
+	first, err := service.Create(individual("user-1", ""))
+	if err != nil || first.BusinessID != "user_mock" {
+		t.Fatalf("expected a customer of the mock's user, got %+v %v", first, err)
+	}
+
+	if existing, err := service.Create(individual("user-1", "")); !errors.Is(err, ErrDuplicateRef) || existing.ID != first.ID {
+		t.Fatalf("expected ErrDuplicateRef with the existing customer, got %s %v", existing.ID, err)
+	}
+	other, err := service.Create(individual("user-1", "sub-account-1"))
+	if err != nil || other.BusinessID != "sub-account-1" {
+		t.Fatalf("expected another business to reuse the reference_id, got %+v %v", other, err)
+	}
+
+	if !service.Exists("", first.ID) || service.Exists("sub-account-1", first.ID) {
+		t.Fatal("expected the customer to exist only for its own business")
+	}
+	if found := service.Search("sub-account-1", "user-1"); len(found) != 1 || found[0].ID != other.ID {
+		t.Fatalf("expected search to return the business's own customer, got %+v", found)
+	}
+}
+
+func TestUpdateKeepsTheCustomerType(t *testing.T) {
+	service := NewService("user_mock")
+	customer, _ := service.Create(individual("user-1", ""))
+
+	if _, err := service.Update("", customer.ID, domain.CustomerUpdate{BusinessDetail: &domain.CustomerBusinessDetail{BusinessName: "PT Budi"}}); !errors.Is(err, ErrDetailTypeInvalid) {
+		t.Fatalf("expected ErrDetailTypeInvalid, got %v", err)
+	}
+	email := "budi@example.com"
+	updated, err := service.Update("", customer.ID, domain.CustomerUpdate{Email: &email})
+	if err != nil || updated.Email != email || updated.IndividualDetail == nil || updated.IndividualDetail.GivenNames != "Budi" {
+		t.Fatalf("expected only the email changed, got %+v %v", updated, err)
+	}
+	if _, err := service.Update("", "cust_missing", domain.CustomerUpdate{Email: &email}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetAndUpdateAreScopedToTheBusiness(t *testing.T) {
+	service := NewService("user_mock")
+	customer, _ := service.Create(individual("user-1", "sub-account-1"))
+
+	if _, ok := service.Get("", customer.ID); ok {
+		t.Fatal("expected another business not to get the customer")
+	}
+	email := "budi@example.com"
+	if _, err := service.Update("", customer.ID, domain.CustomerUpdate{Email: &email}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating another business's customer, got %v", err)
+	}
+	if updated, err := service.Update("sub-account-1", customer.ID, domain.CustomerUpdate{Email: &email}); err != nil || updated.Email != email {
+		t.Fatalf("expected the business to update its own customer, got %+v %v", updated, err)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	service := NewService("user_mock")
+	customer, _ := service.Create(individual("user-1", ""))
+	data, _ := json.Marshal(service.Snapshot())
+
+	restored := NewService("user_mock")
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+	if !restored.Exists("", customer.ID) {
+		t.Fatal("expected the restored customer to exist")
+	}
+	if _, err := restored.Create(individual("user-1", "")); !errors.Is(err, ErrDuplicateRef) {
+		t.Fatalf("expected the restored reference_id to stay taken, got %v", err)
+	}
+}
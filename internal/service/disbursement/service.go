@@ -1,35 +1,259 @@
 package disbursement
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
 	"xendit-api-mock/internal/callback"
 	"xendit-api-mock/internal/domain"
 	"xendit-api-mock/internal/scenario"
 )
 
+var ErrNotFound = errors.New("disbursement not found")
+
 type Service struct {
-	engine *scenario.Engine
-	cb     *callback.Client
-	userID string
+	engine  *scenario.Engine
+	cb      callback.Sender
+	userID  string
+	ledger  *balance.Ledger
+	mu      sync.Mutex
+	records map[string]Record
+	// generation changes on Reset and Restore so that reversals scheduled
+	// before them do not fire.
+	generation int
+}
+
+type Record struct {
+	Request  domain.DisbursementRequest  `json:"request"`
+	Response domain.DisbursementResponse `json:"response"`
+	Session  string                      `json:"session,omitempty"`
+}
+
+type State struct {
+	Engine        scenario.State    `json:"engine"`
+	Disbursements map[string]Record `json:"disbursements"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, records: make(map[string]Record)}
 }
 
-func NewService(engine *scenario.Engine, cb *callback.Client, userID string) *Service {
-	return &Service{engine: engine, cb: cb, userID: userID}
+// WithLedger makes disbursements spend from the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
 }
 
 func (s *Service) Create(req domain.DisbursementRequest) (domain.DisbursementResponse, error) {
-	status := domain.NormalizeStatus(s.engine.PickStatus(req))
-	resp := domain.BuildDisbursementResponse(req, status, s.userID)
-	err := s.cb.Send(domain.BuildCallbackPayload(req, status, s.userID))
+	userID := s.userFor(req)
+	if s.ledger != nil {
+		if err := s.ledger.Withdraw(userID, req.Amount); err != nil {
+			return s.failInsufficientBalance(req, userID)
+		}
+	}
+
+	decision := s.engine.Decide(req)
+	status := domain.NormalizeStatus(decision.Status)
+	if status != domain.StatusCompleted && s.ledger != nil {
+		s.ledger.Refund(userID, req.Amount)
+	}
+	resp := domain.BuildDisbursementResponse(req, status, userID)
+	s.store(req, resp)
+	err := s.sendCallbacks(domain.BuildCallbackPayload(req, status, userID), req, decision.Callback)
+	if decision.Reverse && status == domain.StatusCompleted {
+		s.scheduleReversal(resp.ID, decision.ReverseAfter)
+	}
+	return resp, err
+}
+
+func (s *Service) failInsufficientBalance(req domain.DisbursementRequest, userID string) (domain.DisbursementResponse, error) {
+	resp := domain.BuildDisbursementResponse(req, domain.StatusFailed, userID)
+	resp.FailureCode = domain.FailureInsufficientBalance
+	s.store(req, resp)
+	payload := domain.BuildCallbackPayload(req, domain.StatusFailed, userID)
+	payload.FailureCode = domain.FailureInsufficientBalance
+	err := s.cb.Deliver(callback.DisbursementEvent(payload, req.Session))
 	return resp, err
 }
 
 func (s *Service) SimulateSuccess(req domain.DisbursementRequest) (domain.DisbursementResponse, error) {
 	status := domain.NormalizeStatus(domain.StatusCompleted)
-	resp := domain.BuildDisbursementResponse(req, status, s.userID)
-	err := s.cb.Send(domain.BuildCallbackPayload(req, status, s.userID))
+	userID := s.userFor(req)
+	resp := domain.BuildDisbursementResponse(req, status, userID)
+	s.store(req, resp)
+	err := s.cb.Deliver(callback.DisbursementEvent(domain.BuildCallbackPayload(req, status, userID), req.Session))
 	return resp, err
 }
 
+// SendStatus moves an existing disbursement to status and sends the matching
+// callback without creating a new disbursement.
+func (s *Service) SendStatus(id, status, failureCode string) (domain.DisbursementResponse, error) {
+	s.mu.Lock()
+	record, ok := s.records[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.DisbursementResponse{}, ErrNotFound
+	}
+	payload := domain.BuildCallbackPayload(record.Request, status, record.Response.UserID)
+	payload.Created = record.Response.Created
+	if payload.Status == domain.StatusFailed {
+		payload.FailureCode = failureCode
+	}
+	s.settle(record, payload.Status)
+	record.Response.Status = payload.Status
+	record.Response.FailureCode = payload.FailureCode
+	record.Response.Updated = payload.Updated
+	s.records[id] = record
+	s.mu.Unlock()
+
+	err := s.cb.Deliver(callback.DisbursementEvent(payload, record.Session))
+	return record.Response, err
+}
+
+// settle keeps the balance in line with a status change: leaving COMPLETED
+// credits the funds back, entering it debits them.
+func (s *Service) settle(record Record, status string) {
+	if s.ledger == nil || record.Response.Status == status {
+		return
+	}
+	switch {
+	case record.Response.Status == domain.StatusCompleted:
+		s.ledger.Refund(record.Response.UserID, record.Request.Amount)
+	case status == domain.StatusCompleted:
+		s.ledger.Charge(record.Response.UserID, record.Request.Amount)
+	}
+}
+
+// scheduleReversal returns a completed disbursement after delay, as a bank
+// does when it bounces funds, and sends the REVERSED callback.
+func (s *Service) scheduleReversal(id string, delay time.Duration) {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		record, ok := s.records[id]
+		current := s.generation
+		s.mu.Unlock()
+		if current != generation || !ok || record.Response.Status != domain.StatusCompleted {
+			return
+		}
+		if _, err := s.SendStatus(id, domain.StatusReversed, ""); err != nil {
+			log.Printf("[disbursement.scheduleReversal] reversal failed id=%s error=%v", id, err)
+		}
+	})
+}
+
+// TestEvent builds a well-formed COMPLETED callback for req that is not
+// stored, for checking that a receiver accepts callbacks.
+func (s *Service) TestEvent(req domain.DisbursementRequest) callback.Event {
+	payload := domain.BuildCallbackPayload(req, domain.StatusCompleted, s.userFor(req))
+	return callback.DisbursementEvent(payload, req.Session)
+}
+
 func (s *Service) Reset() {
 	s.engine.Reset()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = make(map[string]Record)
+	s.generation++
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make(map[string]Record, len(s.records))
+	for id, record := range s.records {
+		records[id] = record
+	}
+	return State{Engine: s.engine.Snapshot(), Disbursements: records}
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	s.engine.Restore(state.Engine)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = make(map[string]Record, len(state.Disbursements))
+	for id, record := range state.Disbursements {
+		s.records[id] = record
+	}
+	s.generation++
+	return nil
+}
+
+// sendCallbacks delivers the final callback as shaped by the scenario: repeated,
+// followed by a stale status, delayed or not at all.
+func (s *Service) sendCallbacks(payload domain.CallbackPayload, req domain.DisbursementRequest, behavior *scenario.CallbackBehavior) error {
+	if behavior == nil {
+		return s.cb.Deliver(callback.DisbursementEvent(payload, req.Session))
+	}
+	if behavior.Drop {
+		log.Printf("[disbursement.sendCallbacks] callback dropped by scenario id=%s status=%s", payload.ID, payload.Status)
+		return nil
+	}
+
+	payloads := []domain.CallbackPayload{payload}
+	for n := 1; n < behavior.Repeat; n++ {
+		duplicate := payload
+		if behavior.WebhookID == scenario.WebhookIDNew {
+			duplicate.WebhookID = domain.DuplicateWebhookID(payload.ID, payload.Status, n)
+		}
+		payloads = append(payloads, duplicate)
+	}
+	if behavior.StaleStatus != "" {
+		stale := domain.BuildCallbackPayload(req, behavior.StaleStatus, payload.UserID)
+		stale.Created = payload.Created
+		if updated, err := time.Parse(time.RFC3339, payload.Updated); err == nil {
+			stale.Updated = updated.Add(-time.Second).Format(time.RFC3339)
+		}
+		payloads = append(payloads, stale)
+	}
+
+	send := func() error {
+		var errs []error
+		for _, p := range payloads {
+			event := callback.DisbursementEvent(p, req.Session)
+			event.TokenMode = behavior.Token
+			if err := s.cb.Deliver(event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if behavior.DelayMS <= 0 {
+		return send()
+	}
+
+	time.AfterFunc(time.Duration(behavior.DelayMS)*time.Millisecond, func() {
+		if err := send(); err != nil {
+			log.Printf("[disbursement.sendCallbacks] delayed callback failed id=%s error=%v", payload.ID, err)
+		}
+	})
+	return nil
+}
+
+func (s *Service) store(req domain.DisbursementRequest, resp domain.DisbursementResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[resp.ID] = Record{Request: req, Response: resp, Session: req.Session}
+}
+
+func (s *Service) userFor(req domain.DisbursementRequest) string {
+	if req.ForUserID != "" {
+		return req.ForUserID
+	}
+	return s.userID
 }
@@ -0,0 +1,38 @@
+package disbursement
+
+import (
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestResetDropsScheduledReversals(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{Accounts: []scenario.AccountScenario{
+		{AccountNumber: "acct-1", Disbursements: []scenario.Rule{{Outcome: scenario.OutcomeSuccessThenReverse, ReverseAfterMS: 30}}},
+	}})
+	sender := &callbacktest.Recorder{}
+	service := NewService(engine, sender, "user_mock")
+	req := domain.DisbursementRequest{ExternalID: "disb-1", Amount: 10000, BankCode: "BCA", AccountHolderName: "Budi", AccountNumber: "acct-1"}
+	first, err := service.Create(req)
+	if err != nil || first.Status != domain.StatusCompleted {
+		t.Fatalf("expected the disbursement COMPLETED, got %+v %v", first, err)
+	}
+
+	service.Reset()
+	again, _ := service.SimulateSuccess(req)
+	if again.ID != first.ID {
+		t.Fatalf("expected the same external_id to give the same id, got %s and %s", first.ID, again.ID)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if stored := service.Snapshot().(State).Disbursements[again.ID]; stored.Response.Status != domain.StatusCompleted {
+		t.Fatalf("expected the new disbursement to stay COMPLETED, got %s", stored.Response.Status)
+	}
+	for _, event := range sender.Events() {
+		if event.Status == domain.StatusReversed {
+			t.Fatal("expected no REVERSED callback after a reset")
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package ewallet
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/customer"
+)
+
+var (
+	ErrNotFound         = errors.New("ewallet charge not found")
+	ErrNotPending       = errors.New("ewallet charge is not PENDING")
+	ErrNotSucceeded     = errors.New("ewallet charge is not SUCCEEDED")
+	ErrCustomerNotFound = errors.New("customer not found")
+)
+
+type Service struct {
+	engine    *scenario.Engine
+	cb        callback.Sender
+	userID    string
+	baseURL   string
+	ledger    *balance.Ledger
+	customers *customer.Service
+	mu        sync.Mutex
+	charges   map[string]Record
+}
+
+type Record struct {
+	Request domain.EWalletChargeRequest `json:"request"`
+	Charge  domain.EWalletCharge        `json:"charge"`
+	Session string                      `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, baseURL: "http://localhost:8080", charges: make(map[string]Record)}
+}
+
+// WithBaseURL sets where the mock is reachable, for the checkout URLs in
+// charge actions.
+func (s *Service) WithBaseURL(url string) *Service {
+	s.baseURL = url
+	return s
+}
+
+// WithLedger credits captured charges to the user's CASH balance and debits
+// voids.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// WithCustomers makes charges that name a customer_id fail unless the
+// customer exists.
+func (s *Service) WithCustomers(customers *customer.Service) *Service {
+	s.customers = customers
+	return s
+}
+
+// Create stores a PENDING charge, then applies the scenario's rule for the
+// channel: approve or decline it after the rule's delay. Without a rule the
+// charge waits for the payer on the checkout page.
+func (s *Service) Create(req domain.EWalletChargeRequest) (domain.EWalletCharge, error) {
+	businessID := req.ForUserID
+	if businessID == "" {
+		businessID = s.userID
+	}
+	if req.CustomerID != "" && s.customers != nil && !s.customers.Exists(businessID, req.CustomerID) {
+		return domain.EWalletCharge{}, ErrCustomerNotFound
+	}
+	charge := domain.BuildEWalletCharge(req, businessID, s.baseURL)
+
+	s.mu.Lock()
+	s.charges[charge.ID] = Record{Request: req, Charge: charge, Session: req.Session}
+	s.mu.Unlock()
+
+	rule, ok := s.engine.EWalletRule(req.ChannelCode)
+	if !ok {
+		return charge, nil
+	}
+	var action func() error
+	switch rule.Outcome {
+	case scenario.EWalletSucceed:
+		action = func() error {
+			_, err := s.Approve(charge.ID)
+			return err
+		}
+	case scenario.EWalletFail:
+		action = func() error {
+			_, err := s.Decline(charge.ID, rule.FailureCode)
+			return err
+		}
+	default:
+		return charge, nil
+	}
+	return charge, s.after(time.Duration(rule.DelayMS)*time.Millisecond, charge.ID, action)
+}
+
+// Approve captures a PENDING charge and sends the SUCCEEDED callback.
+func (s *Service) Approve(id string) (domain.EWalletCharge, error) {
+	return s.complete(id, domain.EWalletStatusSucceeded, "")
+}
+
+// Decline fails a PENDING charge with failureCode, USER_DECLINED_PAYMENT by
+// default, and sends the FAILED callback.
+func (s *Service) Decline(id, failureCode string) (domain.EWalletCharge, error) {
+	if failureCode == "" {
+		failureCode = domain.EWalletFailureUserDeclined
+	}
+	return s.complete(id, domain.EWalletStatusFailed, failureCode)
+}
+
+func (s *Service) complete(id, status, failureCode string) (domain.EWalletCharge, error) {
+	s.mu.Lock()
+	record, ok := s.charges[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.EWalletCharge{}, ErrNotFound
+	}
+	if record.Charge.Status != domain.EWalletStatusPending {
+		s.mu.Unlock()
+		return record.Charge, ErrNotPending
+	}
+	record.Charge.Status = status
+	record.Charge.FailureCode = failureCode
+	record.Charge.Updated = time.Now().Format(time.RFC3339)
+	s.charges[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil && status == domain.EWalletStatusSucceeded {
+		s.ledger.TopUp(record.Charge.BusinessID, balance.AccountCash, record.Charge.CaptureAmount)
+	}
+	return record.Charge, s.notify(record)
+}
+
+// Void cancels a SUCCEEDED charge that has not been refunded.
+func (s *Service) Void(id string) (domain.EWalletCharge, error) {
+	s.mu.Lock()
+	record, ok := s.charges[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.EWalletCharge{}, ErrNotFound
+	}
+	if record.Charge.Status != domain.EWalletStatusSucceeded || record.Charge.RefundedAmount > 0 {
+		s.mu.Unlock()
+		return record.Charge, ErrNotSucceeded
+	}
+	now := time.Now().Format(time.RFC3339)
+	record.Charge.Status = domain.EWalletStatusVoided
+	record.Charge.VoidStatus = domain.EWalletStatusSucceeded
+	record.Charge.VoidedAt = now
+	record.Charge.Updated = now
+	s.charges[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		s.ledger.TopUp(record.Charge.BusinessID, balance.AccountCash, -record.Charge.CaptureAmount)
+	}
+	return record.Charge, nil
+}
+
+// ApplyRefund records a succeeded refund of amount against a SUCCEEDED
+// charge, which becomes REFUNDED once nothing is left. The refund service
+// keeps the refunds themselves and debits the balance.
+func (s *Service) ApplyRefund(id string, amount int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.charges[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if record.Charge.Status != domain.EWalletStatusSucceeded {
+		return ErrNotSucceeded
+	}
+	record.Charge.RefundedAmount += amount
+	if record.Charge.RefundedAmount >= record.Charge.CaptureAmount {
+		record.Charge.Status = domain.EWalletStatusRefunded
+	}
+	record.Charge.Updated = time.Now().Format(time.RFC3339)
+	s.charges[id] = record
+	return nil
+}
+
+func (s *Service) Get(id string) (domain.EWalletCharge, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.charges[id]
+	return record.Charge, ok
+}
+
+func (s *Service) notify(record Record) error {
+	webhook := domain.EWalletWebhook{
+		Event:      domain.EWalletEventCapture,
+		BusinessID: record.Charge.BusinessID,
+		Created:    record.Charge.Updated,
+		Data:       record.Charge,
+	}
+	return s.cb.Deliver(callback.EWalletEvent(webhook, record.Session))
+}
+
+// after runs action now when delay is not positive, otherwise in the
+// background, where a charge that already moved on is not an error.
+func (s *Service) after(delay time.Duration, id string, action func() error) error {
+	if delay <= 0 {
+		return action()
+	}
+	time.AfterFunc(delay, func() {
+		if err := action(); err != nil && !errors.Is(err, ErrNotPending) {
+			log.Printf("[ewallet.after] scheduled action failed id=%s error=%v", id, err)
+		}
+	})
+	return nil
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.charges = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charges := make(map[string]Record, len(s.charges))
+	for id, record := range s.charges {
+		charges[id] = record
+	}
+	return charges
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var charges map[string]Record
+	if err := json.Unmarshal(data, &charges); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.charges = make(map[string]Record, len(charges))
+	for id, record := range charges {
+		s.charges[id] = record
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package ewallet
+
+import (
+	"errors"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/customer"
+)
+
+func chargeRequest(channelCode string) domain.EWalletChargeRequest {
+	return domain.EWalletChargeRequest{ReferenceID: "order-1", Currency: "IDR", Amount: 30000, CheckoutMethod: "ONE_TIME_PAYMENT", ChannelCode: channelCode}
+}
+
+func TestChargesFollowTheChannelRule(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{EWallets: []scenario.EWalletRule{
+		{ChannelCode: "ID_OVO", Outcome: scenario.EWalletSucceed},
+		{ChannelCode: "ID_DANA", Outcome: scenario.EWalletFail},
+	}})
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(engine, sender, "user_mock").WithLedger(ledger)
+
+	succeeded, _ := service.Create(chargeRequest("ID_OVO"))
+	if stored, _ := service.Get(succeeded.ID); stored.Status != domain.EWalletStatusSucceeded {
+		t.Fatalf("expected the OVO charge SUCCEEDED, got %s", stored.Status)
+	}
+	declined, _ := service.Create(chargeRequest("ID_DANA"))
+	if stored, _ := service.Get(declined.ID); stored.Status != domain.EWalletStatusFailed || stored.FailureCode != domain.EWalletFailureUserDeclined {
+		t.Fatalf("expected the DANA charge FAILED with the default failure code, got %+v", stored)
+	}
+	pending, _ := service.Create(chargeRequest("ID_SHOPEEPAY"))
+	if stored, _ := service.Get(pending.ID); stored.Status != domain.EWalletStatusPending {
+		t.Fatalf("expected a charge without a rule to wait PENDING, got %s", stored.Status)
+	}
+
+	if len(sender.Events()) != 2 {
+		t.Fatalf("expected a capture callback for each completed charge, got %d", len(sender.Events()))
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 30000 {
+		t.Fatalf("expected only the succeeded charge credited, got %d", got)
+	}
+	if _, err := service.Approve(declined.ID); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected ErrNotPending approving a FAILED charge, got %v", err)
+	}
+}
+
+func TestApplyRefundAndVoid(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+	refunded, _ := service.Create(chargeRequest("ID_OVO"))
+	_, _ = service.Approve(refunded.ID)
+
+	if err := service.ApplyRefund(refunded.ID, 10000); err != nil {
+		t.Fatalf("expected a partial refund to apply, got %v", err)
+	}
+	if _, err := service.Void(refunded.ID); !errors.Is(err, ErrNotSucceeded) {
+		t.Fatalf("expected ErrNotSucceeded voiding a refunded charge, got %v", err)
+	}
+	if err := service.ApplyRefund(refunded.ID, 20000); err != nil {
+		t.Fatalf("expected the rest to be refunded, got %v", err)
+	}
+	if stored, _ := service.Get(refunded.ID); stored.Status != domain.EWalletStatusRefunded || stored.RefundedAmount != 30000 {
+		t.Fatalf("expected the charge REFUNDED, got %+v", stored)
+	}
+	if err := service.ApplyRefund(refunded.ID, 1); !errors.Is(err, ErrNotSucceeded) {
+		t.Fatalf("expected ErrNotSucceeded refunding a REFUNDED charge, got %v", err)
+	}
+
+	voided, _ := service.Create(chargeRequest("ID_OVO"))
+	_, _ = service.Approve(voided.ID)
+	if charge, err := service.Void(voided.ID); err != nil || charge.Status != domain.EWalletStatusVoided {
+		t.Fatalf("expected the charge VOIDED, got %+v %v", charge, err)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 30000 {
+		t.Fatalf("expected the void debited from CASH, got %d", got)
+	}
+}
+
+func TestCreateRequiresTheBusinessCustomer(t *testing.T) {
+	customers := customer.NewService("user_mock")
+	owned, _ := customers.Create(domain.CustomerRequest{ReferenceID: "user-1", Type: domain.CustomerIndividual, ForUserID: "sub-account-1"})
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock").WithCustomers(customers)
+
+	req := chargeRequest("ID_OVO")
+	req.CustomerID = owned.ID
+	if _, err := service.Create(req); !errors.Is(err, ErrCustomerNotFound) {
+		t.Fatalf("expected ErrCustomerNotFound for another business's customer, got %v", err)
+	}
+	req.ForUserID = "sub-account-1"
+	if charge, err := service.Create(req); err != nil || charge.BusinessID != "sub-account-1" {
+		t.Fatalf("expected the sub-account's charge, got %+v %v", charge, err)
+	}
+}
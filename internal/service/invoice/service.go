@@ -0,0 +1,329 @@
+package invoice
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound   = errors.New("invoice not found")
+	ErrNotPending = errors.New("invoice is not PENDING")
+)
+
+type Service struct {
+	engine       *scenario.Engine
+	cb           callback.Sender
+	userID       string
+	merchantName string
+	ledger       *balance.Ledger
+	mu           sync.Mutex
+	invoices     map[string]Record
+}
+
+// Record keeps an invoice with what was refunded of it while PAID, which is
+// taken from HOLDING and so is not settled.
+type Record struct {
+	Request  domain.InvoiceRequest `json:"request"`
+	Invoice  domain.Invoice        `json:"invoice"`
+	Refunded int                   `json:"refunded,omitempty"`
+	Session  string                `json:"session,omitempty"`
+}
+
+type Filter struct {
+	ExternalID string
+	Statuses   []string
+	Limit      int
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, merchantName: "Xendit Mock", invoices: make(map[string]Record)}
+}
+
+func (s *Service) WithMerchantName(name string) *Service {
+	s.merchantName = name
+	return s
+}
+
+// WithLedger credits paid invoices to the user's HOLDING balance and moves
+// them to CASH when they settle.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create stores a PENDING invoice that expires after its invoice_duration,
+// then applies the scenario's invoice rule: pay or expire it after the rule's
+// delay.
+func (s *Service) Create(req domain.InvoiceRequest) (domain.Invoice, error) {
+	userID := req.ForUserID
+	if userID == "" {
+		userID = s.userID
+	}
+	invoice := domain.BuildInvoice(req, userID, s.merchantName)
+
+	s.mu.Lock()
+	s.invoices[invoice.ID] = Record{Request: req, Invoice: invoice, Session: req.Session}
+	s.mu.Unlock()
+
+	s.scheduleExpiry(invoice)
+
+	rule, ok := s.engine.InvoiceRule(req.ExternalID)
+	if !ok {
+		return invoice, nil
+	}
+	delay := time.Duration(rule.DelayMS) * time.Millisecond
+	switch rule.Outcome {
+	case scenario.InvoicePay:
+		payment := domain.InvoicePayment{PaymentMethod: rule.PaymentMethod, PaymentChannel: rule.PaymentChannel, Amount: rule.PaidAmount}
+		return invoice, s.after(delay, invoice.ID, func() error {
+			_, err := s.Pay(invoice.ID, payment)
+			return err
+		})
+	case scenario.InvoiceExpire:
+		return invoice, s.after(delay, invoice.ID, func() error {
+			_, err := s.Expire(invoice.ID)
+			return err
+		})
+	}
+	return invoice, nil
+}
+
+// Pay simulates the payer completing a PENDING invoice and sends the PAID
+// callback.
+func (s *Service) Pay(id string, payment domain.InvoicePayment) (domain.Invoice, error) {
+	if payment.PaymentMethod == "" {
+		payment.PaymentMethod = "BANK_TRANSFER"
+	}
+	if payment.PaymentChannel == "" {
+		payment.PaymentChannel = "BCA"
+	}
+
+	s.mu.Lock()
+	record, ok := s.invoices[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.Invoice{}, ErrNotFound
+	}
+	if record.Invoice.Status != domain.InvoiceStatusPending {
+		s.mu.Unlock()
+		return record.Invoice, ErrNotPending
+	}
+	now := time.Now().Format(time.RFC3339)
+	invoice := record.Invoice
+	invoice.Status = domain.InvoiceStatusPaid
+	invoice.PaymentMethod = payment.PaymentMethod
+	invoice.PaymentChannel = payment.PaymentChannel
+	if payment.PaymentMethod == "BANK_TRANSFER" {
+		invoice.PaymentDestination = domain.NumericCode(invoice.ID, 12)
+	}
+	invoice.PaidAmount = payment.Amount
+	if invoice.PaidAmount <= 0 {
+		invoice.PaidAmount = invoice.Amount
+	}
+	invoice.PaidAt = now
+	invoice.Updated = now
+	record.Invoice = invoice
+	s.invoices[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		s.ledger.TopUp(invoice.UserID, balance.AccountHolding, invoice.PaidAmount)
+	}
+	if rule, ok := s.engine.InvoiceRule(invoice.ExternalID); ok && rule.SettleAfterMS > 0 {
+		s.after(time.Duration(rule.SettleAfterMS)*time.Millisecond, id, func() error {
+			_, err := s.Settle(id)
+			return err
+		})
+	}
+	return invoice, s.cb.Deliver(callback.InvoiceEvent(domain.BuildInvoiceCallbackPayload(invoice), record.Session))
+}
+
+// Settle moves a PAID invoice to SETTLED, as Xendit does once the funds are
+// available. No callback is sent.
+func (s *Service) Settle(id string) (domain.Invoice, error) {
+	s.mu.Lock()
+	record, ok := s.invoices[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.Invoice{}, ErrNotFound
+	}
+	if record.Invoice.Status != domain.InvoiceStatusPaid {
+		s.mu.Unlock()
+		return record.Invoice, nil
+	}
+	record.Invoice.Status = domain.InvoiceStatusSettled
+	record.Invoice.Updated = time.Now().Format(time.RFC3339)
+	s.invoices[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		settled := record.Invoice.PaidAmount - record.Refunded
+		s.ledger.TopUp(record.Invoice.UserID, balance.AccountHolding, -settled)
+		s.ledger.TopUp(record.Invoice.UserID, balance.AccountCash, settled)
+	}
+	return record.Invoice, nil
+}
+
+// ApplyRefund records a succeeded refund of amount and returns the balance
+// account holding the invoice's funds, which the refund is taken from:
+// HOLDING until the invoice is SETTLED, CASH after.
+func (s *Service) ApplyRefund(id string, amount int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.invoices[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if record.Invoice.Status != domain.InvoiceStatusPaid {
+		return balance.AccountCash, nil
+	}
+	record.Refunded += amount
+	s.invoices[id] = record
+	return balance.AccountHolding, nil
+}
+
+// Expire expires a PENDING invoice and sends the EXPIRED callback.
+func (s *Service) Expire(id string) (domain.Invoice, error) {
+	s.mu.Lock()
+	record, ok := s.invoices[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.Invoice{}, ErrNotFound
+	}
+	if record.Invoice.Status != domain.InvoiceStatusPending {
+		s.mu.Unlock()
+		return record.Invoice, ErrNotPending
+	}
+	now := time.Now().Format(time.RFC3339)
+	record.Invoice.Status = domain.InvoiceStatusExpired
+	record.Invoice.ExpiryDate = now
+	record.Invoice.Updated = now
+	s.invoices[id] = record
+	s.mu.Unlock()
+
+	return record.Invoice, s.cb.Deliver(callback.InvoiceEvent(domain.BuildInvoiceCallbackPayload(record.Invoice), record.Session))
+}
+
+func (s *Service) Get(id string) (domain.Invoice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.invoices[id]
+	return record.Invoice, ok
+}
+
+// List returns matching invoices, newest first.
+func (s *Service) List(filter Filter) []domain.Invoice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make(map[string]bool, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+	invoices := make([]domain.Invoice, 0)
+	for _, record := range s.invoices {
+		if filter.ExternalID != "" && record.Invoice.ExternalID != filter.ExternalID {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[record.Invoice.Status] {
+			continue
+		}
+		invoices = append(invoices, record.Invoice)
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		if invoices[i].Created != invoices[j].Created {
+			return invoices[i].Created > invoices[j].Created
+		}
+		return invoices[i].ID < invoices[j].ID
+	})
+	if filter.Limit > 0 && len(invoices) > filter.Limit {
+		invoices = invoices[:filter.Limit]
+	}
+	return invoices
+}
+
+// scheduleExpiry expires a PENDING invoice at its expiry date unless the date
+// has changed by then, as it does when a snapshot is restored.
+func (s *Service) scheduleExpiry(invoice domain.Invoice) {
+	expiry, err := time.Parse(time.RFC3339, invoice.ExpiryDate)
+	if err != nil {
+		return
+	}
+	time.AfterFunc(time.Until(expiry), func() {
+		s.mu.Lock()
+		current := s.invoices[invoice.ID].Invoice
+		s.mu.Unlock()
+		if current.ExpiryDate != invoice.ExpiryDate {
+			return
+		}
+		if _, err := s.Expire(invoice.ID); err != nil && !errors.Is(err, ErrNotPending) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[invoice.scheduleExpiry] expiry failed id=%s error=%v", invoice.ID, err)
+		}
+	})
+}
+
+// after runs action now when delay is not positive, otherwise in the
+// background, where an invoice that already moved on is not an error.
+func (s *Service) after(delay time.Duration, id string, action func() error) error {
+	if delay <= 0 {
+		return action()
+	}
+	time.AfterFunc(delay, func() {
+		if err := action(); err != nil && !errors.Is(err, ErrNotPending) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[invoice.after] scheduled action failed id=%s error=%v", id, err)
+		}
+	})
+	return nil
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.invoices = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoices := make(map[string]Record, len(s.invoices))
+	for id, record := range s.invoices {
+		invoices[id] = record
+	}
+	return invoices
+}
+
+// Restore replaces the invoices with the snapshot's and schedules the expiry
+// of the PENDING ones again.
+func (s *Service) Restore(data json.RawMessage) error {
+	var invoices map[string]Record
+	if err := json.Unmarshal(data, &invoices); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.invoices = make(map[string]Record, len(invoices))
+	for id, record := range invoices {
+		s.invoices[id] = record
+	}
+	s.mu.Unlock()
+
+	for _, record := range invoices {
+		if record.Invoice.Status == domain.InvoiceStatusPending {
+			s.scheduleExpiry(record.Invoice)
+		}
+	}
+	return nil
+}
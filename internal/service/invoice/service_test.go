@@ -0,0 +1,62 @@
+package invoice
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestRestoreSchedulesPendingExpiry(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	expiry := time.Now().Add(-time.Second).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{
+		"inv_pending": {Invoice: domain.Invoice{ID: "inv_pending", Status: domain.InvoiceStatusPending, ExpiryDate: expiry}},
+		"inv_paid":    {Invoice: domain.Invoice{ID: "inv_paid", Status: domain.InvoiceStatusPaid, ExpiryDate: expiry}},
+	})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		inv, _ := service.Get("inv_pending")
+		if inv.Status == domain.InvoiceStatusExpired {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the restored PENDING invoice to expire, got %s", inv.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if inv, _ := service.Get("inv_paid"); inv.Status != domain.InvoiceStatusPaid {
+		t.Fatalf("expected the PAID invoice left alone, got %s", inv.Status)
+	}
+}
+
+func TestApplyRefundUsesTheAccountHoldingTheFunds(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+	inv, _ := service.Create(domain.InvoiceRequest{ExternalID: "order-1", Amount: 50000})
+	if _, err := service.Pay(inv.ID, domain.InvoicePayment{}); err != nil {
+		t.Fatalf("expected payment to succeed, got %v", err)
+	}
+
+	if account, err := service.ApplyRefund(inv.ID, 20000); err != nil || account != balance.AccountHolding {
+		t.Fatalf("expected an unsettled refund from HOLDING, got %q %v", account, err)
+	}
+	ledger.TopUp("user_mock", balance.AccountHolding, -20000)
+	if _, err := service.Settle(inv.ID); err != nil {
+		t.Fatalf("expected settlement to succeed, got %v", err)
+	}
+	if holding, cash := ledger.Balance("user_mock", balance.AccountHolding), ledger.Balance("user_mock", balance.AccountCash); holding != 0 || cash != 30000 {
+		t.Fatalf("expected only what was not refunded settled, got HOLDING %d CASH %d", holding, cash)
+	}
+	if account, err := service.ApplyRefund(inv.ID, 10000); err != nil || account != balance.AccountCash {
+		t.Fatalf("expected a settled refund from CASH, got %q %v", account, err)
+	}
+}
@@ -0,0 +1,151 @@
+package namevalidation
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+type Service struct {
+	engine      *scenario.Engine
+	cb          callback.Sender
+	userID      string
+	mu          sync.Mutex
+	validations map[string]Record
+}
+
+type Record struct {
+	Request    domain.NameValidationRequest `json:"request"`
+	Validation domain.NameValidation        `json:"validation"`
+	Session    string                       `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, validations: make(map[string]Record)}
+}
+
+// Create validates an account against the scenario's known accounts; unknown
+// accounts validate to a name generated from the account number. Pending
+// entries answer PENDING and deliver the result by callback after the delay.
+func (s *Service) Create(req domain.NameValidationRequest) (domain.NameValidation, error) {
+	userID := req.ForUserID
+	if userID == "" {
+		userID = s.userID
+	}
+	now := time.Now().Format(time.RFC3339)
+	validation := domain.NameValidation{
+		ID:                domain.NameValidationID(req),
+		UserID:            userID,
+		BankCode:          req.BankCode,
+		BankAccountNumber: req.BankAccountNumber,
+		Created:           now,
+		Updated:           now,
+	}
+
+	entry, _ := s.engine.NameValidation(req.BankCode, req.BankAccountNumber)
+	if !entry.Pending {
+		validation = resolve(validation, entry)
+		s.store(Record{Request: req, Validation: validation, Session: req.Session})
+		return validation, nil
+	}
+
+	validation.Status = domain.NameValidationPending
+	s.store(Record{Request: req, Validation: validation, Session: req.Session})
+	if entry.DelayMS <= 0 {
+		return validation, s.complete(validation.ID, entry)
+	}
+	time.AfterFunc(time.Duration(entry.DelayMS)*time.Millisecond, func() {
+		if err := s.complete(validation.ID, entry); err != nil {
+			log.Printf("[namevalidation.Create] callback failed id=%s error=%v", validation.ID, err)
+		}
+	})
+	return validation, nil
+}
+
+func (s *Service) complete(id string, entry scenario.NameValidation) error {
+	s.mu.Lock()
+	record, ok := s.validations[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+	record.Validation = resolve(record.Validation, entry)
+	record.Validation.Updated = time.Now().Format(time.RFC3339)
+	s.validations[id] = record
+	s.mu.Unlock()
+
+	return s.cb.Deliver(callback.NameValidationEvent(record.Validation, record.Session))
+}
+
+func resolve(validation domain.NameValidation, entry scenario.NameValidation) domain.NameValidation {
+	if entry.Outcome == "fail" {
+		validation.Status = domain.NameValidationFailed
+		validation.FailureReason = entry.FailureReason
+		if validation.FailureReason == "" {
+			validation.FailureReason = domain.FailureInvalidDestination
+		}
+		return validation
+	}
+
+	validation.Status = domain.NameValidationSuccess
+	validation.IsNormalized = true
+	validation.BankAccountHolderName = entry.HolderName
+	if validation.BankAccountHolderName == "" {
+		validation.BankAccountHolderName = domain.GeneratedHolderName(validation.BankAccountNumber)
+	}
+	return validation
+}
+
+func (s *Service) Get(id string) (domain.NameValidation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.validations[id]
+	return record.Validation, ok
+}
+
+func (s *Service) store(record Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validations[record.Validation.ID] = record
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validations = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	validations := make(map[string]Record, len(s.validations))
+	for id, record := range s.validations {
+		validations[id] = record
+	}
+	return validations
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var validations map[string]Record
+	if err := json.Unmarshal(data, &validations); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validations = make(map[string]Record, len(validations))
+	for id, record := range validations {
+		s.validations[id] = record
+	}
+	return nil
+}
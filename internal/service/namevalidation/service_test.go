@@ -0,0 +1,51 @@
+package namevalidation
+
+import (
+	"testing"
+
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestCreateResolvesKnownAndUnknownAccounts(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{NameValidations: []scenario.NameValidation{
+		{BankCode: "BCA", AccountNumber: "111", HolderName: "Budi Santoso"},
+		{AccountNumber: "222", Outcome: "fail"},
+	}})
+	sender := &callbacktest.Recorder{}
+	service := NewService(engine, sender, "user_mock")
+
+	known, _ := service.Create(domain.NameValidationRequest{BankCode: "BCA", BankAccountNumber: "111"})
+	if known.Status != domain.NameValidationSuccess || known.BankAccountHolderName != "Budi Santoso" {
+		t.Fatalf("expected the configured holder name, got %+v", known)
+	}
+	failed, _ := service.Create(domain.NameValidationRequest{BankCode: "BNI", BankAccountNumber: "222"})
+	if failed.Status != domain.NameValidationFailed || failed.FailureReason != domain.FailureInvalidDestination {
+		t.Fatalf("expected a FAILED validation with the default reason on any bank, got %+v", failed)
+	}
+	unknown, _ := service.Create(domain.NameValidationRequest{BankCode: "BCA", BankAccountNumber: "333"})
+	if unknown.Status != domain.NameValidationSuccess || unknown.BankAccountHolderName != domain.GeneratedHolderName("333") {
+		t.Fatalf("expected a generated holder name for an unknown account, got %+v", unknown)
+	}
+	if len(sender.Events()) != 0 {
+		t.Fatalf("expected no callbacks for validations answered straight away, got %d", len(sender.Events()))
+	}
+}
+
+func TestPendingValidationsCompleteByCallback(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{NameValidations: []scenario.NameValidation{{AccountNumber: "111", HolderName: "Budi Santoso", Pending: true}}})
+	sender := &callbacktest.Recorder{}
+	service := NewService(engine, sender, "user_mock")
+
+	validation, err := service.Create(domain.NameValidationRequest{BankCode: "BCA", BankAccountNumber: "111"})
+	if err != nil || validation.Status != domain.NameValidationPending {
+		t.Fatalf("expected the validation answered PENDING, got %+v %v", validation, err)
+	}
+	if stored, _ := service.Get(validation.ID); stored.Status != domain.NameValidationSuccess {
+		t.Fatalf("expected the stored validation resolved, got %s", stored.Status)
+	}
+	if len(sender.Events()) != 1 || sender.Events()[0].Status != domain.NameValidationSuccess {
+		t.Fatalf("expected one SUCCESS callback, got %+v", sender.Events())
+	}
+}
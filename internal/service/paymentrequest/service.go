@@ -0,0 +1,482 @@
+package paymentrequest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/customer"
+)
+
+var (
+	ErrNotFound         = errors.New("payment request not found")
+	ErrMethodNotFound   = errors.New("payment method not found")
+	ErrMethodNotActive  = errors.New("payment method is not ACTIVE")
+	ErrNotPending       = errors.New("payment request is no longer awaiting payment")
+	ErrNoPendingAction  = errors.New("no authentication is pending")
+	ErrCustomerNotFound = errors.New("customer not found")
+)
+
+type Service struct {
+	engine    *scenario.Engine
+	cb        callback.Sender
+	userID    string
+	baseURL   string
+	ledger    *balance.Ledger
+	customers *customer.Service
+	mu        sync.Mutex
+	requests  map[string]RequestRecord
+	methods   map[string]MethodRecord
+}
+
+type RequestRecord struct {
+	Request        domain.PaymentRequestRequest `json:"request"`
+	PaymentRequest domain.PaymentRequest        `json:"payment_request"`
+	Session        string                       `json:"session,omitempty"`
+}
+
+type MethodRecord struct {
+	Request       domain.PaymentMethodRequest `json:"request"`
+	PaymentMethod domain.PaymentMethod        `json:"payment_method"`
+	Session       string                      `json:"session,omitempty"`
+}
+
+type State struct {
+	PaymentRequests map[string]RequestRecord `json:"payment_requests"`
+	PaymentMethods  map[string]MethodRecord  `json:"payment_methods"`
+}
+
+type Filter struct {
+	ReferenceID string
+	CustomerID  string
+	Limit       int
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{
+		engine:   engine,
+		cb:       cb,
+		userID:   userID,
+		baseURL:  "http://localhost:8080",
+		requests: make(map[string]RequestRecord),
+		methods:  make(map[string]MethodRecord),
+	}
+}
+
+// WithBaseURL sets where the mock is reachable, for the authentication URLs
+// in actions.
+func (s *Service) WithBaseURL(url string) *Service {
+	s.baseURL = url
+	return s
+}
+
+// WithLedger credits succeeded payments to the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// WithCustomers makes requests and methods that name a customer_id fail
+// unless the customer exists.
+func (s *Service) WithCustomers(customers *customer.Service) *Service {
+	s.customers = customers
+	return s
+}
+
+// CreateMethod stores a payment method. Reusable e-wallets and direct debits
+// need linking and wait in REQUIRES_ACTION for the customer; others are
+// ACTIVE straight away.
+func (s *Service) CreateMethod(req domain.PaymentMethodRequest) (domain.PaymentMethod, error) {
+	businessID := s.businessID(req.ForUserID)
+	if !s.customerExists(businessID, req.CustomerID) {
+		return domain.PaymentMethod{}, ErrCustomerNotFound
+	}
+	method := domain.BuildPaymentMethod(req, businessID)
+	if method.NeedsLinking() {
+		method.Status = domain.PaymentMethodRequiresAction
+		method.Actions = domain.AuthActions(s.baseURL, method.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[method.ID] = MethodRecord{Request: req, PaymentMethod: method, Session: req.Session}
+	return method, nil
+}
+
+// Create stores a payment request. Requests paid with a new method that needs
+// authentication or linking wait in REQUIRES_ACTION; others are PENDING. The
+// scenario's rule for the channel then settles it.
+func (s *Service) Create(req domain.PaymentRequestRequest) (domain.PaymentRequest, error) {
+	businessID := s.businessID(req.ForUserID)
+	if !s.customerExists(businessID, req.CustomerID) || (req.PaymentMethod != nil && !s.customerExists(businessID, req.PaymentMethod.CustomerID)) {
+		return domain.PaymentRequest{}, ErrCustomerNotFound
+	}
+
+	s.mu.Lock()
+	var method domain.PaymentMethod
+	if req.PaymentMethodID != "" {
+		record, ok := s.methods[req.PaymentMethodID]
+		if !ok || record.PaymentMethod.BusinessID != businessID {
+			s.mu.Unlock()
+			return domain.PaymentRequest{}, ErrMethodNotFound
+		}
+		if record.PaymentMethod.Status != domain.PaymentMethodActive {
+			s.mu.Unlock()
+			return domain.PaymentRequest{}, ErrMethodNotActive
+		}
+		method = record.PaymentMethod
+	} else {
+		method = domain.BuildPaymentMethod(*req.PaymentMethod, businessID)
+		if method.NeedsLinking() {
+			method.Status = domain.PaymentMethodRequiresAction
+		}
+		s.methods[method.ID] = MethodRecord{Request: *req.PaymentMethod, PaymentMethod: method, Session: req.Session}
+	}
+
+	pr := domain.BuildPaymentRequest(req, method, businessID)
+	if req.PaymentMethodID == "" && (method.NeedsAuthentication() || method.NeedsLinking()) {
+		pr.Status = domain.PaymentRequestRequiresAction
+		pr.Actions = domain.AuthActions(s.baseURL, pr.ID)
+	}
+	s.requests[pr.ID] = RequestRecord{Request: req, PaymentRequest: pr, Session: req.Session}
+	s.mu.Unlock()
+
+	rule, ok := s.engine.PaymentRequestRule(method.ChannelCode())
+	if !ok {
+		return pr, nil
+	}
+	var action func() error
+	switch rule.Outcome {
+	case scenario.PaymentRequestSucceed:
+		action = func() error {
+			_, err := s.complete(pr.ID, domain.PaymentRequestSucceeded, "")
+			return err
+		}
+	case scenario.PaymentRequestFail:
+		failureCode := rule.FailureCode
+		if failureCode == "" {
+			failureCode = domain.PaymentFailureDeclined
+		}
+		action = func() error {
+			_, err := s.complete(pr.ID, domain.PaymentRequestFailed, failureCode)
+			return err
+		}
+	default:
+		return pr, nil
+	}
+
+	if rule.DelayMS <= 0 {
+		return pr, action()
+	}
+	time.AfterFunc(time.Duration(rule.DelayMS)*time.Millisecond, func() {
+		if err := action(); err != nil && !errors.Is(err, ErrNotPending) {
+			log.Printf("[paymentrequest.Create] scheduled %s failed id=%s error=%v", rule.Outcome, pr.ID, err)
+		}
+	})
+	return pr, nil
+}
+
+// Simulate pays a payment request as if the customer completed it.
+func (s *Service) Simulate(id string) (domain.PaymentRequest, error) {
+	return s.complete(id, domain.PaymentRequestSucceeded, "")
+}
+
+// Cancel cancels a payment request that has not been paid. No webhook is
+// sent.
+func (s *Service) Cancel(id string) (domain.PaymentRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.requests[id]
+	if !ok {
+		return domain.PaymentRequest{}, ErrNotFound
+	}
+	if !awaitingPayment(record.PaymentRequest) {
+		return record.PaymentRequest, ErrNotPending
+	}
+	record.PaymentRequest.Status = domain.PaymentRequestCanceled
+	record.PaymentRequest.Actions = []domain.PaymentAction{}
+	record.PaymentRequest.Updated = time.Now().Format(time.RFC3339)
+	s.requests[id] = record
+	return record.PaymentRequest, nil
+}
+
+// Authenticate completes the customer's step on the action page for a
+// payment request or payment method, and returns where to send the customer
+// next.
+func (s *Service) Authenticate(id string, approved bool) (string, error) {
+	if strings.HasPrefix(id, "pm-") {
+		return s.authenticateMethod(id, approved)
+	}
+
+	s.mu.Lock()
+	record, ok := s.requests[id]
+	s.mu.Unlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+	if record.PaymentRequest.Status != domain.PaymentRequestRequiresAction {
+		return "", ErrNoPendingAction
+	}
+	if approved {
+		_, err := s.complete(id, domain.PaymentRequestSucceeded, "")
+		return returnURL(record.PaymentRequest.PaymentMethod, "success_return_url"), err
+	}
+	_, err := s.complete(id, domain.PaymentRequestFailed, domain.PaymentFailureDeclined)
+	return returnURL(record.PaymentRequest.PaymentMethod, "failure_return_url"), err
+}
+
+func (s *Service) authenticateMethod(id string, approved bool) (string, error) {
+	s.mu.Lock()
+	record, ok := s.methods[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", ErrMethodNotFound
+	}
+	if record.PaymentMethod.Status != domain.PaymentMethodRequiresAction {
+		s.mu.Unlock()
+		return "", ErrNoPendingAction
+	}
+	next := returnURL(record.PaymentMethod, "success_return_url")
+	if !approved {
+		record.PaymentMethod.Status = domain.PaymentMethodFailed
+		record.PaymentMethod.FailureCode = domain.PaymentFailureDeclined
+		next = returnURL(record.PaymentMethod, "failure_return_url")
+	}
+	record.PaymentMethod.Updated = time.Now().Format(time.RFC3339)
+	record.PaymentMethod.Actions = []domain.PaymentAction{}
+	s.methods[id] = record
+	s.mu.Unlock()
+
+	if !approved {
+		return next, nil
+	}
+	_, err := s.activate(id)
+	return next, err
+}
+
+// activate makes a linked method ACTIVE and sends the
+// payment_method.activated webhook.
+func (s *Service) activate(id string) (domain.PaymentMethod, error) {
+	s.mu.Lock()
+	record := s.methods[id]
+	record.PaymentMethod.Status = domain.PaymentMethodActive
+	record.PaymentMethod.Actions = []domain.PaymentAction{}
+	record.PaymentMethod.Updated = time.Now().Format(time.RFC3339)
+	s.methods[id] = record
+	s.mu.Unlock()
+
+	webhook := domain.PaymentMethodWebhook{
+		Event:      domain.PaymentMethodEventActivated,
+		BusinessID: record.PaymentMethod.BusinessID,
+		Created:    record.PaymentMethod.Updated,
+		Data:       record.PaymentMethod,
+	}
+	return record.PaymentMethod, s.cb.Deliver(callback.PaymentMethodEvent(webhook, record.Session))
+}
+
+// complete settles a payment request that is awaiting payment and sends the
+// payment.succeeded or payment.failed webhook. A method linked by a
+// successful payment is activated too.
+func (s *Service) complete(id, status, failureCode string) (domain.PaymentRequest, error) {
+	s.mu.Lock()
+	record, ok := s.requests[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.PaymentRequest{}, ErrNotFound
+	}
+	if !awaitingPayment(record.PaymentRequest) {
+		s.mu.Unlock()
+		return record.PaymentRequest, ErrNotPending
+	}
+	pr := record.PaymentRequest
+	pr.Status = status
+	pr.FailureCode = failureCode
+	pr.Actions = []domain.PaymentAction{}
+	pr.Updated = time.Now().Format(time.RFC3339)
+	method := s.methods[pr.PaymentMethod.ID]
+	linking := method.PaymentMethod.Status == domain.PaymentMethodRequiresAction
+	if linking && status == domain.PaymentRequestFailed {
+		method.PaymentMethod.Status = domain.PaymentMethodFailed
+		method.PaymentMethod.FailureCode = failureCode
+		method.PaymentMethod.Updated = pr.Updated
+		s.methods[pr.PaymentMethod.ID] = method
+		pr.PaymentMethod = method.PaymentMethod
+	}
+	record.PaymentRequest = pr
+	s.requests[id] = record
+	s.mu.Unlock()
+
+	var errs []error
+	if linking && status == domain.PaymentRequestSucceeded {
+		method, err := s.activate(pr.PaymentMethod.ID)
+		errs = append(errs, err)
+		s.mu.Lock()
+		record = s.requests[id]
+		record.PaymentRequest.PaymentMethod = method
+		pr = record.PaymentRequest
+		s.requests[id] = record
+		s.mu.Unlock()
+	}
+	if s.ledger != nil && status == domain.PaymentRequestSucceeded {
+		s.ledger.TopUp(pr.BusinessID, balance.AccountCash, pr.Amount)
+	}
+
+	event := domain.PaymentEventSucceeded
+	if status == domain.PaymentRequestFailed {
+		event = domain.PaymentEventFailed
+	}
+	webhook := domain.PaymentWebhook{Event: event, BusinessID: pr.BusinessID, Created: pr.Updated, Data: domain.BuildPayment(pr)}
+	errs = append(errs, s.cb.Deliver(callback.PaymentEvent(webhook, record.Session)))
+	return pr, errors.Join(errs...)
+}
+
+func (s *Service) Get(id string) (domain.PaymentRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.requests[id]
+	return record.PaymentRequest, ok
+}
+
+func (s *Service) GetMethod(id string) (domain.PaymentMethod, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.methods[id]
+	return record.PaymentMethod, ok
+}
+
+// List returns matching payment requests, newest first.
+func (s *Service) List(filter Filter) []domain.PaymentRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]domain.PaymentRequest, 0)
+	for _, record := range s.requests {
+		pr := record.PaymentRequest
+		if filter.matches(pr.ReferenceID, pr.CustomerID) {
+			requests = append(requests, pr)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].Created != requests[j].Created {
+			return requests[i].Created > requests[j].Created
+		}
+		return requests[i].ID < requests[j].ID
+	})
+	if filter.Limit > 0 && len(requests) > filter.Limit {
+		requests = requests[:filter.Limit]
+	}
+	return requests
+}
+
+// ListMethods returns matching payment methods, newest first.
+func (s *Service) ListMethods(filter Filter) []domain.PaymentMethod {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methods := make([]domain.PaymentMethod, 0)
+	for _, record := range s.methods {
+		pm := record.PaymentMethod
+		if filter.matches(pm.ReferenceID, pm.CustomerID) {
+			methods = append(methods, pm)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if methods[i].Created != methods[j].Created {
+			return methods[i].Created > methods[j].Created
+		}
+		return methods[i].ID < methods[j].ID
+	})
+	if filter.Limit > 0 && len(methods) > filter.Limit {
+		methods = methods[:filter.Limit]
+	}
+	return methods
+}
+
+func (f Filter) matches(referenceID, customerID string) bool {
+	return (f.ReferenceID == "" || f.ReferenceID == referenceID) && (f.CustomerID == "" || f.CustomerID == customerID)
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = make(map[string]RequestRecord)
+	s.methods = make(map[string]MethodRecord)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		PaymentRequests: make(map[string]RequestRecord, len(s.requests)),
+		PaymentMethods:  make(map[string]MethodRecord, len(s.methods)),
+	}
+	for id, record := range s.requests {
+		state.PaymentRequests[id] = record
+	}
+	for id, record := range s.methods {
+		state.PaymentMethods[id] = record
+	}
+	return state
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = make(map[string]RequestRecord, len(state.PaymentRequests))
+	for id, record := range state.PaymentRequests {
+		s.requests[id] = record
+	}
+	s.methods = make(map[string]MethodRecord, len(state.PaymentMethods))
+	for id, record := range state.PaymentMethods {
+		s.methods[id] = record
+	}
+	return nil
+}
+
+func (s *Service) customerExists(businessID, id string) bool {
+	return id == "" || s.customers == nil || s.customers.Exists(businessID, id)
+}
+
+func (s *Service) businessID(forUserID string) string {
+	if forUserID != "" {
+		return forUserID
+	}
+	return s.userID
+}
+
+func awaitingPayment(pr domain.PaymentRequest) bool {
+	return pr.Status == domain.PaymentRequestRequiresAction || pr.Status == domain.PaymentRequestPending
+}
+
+// returnURL reads a redirect URL such as success_return_url from the
+// method's channel properties.
+func returnURL(method domain.PaymentMethod, key string) string {
+	for _, channel := range []*domain.PaymentChannel{method.EWallet, method.DirectDebit, method.Card} {
+		if channel == nil {
+			continue
+		}
+		if url, ok := channel.ChannelProperties[key].(string); ok {
+			return url
+		}
+	}
+	return ""
+}
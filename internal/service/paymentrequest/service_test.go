@@ -0,0 +1,94 @@
+package paymentrequest
+
+import (
+	"errors"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func ewalletMethod(reusability string) *domain.PaymentMethodRequest {
+	return &domain.PaymentMethodRequest{
+		Type:        domain.PaymentMethodEWallet,
+		Reusability: reusability,
+		EWallet: &domain.PaymentChannel{
+			ChannelCode:       "DANA",
+			ChannelProperties: map[string]any{"success_return_url": "https://shop.test/success", "failure_return_url": "https://shop.test/failure"},
+		},
+	}
+}
+
+func TestPayingWithANewReusableMethodLinksIt(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+	pr, err := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-1", Amount: 40000, Currency: "IDR", PaymentMethod: ewalletMethod(domain.PaymentMethodMultipleUse)})
+	if err != nil || pr.Status != domain.PaymentRequestRequiresAction {
+		t.Fatalf("expected the request to wait for the customer, got %+v %v", pr, err)
+	}
+	methodID := pr.PaymentMethod.ID
+	if _, err := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-2", Amount: 40000, Currency: "IDR", PaymentMethodID: methodID}); !errors.Is(err, ErrMethodNotActive) {
+		t.Fatalf("expected ErrMethodNotActive before linking, got %v", err)
+	}
+
+	next, err := service.Authenticate(pr.ID, true)
+	if err != nil || next != "https://shop.test/success" {
+		t.Fatalf("expected to be sent to the success URL, got %q %v", next, err)
+	}
+	if stored, _ := service.Get(pr.ID); stored.Status != domain.PaymentRequestSucceeded {
+		t.Fatalf("expected the request SUCCEEDED, got %s", stored.Status)
+	}
+	if method, _ := service.GetMethod(methodID); method.Status != domain.PaymentMethodActive {
+		t.Fatalf("expected the method ACTIVE once linked, got %s", method.Status)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 40000 {
+		t.Fatalf("expected the payment credited to CASH, got %d", got)
+	}
+	if again, err := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-2", Amount: 40000, Currency: "IDR", PaymentMethodID: methodID}); err != nil || again.Status != domain.PaymentRequestPending {
+		t.Fatalf("expected the linked method to pay without an action, got %+v %v", again, err)
+	}
+	if _, err := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-3", Amount: 40000, Currency: "IDR", PaymentMethodID: methodID, ForUserID: "sub-account-1"}); !errors.Is(err, ErrMethodNotFound) {
+		t.Fatalf("expected ErrMethodNotFound paying with another business's method, got %v", err)
+	}
+}
+
+func TestDecliningALinkFailsTheMethod(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	method, err := service.CreateMethod(*ewalletMethod(domain.PaymentMethodMultipleUse))
+	if err != nil || method.Status != domain.PaymentMethodRequiresAction {
+		t.Fatalf("expected the method to need linking, got %+v %v", method, err)
+	}
+
+	next, err := service.Authenticate(method.ID, false)
+	if err != nil || next != "https://shop.test/failure" {
+		t.Fatalf("expected to be sent to the failure URL, got %q %v", next, err)
+	}
+	if stored, _ := service.GetMethod(method.ID); stored.Status != domain.PaymentMethodFailed {
+		t.Fatalf("expected the method FAILED, got %s", stored.Status)
+	}
+	if _, err := service.Authenticate(method.ID, true); !errors.Is(err, ErrNoPendingAction) {
+		t.Fatalf("expected ErrNoPendingAction authenticating twice, got %v", err)
+	}
+}
+
+func TestRequestsSettleByRuleOrWaitToBeCancelled(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{PaymentRequests: []scenario.PaymentRequestRule{{ChannelCode: "OVO", Outcome: scenario.PaymentRequestFail}}})
+	service := NewService(engine, &callbacktest.Recorder{}, "user_mock")
+
+	ovo := ewalletMethod(domain.PaymentMethodOneTimeUse)
+	ovo.EWallet.ChannelCode = "OVO"
+	failed, _ := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-1", Amount: 40000, Currency: "IDR", PaymentMethod: ovo})
+	if stored, _ := service.Get(failed.ID); stored.Status != domain.PaymentRequestFailed || stored.FailureCode != domain.PaymentFailureDeclined {
+		t.Fatalf("expected the OVO request FAILED with the default failure code, got %+v", stored)
+	}
+
+	pending, _ := service.Create(domain.PaymentRequestRequest{ReferenceID: "order-2", Amount: 40000, Currency: "IDR", PaymentMethod: ewalletMethod(domain.PaymentMethodOneTimeUse)})
+	if cancelled, err := service.Cancel(pending.ID); err != nil || cancelled.Status != domain.PaymentRequestCanceled {
+		t.Fatalf("expected the request CANCELED, got %+v %v", cancelled, err)
+	}
+	if _, err := service.Simulate(pending.ID); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected ErrNotPending paying a CANCELED request, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound            = errors.New("payout not found")
+	ErrNotCancellable      = errors.New("only ACCEPTED payouts can be cancelled")
+	ErrIdempotencyConflict = errors.New("idempotency key was already used with a different request")
+)
+
+type Service struct {
+	engine          *scenario.Engine
+	cb              callback.Sender
+	userID          string
+	processingDelay time.Duration
+	ledger          *balance.Ledger
+	mu              sync.Mutex
+	payouts         map[string]Record
+	idempotency     map[idempotencyKey]string
+	// generation changes on Reset and Restore so that reversals scheduled
+	// before them do not fire.
+	generation int
+}
+
+type Record struct {
+	Request domain.PayoutRequest `json:"request"`
+	Payout  domain.Payout        `json:"payout"`
+	Key     string               `json:"idempotency_key"`
+	Session string               `json:"session,omitempty"`
+	Funded  bool                 `json:"funded"`
+}
+
+// idempotencyKey scopes an Idempotency-key to the business that sent it.
+type idempotencyKey struct {
+	BusinessID string
+	Key        string
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{
+		engine:      engine,
+		cb:          cb,
+		userID:      userID,
+		payouts:     make(map[string]Record),
+		idempotency: make(map[idempotencyKey]string),
+	}
+}
+
+func (s *Service) WithProcessingDelay(delay time.Duration) *Service {
+	s.processingDelay = delay
+	return s
+}
+
+// WithLedger makes payouts spend from the business's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create accepts a payout. A repeated Idempotency-key with the same body
+// returns the original payout; with a different body it is a conflict. Keys
+// are per business, so sub-accounts may use the same ones.
+// The payout stays ACCEPTED (and cancellable) for the processing delay, then
+// the scenario rules decide whether it succeeds or fails.
+func (s *Service) Create(req domain.PayoutRequest) (domain.Payout, error) {
+	businessID := s.businessID(req.ForUserID)
+
+	s.mu.Lock()
+	key := idempotencyKey{BusinessID: businessID, Key: req.IdempotencyKey}
+	if id, ok := s.idempotency[key]; ok {
+		record := s.payouts[id]
+		s.mu.Unlock()
+		if !sameRequest(record.Request, req) {
+			return domain.Payout{}, ErrIdempotencyConflict
+		}
+		return record.Payout, nil
+	}
+	payout := domain.BuildPayout(req, businessID)
+	funded := s.ledger == nil || s.ledger.Withdraw(businessID, req.Amount) == nil
+	s.payouts[payout.ID] = Record{Request: req, Payout: payout, Key: req.IdempotencyKey, Session: req.Session, Funded: funded}
+	s.idempotency[key] = payout.ID
+	s.mu.Unlock()
+
+	if s.processingDelay <= 0 {
+		_, err := s.process(payout.ID)
+		return payout, err
+	}
+	go func() {
+		if _, err := s.process(payout.ID); err != nil {
+			log.Printf("[payout.Create] callback failed id=%s error=%v", payout.ID, err)
+		}
+	}()
+	return payout, nil
+}
+
+func (s *Service) process(id string) (domain.Payout, error) {
+	if s.processingDelay > 0 {
+		time.Sleep(s.processingDelay)
+	}
+
+	s.mu.Lock()
+	record, ok := s.payouts[id]
+	if !ok || record.Payout.Status != domain.PayoutStatusAccepted {
+		s.mu.Unlock()
+		return record.Payout, nil
+	}
+	record.Payout.Status = domain.PayoutStatusRequested
+	record.Payout.Updated = time.Now().Format(time.RFC3339)
+	s.payouts[id] = record
+	s.mu.Unlock()
+
+	payout := record.Payout
+	event := domain.PayoutEventSucceeded
+	payout.Status = domain.PayoutStatusSucceeded
+	var decision scenario.Decision
+	if record.Funded {
+		decision = s.engine.Decide(record.Request.DisbursementRequest())
+	} else {
+		decision.Status = domain.StatusFailed
+		payout.FailureCode = domain.FailureInsufficientBalance
+	}
+	if decision.Status != domain.StatusCompleted {
+		event = domain.PayoutEventFailed
+		payout.Status = domain.PayoutStatusFailed
+		if payout.FailureCode == "" {
+			payout.FailureCode = domain.FailureUnknownBankNetworkError
+		}
+		s.refund(record)
+	}
+	payout.Updated = time.Now().Format(time.RFC3339)
+
+	s.mu.Lock()
+	record.Payout = payout
+	s.payouts[id] = record
+	s.mu.Unlock()
+
+	if decision.Reverse && payout.Status == domain.PayoutStatusSucceeded {
+		s.scheduleReversal(id, decision.ReverseAfter)
+	}
+	return payout, s.notify(event, payout, record.Session)
+}
+
+// scheduleReversal moves a SUCCEEDED payout to REVERSED after delay and sends
+// the payout.reversed webhook.
+func (s *Service) scheduleReversal(id string, delay time.Duration) {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		record, ok := s.payouts[id]
+		if s.generation != generation || !ok || record.Payout.Status != domain.PayoutStatusSucceeded {
+			s.mu.Unlock()
+			return
+		}
+		record.Payout.Status = domain.PayoutStatusReversed
+		record.Payout.Updated = time.Now().Format(time.RFC3339)
+		s.payouts[id] = record
+		s.mu.Unlock()
+		s.refund(record)
+
+		if err := s.notify(domain.PayoutEventReversed, record.Payout, record.Session); err != nil {
+			log.Printf("[payout.scheduleReversal] callback failed id=%s error=%v", id, err)
+		}
+	})
+}
+
+func (s *Service) notify(event string, payout domain.Payout, session string) error {
+	return s.cb.Deliver(callback.PayoutEvent(domain.PayoutWebhook{
+		Event:      event,
+		BusinessID: payout.BusinessID,
+		Created:    payout.Updated,
+		Data:       payout,
+	}, session))
+}
+
+// Get returns the payout if it belongs to businessID, or to the mock's own
+// user when businessID is empty.
+func (s *Service) Get(businessID, id string) (domain.Payout, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.payouts[id]
+	if !ok || record.Payout.BusinessID != s.businessID(businessID) {
+		return domain.Payout{}, false
+	}
+	return record.Payout, true
+}
+
+func (s *Service) ListByReference(businessID, referenceID string) []domain.Payout {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	businessID = s.businessID(businessID)
+	payouts := make([]domain.Payout, 0)
+	for _, record := range s.payouts {
+		if record.Payout.BusinessID == businessID && record.Payout.ReferenceID == referenceID {
+			payouts = append(payouts, record.Payout)
+		}
+	}
+	sort.Slice(payouts, func(i, j int) bool {
+		if payouts[i].Created != payouts[j].Created {
+			return payouts[i].Created < payouts[j].Created
+		}
+		return payouts[i].ID < payouts[j].ID
+	})
+	return payouts
+}
+
+func (s *Service) Cancel(businessID, id string) (domain.Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.payouts[id]
+	if !ok || record.Payout.BusinessID != s.businessID(businessID) {
+		return domain.Payout{}, ErrNotFound
+	}
+	if record.Payout.Status != domain.PayoutStatusAccepted {
+		return record.Payout, ErrNotCancellable
+	}
+	record.Payout.Status = domain.PayoutStatusCancelled
+	record.Payout.Updated = time.Now().Format(time.RFC3339)
+	s.payouts[id] = record
+	s.refund(record)
+	return record.Payout, nil
+}
+
+func (s *Service) refund(record Record) {
+	if s.ledger != nil && record.Funded {
+		s.ledger.Refund(record.Payout.BusinessID, record.Request.Amount)
+	}
+}
+
+func (s *Service) businessID(forUserID string) string {
+	if forUserID != "" {
+		return forUserID
+	}
+	return s.userID
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.payouts = make(map[string]Record)
+	s.idempotency = make(map[idempotencyKey]string)
+	s.generation++
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payouts := make(map[string]Record, len(s.payouts))
+	for id, record := range s.payouts {
+		payouts[id] = record
+	}
+	return payouts
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var payouts map[string]Record
+	if err := json.Unmarshal(data, &payouts); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.payouts = make(map[string]Record, len(payouts))
+	s.idempotency = make(map[idempotencyKey]string, len(payouts))
+	for id, record := range payouts {
+		s.payouts[id] = record
+		s.idempotency[idempotencyKey{BusinessID: record.Payout.BusinessID, Key: record.Key}] = id
+	}
+	s.generation++
+	return nil
+}
+
+func sameRequest(a, b domain.PayoutRequest) bool {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+	return bytes.Equal(left, right)
+}
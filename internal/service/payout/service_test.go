@@ -0,0 +1,133 @@
+package payout
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func payoutRequest(key string, amount int) domain.PayoutRequest {
+	return domain.PayoutRequest{
+		ReferenceID:       "ref-1",
+		ChannelCode:       "ID_BCA",
+		ChannelProperties: domain.PayoutChannelProperties{AccountHolderName: "Budi", AccountNumber: "acct-1"},
+		Amount:            amount,
+		Currency:          "IDR",
+		IdempotencyKey:    key,
+	}
+}
+
+func TestPayoutsSpendFromCash(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{Default: map[string]int{balance.AccountCash: 50000}})
+	service := NewService(scenario.NewEngine(&scenario.Config{}), &callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+
+	unfunded, _ := service.Create(payoutRequest("key-1", 90000))
+	if stored, _ := service.Get("", unfunded.ID); stored.Status != domain.PayoutStatusFailed || stored.FailureCode != domain.FailureInsufficientBalance {
+		t.Fatalf("expected the unfunded payout FAILED with INSUFFICIENT_BALANCE, got %+v", stored)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 50000 {
+		t.Fatalf("expected the balance untouched by the unfunded payout, got %d", got)
+	}
+
+	funded, _ := service.Create(payoutRequest("key-2", 30000))
+	if stored, _ := service.Get("", funded.ID); stored.Status != domain.PayoutStatusSucceeded {
+		t.Fatalf("expected the funded payout SUCCEEDED, got %s", stored.Status)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 20000 {
+		t.Fatalf("expected the payout debited from CASH, got %d", got)
+	}
+}
+
+func TestCancelRefundsAcceptedPayouts(t *testing.T) {
+	ledger := balance.NewLedger(balance.Config{Default: map[string]int{balance.AccountCash: 50000}})
+	sender := &callbacktest.Recorder{}
+	service := NewService(scenario.NewEngine(&scenario.Config{}), sender, "user_mock").WithLedger(ledger).WithProcessingDelay(50 * time.Millisecond)
+	payout, _ := service.Create(payoutRequest("key-1", 30000))
+
+	if cancelled, err := service.Cancel("", payout.ID); err != nil || cancelled.Status != domain.PayoutStatusCancelled {
+		t.Fatalf("expected the payout CANCELLED, got %+v %v", cancelled, err)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 50000 {
+		t.Fatalf("expected the cancelled payout refunded to CASH, got %d", got)
+	}
+	if _, err := service.Cancel("", payout.ID); !errors.Is(err, ErrNotCancellable) {
+		t.Fatalf("expected ErrNotCancellable cancelling twice, got %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if len(sender.Events()) != 0 {
+		t.Fatalf("expected no webhook for a cancelled payout, got %d", len(sender.Events()))
+	}
+}
+
+func TestPayoutsAreScopedToTheBusiness(t *testing.T) {
+	service := NewService(scenario.NewEngine(&scenario.Config{}), &callbacktest.Recorder{}, "user_mock").WithProcessingDelay(50 * time.Millisecond)
+	req := payoutRequest("key-1", 30000)
+	req.ForUserID = "sub-account-1"
+	payout, _ := service.Create(req)
+
+	if _, ok := service.Get("", payout.ID); ok {
+		t.Fatal("expected another business not to get the payout")
+	}
+	if payouts := service.ListByReference("", "ref-1"); len(payouts) != 0 {
+		t.Fatalf("expected no payouts listed for another business, got %d", len(payouts))
+	}
+	if _, err := service.Cancel("", payout.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound cancelling another business's payout, got %v", err)
+	}
+	if payouts := service.ListByReference("sub-account-1", "ref-1"); len(payouts) != 1 || payouts[0].ID != payout.ID {
+		t.Fatalf("expected the business's own payout listed, got %+v", payouts)
+	}
+	if cancelled, err := service.Cancel("sub-account-1", payout.ID); err != nil || cancelled.Status != domain.PayoutStatusCancelled {
+		t.Fatalf("expected the business to cancel its own payout, got %+v %v", cancelled, err)
+	}
+}
+
+func TestRestoreKeepsIdempotencyKeysPerBusiness(t *testing.T) {
+	service := NewService(scenario.NewEngine(&scenario.Config{}), &callbacktest.Recorder{}, "user_mock")
+	original, _ := service.Create(payoutRequest("key-1", 30000))
+	data, _ := json.Marshal(service.Snapshot())
+
+	restored := NewService(scenario.NewEngine(&scenario.Config{}), &callbacktest.Recorder{}, "user_mock")
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+	if replay, err := restored.Create(payoutRequest("key-1", 30000)); err != nil || replay.ID != original.ID {
+		t.Fatalf("expected the restored key to replay the payout, got %s %v", replay.ID, err)
+	}
+	if _, err := restored.Create(payoutRequest("key-1", 1000)); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("expected ErrIdempotencyConflict for a different body, got %v", err)
+	}
+	other := payoutRequest("key-1", 1000)
+	other.ForUserID = "sub-account-1"
+	if payout, err := restored.Create(other); err != nil || payout.ID == original.ID || payout.BusinessID != "sub-account-1" {
+		t.Fatalf("expected another business's key to create its own payout, got %+v %v", payout, err)
+	}
+}
+
+func TestRestoreDropsScheduledReversals(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{Accounts: []scenario.AccountScenario{
+		{AccountNumber: "acct-1", Disbursements: []scenario.Rule{{Outcome: scenario.OutcomeSuccessThenReverse, ReverseAfterMS: 30}}},
+	}})
+	sender := &callbacktest.Recorder{}
+	service := NewService(engine, sender, "user_mock")
+	payout, _ := service.Create(payoutRequest("key-1", 30000))
+	data, _ := json.Marshal(service.Snapshot())
+
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if stored, _ := service.Get("", payout.ID); stored.Status != domain.PayoutStatusSucceeded {
+		t.Fatalf("expected the restored payout to stay SUCCEEDED, got %s", stored.Status)
+	}
+	if events := sender.Events(); len(events) != 1 {
+		t.Fatalf("expected only the payout.succeeded webhook, got %d", len(events))
+	}
+}
@@ -0,0 +1,243 @@
+package qrcode
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound       = errors.New("qr code not found")
+	ErrInactive       = errors.New("qr code is not ACTIVE")
+	ErrAmountRequired = errors.New("amount is required to pay a STATIC qr code")
+)
+
+type Service struct {
+	engine       *scenario.Engine
+	cb           callback.Sender
+	userID       string
+	merchantName string
+	ledger       *balance.Ledger
+	mu           sync.Mutex
+	codes        map[string]Record
+}
+
+type Record struct {
+	Request  domain.QRCodeRequest `json:"request"`
+	QRCode   domain.QRCode        `json:"qr_code"`
+	Payments []domain.QRPayment   `json:"payments,omitempty"`
+	Session  string               `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, merchantName: "Xendit Mock", codes: make(map[string]Record)}
+}
+
+func (s *Service) WithMerchantName(name string) *Service {
+	s.merchantName = name
+	return s
+}
+
+// WithLedger credits QR payments to the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create stores an ACTIVE QR code that expires at expires_at, then applies
+// the scenario's QR rule: pay or expire it after the rule's delay.
+func (s *Service) Create(req domain.QRCodeRequest) (domain.QRCode, error) {
+	businessID := req.ForUserID
+	if businessID == "" {
+		businessID = s.userID
+	}
+	qr := domain.BuildQRCode(req, businessID, s.merchantName)
+
+	s.mu.Lock()
+	s.codes[qr.ID] = Record{Request: req, QRCode: qr, Session: req.Session}
+	s.mu.Unlock()
+
+	s.scheduleExpiry(qr)
+
+	rule, ok := s.engine.QRCodeRule(req.ReferenceID)
+	if !ok {
+		return qr, nil
+	}
+	delay := time.Duration(rule.DelayMS) * time.Millisecond
+	switch rule.Outcome {
+	case scenario.QRCodePay:
+		return qr, s.after(delay, qr.ID, func() error {
+			_, err := s.Pay(qr.ID, rule.PaidAmount)
+			return err
+		})
+	case scenario.QRCodeExpire:
+		return qr, s.after(delay, qr.ID, func() error {
+			_, err := s.Expire(qr.ID)
+			return err
+		})
+	}
+	return qr, nil
+}
+
+// Pay simulates a payer scanning an ACTIVE QR code and sends the qr.payment
+// callback. Dynamic codes are paid in full when amount is not set and become
+// INACTIVE; static codes stay ACTIVE and need an amount.
+func (s *Service) Pay(id string, amount int) (domain.QRPayment, error) {
+	s.mu.Lock()
+	record, ok := s.codes[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.QRPayment{}, ErrNotFound
+	}
+	if record.QRCode.Status != domain.QRCodeActive {
+		s.mu.Unlock()
+		return domain.QRPayment{}, ErrInactive
+	}
+	if amount <= 0 {
+		amount = record.QRCode.Amount
+	}
+	if amount <= 0 {
+		s.mu.Unlock()
+		return domain.QRPayment{}, ErrAmountRequired
+	}
+	payment := domain.BuildQRPayment(record.QRCode, amount, len(record.Payments))
+	record.Payments = append(record.Payments, payment)
+	if record.QRCode.Type == domain.QRCodeDynamic {
+		record.QRCode.Status = domain.QRCodeInactive
+		record.QRCode.Updated = payment.Created
+	}
+	s.codes[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		s.ledger.TopUp(payment.BusinessID, balance.AccountCash, amount)
+	}
+	webhook := domain.QRPaymentWebhook{
+		Event:      domain.QRCodeEventPayment,
+		APIVersion: "v2",
+		BusinessID: payment.BusinessID,
+		Created:    payment.Created,
+		Data:       payment,
+	}
+	return payment, s.cb.Deliver(callback.QRPaymentEvent(webhook, record.Session))
+}
+
+// Expire deactivates an ACTIVE QR code. Xendit sends no callback for it.
+func (s *Service) Expire(id string) (domain.QRCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.codes[id]
+	if !ok {
+		return domain.QRCode{}, ErrNotFound
+	}
+	if record.QRCode.Status != domain.QRCodeActive {
+		return record.QRCode, ErrInactive
+	}
+	now := time.Now().Format(time.RFC3339)
+	record.QRCode.Status = domain.QRCodeInactive
+	record.QRCode.ExpiresAt = now
+	record.QRCode.Updated = now
+	s.codes[id] = record
+	return record.QRCode, nil
+}
+
+func (s *Service) Get(id string) (domain.QRCode, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.codes[id]
+	return record.QRCode, ok
+}
+
+// Payments returns the payments made to a QR code, oldest first.
+func (s *Service) Payments(id string) ([]domain.QRPayment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.codes[id]
+	return append([]domain.QRPayment{}, record.Payments...), ok
+}
+
+// scheduleExpiry deactivates the QR code at expires_at unless that has
+// changed by then, as it does when the code is expired early or a snapshot is
+// restored.
+func (s *Service) scheduleExpiry(qr domain.QRCode) {
+	expiry, err := time.Parse(time.RFC3339, qr.ExpiresAt)
+	if err != nil {
+		return
+	}
+	time.AfterFunc(time.Until(expiry), func() {
+		s.mu.Lock()
+		current := s.codes[qr.ID].QRCode
+		s.mu.Unlock()
+		if current.ExpiresAt != qr.ExpiresAt {
+			return
+		}
+		if _, err := s.Expire(qr.ID); err != nil && !errors.Is(err, ErrInactive) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[qrcode.scheduleExpiry] expiry failed id=%s error=%v", qr.ID, err)
+		}
+	})
+}
+
+// after runs action now when delay is not positive, otherwise in the
+// background, where a QR code that is no longer active is not an error.
+func (s *Service) after(delay time.Duration, id string, action func() error) error {
+	if delay <= 0 {
+		return action()
+	}
+	time.AfterFunc(delay, func() {
+		if err := action(); err != nil && !errors.Is(err, ErrInactive) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[qrcode.after] scheduled action failed id=%s error=%v", id, err)
+		}
+	})
+	return nil
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.codes = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make(map[string]Record, len(s.codes))
+	for id, record := range s.codes {
+		codes[id] = record
+	}
+	return codes
+}
+
+// Restore replaces the QR codes with the snapshot's and schedules the expiry
+// of the ACTIVE ones again.
+func (s *Service) Restore(data json.RawMessage) error {
+	var codes map[string]Record
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.codes = make(map[string]Record, len(codes))
+	for id, record := range codes {
+		s.codes[id] = record
+	}
+	s.mu.Unlock()
+
+	for _, record := range codes {
+		if record.QRCode.Status == domain.QRCodeActive {
+			s.scheduleExpiry(record.QRCode)
+		}
+	}
+	return nil
+}
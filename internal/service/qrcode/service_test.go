@@ -0,0 +1,100 @@
+package qrcode
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestDynamicCodesArePaidOnce(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{QRCodes: []scenario.QRCodeRule{{ReferenceID: "order-1", Outcome: scenario.QRCodePay}}})
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(engine, sender, "user_mock").WithLedger(ledger)
+	qr, err := service.Create(domain.QRCodeRequest{ReferenceID: "order-1", Type: domain.QRCodeDynamic, Currency: "IDR", Amount: 15000})
+	if err != nil {
+		t.Fatalf("expected the QR code to be created and paid, got %v", err)
+	}
+
+	if stored, _ := service.Get(qr.ID); stored.Status != domain.QRCodeInactive {
+		t.Fatalf("expected the paid dynamic code INACTIVE, got %s", stored.Status)
+	}
+	if _, err := service.Pay(qr.ID, 0); !errors.Is(err, ErrInactive) {
+		t.Fatalf("expected ErrInactive paying twice, got %v", err)
+	}
+	if len(sender.Events()) != 1 {
+		t.Fatalf("expected one qr.payment callback, got %d", len(sender.Events()))
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 15000 {
+		t.Fatalf("expected the full amount credited to CASH, got %d", got)
+	}
+}
+
+func TestStaticCodesTakeManyPaymentsUntilExpired(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	qr, _ := service.Create(domain.QRCodeRequest{ReferenceID: "till-1", Type: domain.QRCodeStatic, Currency: "IDR"})
+
+	if _, err := service.Pay(qr.ID, 0); !errors.Is(err, ErrAmountRequired) {
+		t.Fatalf("expected ErrAmountRequired without an amount, got %v", err)
+	}
+	for _, amount := range []int{5000, 7000} {
+		if _, err := service.Pay(qr.ID, amount); err != nil {
+			t.Fatalf("expected the payment of %d to be accepted, got %v", amount, err)
+		}
+	}
+	if payments, _ := service.Payments(qr.ID); len(payments) != 2 || payments[0].Amount != 5000 {
+		t.Fatalf("expected both payments oldest first, got %+v", payments)
+	}
+
+	if expired, err := service.Expire(qr.ID); err != nil || expired.Status != domain.QRCodeInactive {
+		t.Fatalf("expected the code INACTIVE, got %+v %v", expired, err)
+	}
+	if _, err := service.Pay(qr.ID, 5000); !errors.Is(err, ErrInactive) {
+		t.Fatalf("expected ErrInactive paying an expired code, got %v", err)
+	}
+}
+
+func TestRestoreSchedulesActiveExpiry(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	expiry := time.Now().Add(-time.Second).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{
+		"qr_active": {QRCode: domain.QRCode{ID: "qr_active", Type: domain.QRCodeDynamic, Status: domain.QRCodeActive, ExpiresAt: expiry}},
+	})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		qr, _ := service.Get("qr_active")
+		if qr.Status == domain.QRCodeInactive {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the restored ACTIVE code to expire, got %s", qr.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRestoredExpiryIgnoresTheOldTimer(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	qr, _ := service.Create(domain.QRCodeRequest{ReferenceID: "order-1", Type: domain.QRCodeDynamic, Currency: "IDR", Amount: 15000, ExpiresAt: time.Now().Add(time.Second).Format(time.RFC3339)})
+	stored, _ := service.Get(qr.ID)
+	stored.ExpiresAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{qr.ID: {QRCode: stored}})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if got, _ := service.Get(qr.ID); got.Status != domain.QRCodeActive {
+		t.Fatalf("expected the code kept ACTIVE until its restored expires_at, got %s", got.Status)
+	}
+}
@@ -0,0 +1,347 @@
+package recurring
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/clock"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/customer"
+	"xendit-api-mock/internal/service/paymentrequest"
+)
+
+var (
+	ErrNotFound         = errors.New("recurring plan not found")
+	ErrCycleNotFound    = errors.New("recurring cycle not found")
+	ErrInactive         = errors.New("recurring plan is not ACTIVE")
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrMethodNotFound   = errors.New("payment method not found")
+	ErrMethodNotActive  = errors.New("payment method is not ACTIVE")
+)
+
+type Service struct {
+	engine          *scenario.Engine
+	cb              callback.Sender
+	clock           *clock.Clock
+	userID          string
+	ledger          *balance.Ledger
+	customers       *customer.Service
+	paymentRequests *paymentrequest.Service
+	mu              sync.Mutex
+	plans           map[string]PlanRecord
+	cycles          map[string]domain.RecurringCycle
+}
+
+type PlanRecord struct {
+	Request domain.RecurringPlanRequest `json:"request"`
+	Plan    domain.RecurringPlan        `json:"plan"`
+	Session string                      `json:"session,omitempty"`
+}
+
+type State struct {
+	Plans  map[string]PlanRecord            `json:"plans"`
+	Cycles map[string]domain.RecurringCycle `json:"cycles"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, clk *clock.Clock, userID string) *Service {
+	return &Service{
+		engine: engine,
+		cb:     cb,
+		clock:  clk,
+		userID: userID,
+		plans:  make(map[string]PlanRecord),
+		cycles: make(map[string]domain.RecurringCycle),
+	}
+}
+
+// WithLedger credits succeeded cycles to the user's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// WithCustomers makes plans fail unless their customer exists.
+func (s *Service) WithCustomers(customers *customer.Service) *Service {
+	s.customers = customers
+	return s
+}
+
+// WithPaymentRequests makes plans fail unless their payment methods exist
+// and are ACTIVE.
+func (s *Service) WithPaymentRequests(paymentRequests *paymentrequest.Service) *Service {
+	s.paymentRequests = paymentRequests
+	return s
+}
+
+// Create stores an ACTIVE plan and schedules its first cycle at the anchor
+// date. Cycles are attempted when the virtual clock reaches them.
+func (s *Service) Create(req domain.RecurringPlanRequest) (domain.RecurringPlan, error) {
+	businessID := req.ForUserID
+	if businessID == "" {
+		businessID = s.userID
+	}
+	if s.customers != nil && !s.customers.Exists(businessID, req.CustomerID) {
+		return domain.RecurringPlan{}, ErrCustomerNotFound
+	}
+	if s.paymentRequests != nil {
+		for _, pm := range req.PaymentMethods {
+			method, ok := s.paymentRequests.GetMethod(pm.PaymentMethodID)
+			if !ok {
+				return domain.RecurringPlan{}, ErrMethodNotFound
+			}
+			if method.Status != domain.PaymentMethodActive {
+				return domain.RecurringPlan{}, ErrMethodNotActive
+			}
+		}
+	}
+
+	now := s.clock.Now()
+	plan := domain.BuildRecurringPlan(req, businessID, now)
+	anchor, err := time.Parse(time.RFC3339, plan.Schedule.AnchorDate)
+	if err != nil {
+		anchor = now
+	}
+
+	s.mu.Lock()
+	s.plans[plan.ID] = PlanRecord{Request: req, Plan: plan, Session: req.Session}
+	s.mu.Unlock()
+
+	err = s.notifyPlan(plan, domain.RecurringPlanEventActivated, req.Session)
+	return plan, errors.Join(err, s.schedule(plan, 1, anchor, req.Session))
+}
+
+// Deactivate stops an ACTIVE plan and cancels its pending cycles.
+func (s *Service) Deactivate(id string) (domain.RecurringPlan, error) {
+	s.mu.Lock()
+	record, ok := s.plans[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.RecurringPlan{}, ErrNotFound
+	}
+	if record.Plan.Status != domain.RecurringPlanActive {
+		s.mu.Unlock()
+		return record.Plan, ErrInactive
+	}
+	now := s.clock.Now().Format(time.RFC3339)
+	record.Plan.Status = domain.RecurringPlanInactive
+	record.Plan.Updated = now
+	s.plans[id] = record
+	for cycleID, cycle := range s.cycles {
+		if cycle.PlanID == id && (cycle.Status == domain.RecurringCycleScheduled || cycle.Status == domain.RecurringCycleRetrying) {
+			cycle.Status = domain.RecurringCycleCancelled
+			cycle.Updated = now
+			s.cycles[cycleID] = cycle
+		}
+	}
+	s.mu.Unlock()
+
+	return record.Plan, s.notifyPlan(record.Plan, domain.RecurringPlanEventInactivated, record.Session)
+}
+
+// schedule creates cycle number of the plan, due at scheduled, and sends
+// recurring.cycle.created.
+func (s *Service) schedule(plan domain.RecurringPlan, number int, scheduled time.Time, session string) error {
+	cycle := domain.BuildRecurringCycle(plan, number, scheduled, s.clock.Now())
+
+	s.mu.Lock()
+	s.cycles[cycle.ID] = cycle
+	s.mu.Unlock()
+
+	err := s.notifyCycle(cycle, domain.RecurringCycleEventCreated, session)
+	s.at(scheduled, cycle.ID)
+	return err
+}
+
+func (s *Service) at(due time.Time, cycleID string) {
+	s.clock.At(due, func() {
+		if err := s.attempt(cycleID); err != nil {
+			log.Printf("[recurring.attempt] cycle attempt failed id=%s error=%v", cycleID, err)
+		}
+	})
+}
+
+// attempt charges a due cycle as the scenario's rule says. A failed attempt
+// is retried after the plan's retry interval until total_retry retries are
+// used up; the cycle then fails and the plan resumes or stops.
+func (s *Service) attempt(cycleID string) error {
+	s.mu.Lock()
+	cycle, ok := s.cycles[cycleID]
+	if !ok || (cycle.Status != domain.RecurringCycleScheduled && cycle.Status != domain.RecurringCycleRetrying) {
+		s.mu.Unlock()
+		return nil
+	}
+	record := s.plans[cycle.PlanID]
+	plan := record.Plan
+	if plan.Status != domain.RecurringPlanActive {
+		s.mu.Unlock()
+		return nil
+	}
+
+	now := s.clock.Now()
+	cycle.AttemptCount++
+	attempt := domain.RecurringAttempt{AttemptNumber: cycle.AttemptCount, Type: "PAYMENT", Status: domain.RecurringCycleSucceeded, Created: now.Format(time.RFC3339)}
+	rule, ok := s.engine.RecurringRule(plan.ReferenceID)
+	if ok && (rule.Outcome == scenario.RecurringFail || cycle.AttemptCount <= rule.FailedAttempts) {
+		attempt.Status = domain.RecurringCycleFailed
+		attempt.FailureCode = rule.FailureCode
+		if attempt.FailureCode == "" {
+			attempt.FailureCode = domain.RecurringFailureDeclined
+		}
+	}
+
+	event := domain.RecurringCycleEventSucceeded
+	var retryAt time.Time
+	switch {
+	case attempt.Status == domain.RecurringCycleSucceeded:
+		cycle.Status = domain.RecurringCycleSucceeded
+	case cycle.AttemptCount <= plan.Schedule.TotalRetry:
+		retryAt = plan.Schedule.NextRetry(now)
+		attempt.NextRetryTimestamp = retryAt.Format(time.RFC3339)
+		cycle.Status = domain.RecurringCycleRetrying
+		event = domain.RecurringCycleEventRetrying
+	default:
+		cycle.Status = domain.RecurringCycleFailed
+		event = domain.RecurringCycleEventFailed
+	}
+	cycle.AttemptDetails = append(cycle.AttemptDetails, attempt)
+	cycle.Updated = now.Format(time.RFC3339)
+	s.cycles[cycleID] = cycle
+	s.mu.Unlock()
+
+	if cycle.Status == domain.RecurringCycleSucceeded && s.ledger != nil {
+		s.ledger.TopUp(plan.BusinessID, balance.AccountCash, cycle.Amount)
+	}
+	err := s.notifyCycle(cycle, event, record.Session)
+	switch {
+	case cycle.Status == domain.RecurringCycleRetrying:
+		s.at(retryAt, cycleID)
+		return err
+	case cycle.Status == domain.RecurringCycleFailed && plan.FailedCycleAction == domain.RecurringFailedCycleStop,
+		plan.Schedule.TotalRecurrence > 0 && cycle.CycleNumber >= plan.Schedule.TotalRecurrence:
+		_, stopErr := s.Deactivate(plan.ID)
+		return errors.Join(err, stopErr)
+	}
+	scheduled, parseErr := time.Parse(time.RFC3339, cycle.ScheduledTimestamp)
+	if parseErr != nil {
+		scheduled = now
+	}
+	return errors.Join(err, s.schedule(plan, cycle.CycleNumber+1, plan.Schedule.Next(scheduled), record.Session))
+}
+
+func (s *Service) notifyPlan(plan domain.RecurringPlan, event, session string) error {
+	webhook := domain.RecurringWebhook{Event: event, BusinessID: plan.BusinessID, Created: s.clock.Now().Format(time.RFC3339), Data: plan}
+	return s.cb.Deliver(callback.RecurringEvent(webhook, plan.ID, plan.ReferenceID, plan.Status, session))
+}
+
+func (s *Service) notifyCycle(cycle domain.RecurringCycle, event, session string) error {
+	webhook := domain.RecurringWebhook{Event: event, BusinessID: cycle.BusinessID, Created: s.clock.Now().Format(time.RFC3339), Data: cycle}
+	return s.cb.Deliver(callback.RecurringEvent(webhook, cycle.ID, cycle.ReferenceID, cycle.Status, session))
+}
+
+func (s *Service) Get(id string) (domain.RecurringPlan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.plans[id]
+	return record.Plan, ok
+}
+
+// Cycles returns the plan's cycles in order.
+func (s *Service) Cycles(planID string) ([]domain.RecurringCycle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.plans[planID]; !ok {
+		return nil, ErrNotFound
+	}
+	cycles := make([]domain.RecurringCycle, 0)
+	for _, cycle := range s.cycles {
+		if cycle.PlanID == planID {
+			cycles = append(cycles, cycle)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].CycleNumber < cycles[j].CycleNumber })
+	return cycles, nil
+}
+
+func (s *Service) Cycle(planID, cycleID string) (domain.RecurringCycle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.plans[planID]; !ok {
+		return domain.RecurringCycle{}, ErrNotFound
+	}
+	cycle, ok := s.cycles[cycleID]
+	if !ok || cycle.PlanID != planID {
+		return domain.RecurringCycle{}, ErrCycleNotFound
+	}
+	return cycle, nil
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.plans = make(map[string]PlanRecord)
+	s.cycles = make(map[string]domain.RecurringCycle)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		Plans:  make(map[string]PlanRecord, len(s.plans)),
+		Cycles: make(map[string]domain.RecurringCycle, len(s.cycles)),
+	}
+	for id, record := range s.plans {
+		state.Plans[id] = record
+	}
+	for id, cycle := range s.cycles {
+		state.Cycles[id] = cycle
+	}
+	return state
+}
+
+// Restore loads the plans and cycles and schedules the pending attempts on
+// the clock again, so the clock must be restored first.
+func (s *Service) Restore(data json.RawMessage) error {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.plans = make(map[string]PlanRecord, len(state.Plans))
+	for id, record := range state.Plans {
+		s.plans[id] = record
+	}
+	s.cycles = make(map[string]domain.RecurringCycle, len(state.Cycles))
+	due := make(map[string]string)
+	for id, cycle := range state.Cycles {
+		s.cycles[id] = cycle
+		switch {
+		case cycle.Status == domain.RecurringCycleScheduled:
+			due[id] = cycle.ScheduledTimestamp
+		case cycle.Status == domain.RecurringCycleRetrying && len(cycle.AttemptDetails) > 0:
+			due[id] = cycle.AttemptDetails[len(cycle.AttemptDetails)-1].NextRetryTimestamp
+		}
+	}
+	s.mu.Unlock()
+
+	for id, timestamp := range due {
+		at, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return err
+		}
+		s.at(at, id)
+	}
+	return nil
+}
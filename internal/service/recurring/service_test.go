@@ -0,0 +1,124 @@
+package recurring
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/clock"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+// planRequest is a daily plan whose first cycle is due an hour from now, so
+// only Advance runs it.
+func planRequest(clk *clock.Clock, referenceID string) domain.RecurringPlanRequest {
+	return domain.RecurringPlanRequest{
+		ReferenceID: referenceID,
+		CustomerID:  "cust_1",
+		Currency:    "IDR",
+		Amount:      25000,
+		Schedule: domain.RecurringSchedule{
+			Interval:        domain.RecurringIntervalDay,
+			TotalRecurrence: 2,
+			AnchorDate:      clk.Now().Add(time.Hour).Format(time.RFC3339),
+			TotalRetry:      1,
+		},
+	}
+}
+
+func cycleStatuses(t *testing.T, service *Service, planID string) []string {
+	t.Helper()
+	cycles, err := service.Cycles(planID)
+	if err != nil {
+		t.Fatalf("expected the plan's cycles, got %v", err)
+	}
+	statuses := make([]string, 0, len(cycles))
+	for _, cycle := range cycles {
+		statuses = append(statuses, cycle.Status)
+	}
+	return statuses
+}
+
+func TestSucceededCyclesScheduleTheNextUntilTheLast(t *testing.T) {
+	clk := clock.New()
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, clk, "user_mock").WithLedger(ledger)
+	plan, err := service.Create(planRequest(clk, "plan-1"))
+	if err != nil {
+		t.Fatalf("expected the plan to be created, got %v", err)
+	}
+
+	clk.Advance(time.Hour)
+	if got := cycleStatuses(t, service, plan.ID); len(got) != 2 || got[0] != domain.RecurringCycleSucceeded || got[1] != domain.RecurringCycleScheduled {
+		t.Fatalf("expected the first cycle SUCCEEDED and the second SCHEDULED, got %v", got)
+	}
+	clk.Advance(24 * time.Hour)
+	if got := cycleStatuses(t, service, plan.ID); len(got) != 2 || got[1] != domain.RecurringCycleSucceeded {
+		t.Fatalf("expected no cycle after the last recurrence, got %v", got)
+	}
+	if stored, _ := service.Get(plan.ID); stored.Status != domain.RecurringPlanInactive {
+		t.Fatalf("expected the plan INACTIVE after its last cycle, got %s", stored.Status)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 50000 {
+		t.Fatalf("expected both cycles credited to CASH, got %d", got)
+	}
+}
+
+func TestFailedCycleRetriesThenStopsThePlan(t *testing.T) {
+	clk := clock.New()
+	engine := scenario.NewEngine(&scenario.Config{Recurring: []scenario.RecurringRule{{ReferenceID: "plan-fail", Outcome: scenario.RecurringFail}}})
+	service := NewService(engine, &callbacktest.Recorder{}, clk, "user_mock")
+	req := planRequest(clk, "plan-fail")
+	req.FailedCycleAction = domain.RecurringFailedCycleStop
+	plan, _ := service.Create(req)
+
+	clk.Advance(time.Hour)
+	if got := cycleStatuses(t, service, plan.ID); len(got) != 1 || got[0] != domain.RecurringCycleRetrying {
+		t.Fatalf("expected the cycle RETRYING after its first attempt, got %v", got)
+	}
+	clk.Advance(24 * time.Hour)
+	if got := cycleStatuses(t, service, plan.ID); len(got) != 1 || got[0] != domain.RecurringCycleFailed {
+		t.Fatalf("expected the cycle FAILED once its retry is used up, got %v", got)
+	}
+	if stored, _ := service.Get(plan.ID); stored.Status != domain.RecurringPlanInactive {
+		t.Fatalf("expected a STOP plan INACTIVE after a failed cycle, got %s", stored.Status)
+	}
+}
+
+func TestRestoreSchedulesPendingCycles(t *testing.T) {
+	clk := clock.New()
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, clk, "user_mock")
+	plan, _ := service.Create(planRequest(clk, "plan-1"))
+	data, _ := json.Marshal(service.Snapshot())
+
+	restoredClock := clock.New()
+	restored := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, restoredClock, "user_mock")
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+	restoredClock.Advance(time.Hour)
+	if got := cycleStatuses(t, restored, plan.ID); len(got) != 2 || got[0] != domain.RecurringCycleSucceeded {
+		t.Fatalf("expected the restored cycle attempted on the clock, got %v", got)
+	}
+}
+
+func TestDeactivateCancelsPendingCycles(t *testing.T) {
+	clk := clock.New()
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, clk, "user_mock")
+	plan, _ := service.Create(planRequest(clk, "plan-1"))
+
+	if _, err := service.Deactivate(plan.ID); err != nil {
+		t.Fatalf("expected the plan to be deactivated, got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if got := cycleStatuses(t, service, plan.ID); len(got) != 1 || got[0] != domain.RecurringCycleCancelled {
+		t.Fatalf("expected the pending cycle CANCELLED and not attempted, got %v", got)
+	}
+	if _, err := service.Deactivate(plan.ID); !errors.Is(err, ErrInactive) {
+		t.Fatalf("expected ErrInactive deactivating twice, got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package refund
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/ewallet"
+	"xendit-api-mock/internal/service/invoice"
+	"xendit-api-mock/internal/service/paymentrequest"
+)
+
+var (
+	ErrNotFound        = errors.New("refund not found")
+	ErrPaymentNotFound = errors.New("payment to refund not found")
+	ErrIneligible      = errors.New("payment has not been paid")
+	ErrAmountExceeded  = errors.New("refund amount exceeds the amount left to refund")
+	ErrNotPending      = errors.New("refund is not PENDING")
+)
+
+type Service struct {
+	engine          *scenario.Engine
+	cb              callback.Sender
+	userID          string
+	invoices        *invoice.Service
+	paymentRequests *paymentrequest.Service
+	ewallets        *ewallet.Service
+	ledger          *balance.Ledger
+	mu              sync.Mutex
+	refunds         map[string]Record
+}
+
+type Record struct {
+	Request domain.RefundRequest `json:"request"`
+	Refund  domain.Refund        `json:"refund"`
+	Session string               `json:"session,omitempty"`
+}
+
+type Filter struct {
+	PaymentRequestID string
+	InvoiceID        string
+	EWalletChargeID  string
+	Limit            int
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, refunds: make(map[string]Record)}
+}
+
+// WithInvoices lets paid invoices be refunded.
+func (s *Service) WithInvoices(invoices *invoice.Service) *Service {
+	s.invoices = invoices
+	return s
+}
+
+// WithPaymentRequests lets succeeded payment requests be refunded.
+func (s *Service) WithPaymentRequests(paymentRequests *paymentrequest.Service) *Service {
+	s.paymentRequests = paymentRequests
+	return s
+}
+
+// WithEWallets lets succeeded e-wallet charges be refunded. Succeeded
+// refunds are recorded against the charge.
+func (s *Service) WithEWallets(ewallets *ewallet.Service) *Service {
+	s.ewallets = ewallets
+	return s
+}
+
+// WithLedger debits succeeded refunds from the user's balance: HOLDING for
+// invoices that are not settled yet, CASH otherwise.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create stores a PENDING refund of the payment, the whole amount left when
+// req.Amount is not set. Pending and succeeded refunds of a payment may not
+// add up to more than was captured. The scenario's refund rule then settles
+// it; without one it succeeds straight away. A payment of another business is
+// not found.
+func (s *Service) Create(req domain.RefundRequest) (domain.Refund, error) {
+	payment, err := s.payment(req)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	if payment.BusinessID != s.businessID(req.ForUserID) {
+		return domain.Refund{}, ErrPaymentNotFound
+	}
+
+	s.mu.Lock()
+	remaining := payment.Amount
+	for _, record := range s.refunds {
+		if record.Refund.PaymentID == payment.PaymentID && record.Refund.Status != domain.RefundStatusFailed {
+			remaining -= record.Refund.Amount
+		}
+	}
+	amount := req.Amount
+	if amount <= 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		s.mu.Unlock()
+		return domain.Refund{}, ErrAmountExceeded
+	}
+	refund := domain.BuildRefund(req, payment, amount)
+	s.refunds[refund.ID] = Record{Request: req, Refund: refund, Session: req.Session}
+	s.mu.Unlock()
+
+	status, failureCode, delay := domain.RefundStatusSucceeded, "", time.Duration(0)
+	if rule, ok := s.engine.RefundRule(req.ReferenceID); ok {
+		delay = time.Duration(rule.DelayMS) * time.Millisecond
+		if rule.Outcome == scenario.RefundFail {
+			status, failureCode = domain.RefundStatusFailed, rule.FailureCode
+			if failureCode == "" {
+				failureCode = domain.RefundFailureUnknown
+			}
+		}
+	}
+	if delay <= 0 {
+		return refund, s.settle(refund.ID, status, failureCode)
+	}
+	time.AfterFunc(delay, func() {
+		if err := s.settle(refund.ID, status, failureCode); err != nil && !errors.Is(err, ErrNotPending) {
+			log.Printf("[refund.Create] scheduled settlement failed id=%s error=%v", refund.ID, err)
+		}
+	})
+	return refund, nil
+}
+
+// settle moves a PENDING refund to status and sends the refund.succeeded or
+// refund.failed webhook.
+func (s *Service) settle(id, status, failureCode string) error {
+	s.mu.Lock()
+	record, ok := s.refunds[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	if record.Refund.Status != domain.RefundStatusPending {
+		s.mu.Unlock()
+		return ErrNotPending
+	}
+	account := balance.AccountCash
+	if status == domain.RefundStatusSucceeded && record.Refund.InvoiceID != "" && s.invoices != nil {
+		if held, err := s.invoices.ApplyRefund(record.Refund.InvoiceID, record.Refund.Amount); err == nil {
+			account = held
+		}
+	}
+	if status == domain.RefundStatusSucceeded && record.Refund.EWalletChargeID != "" && s.ewallets != nil {
+		if err := s.ewallets.ApplyRefund(record.Refund.EWalletChargeID, record.Refund.Amount); err != nil {
+			log.Printf("[refund.settle] charge no longer refundable id=%s charge_id=%s error=%v", id, record.Refund.EWalletChargeID, err)
+			status, failureCode = domain.RefundStatusFailed, domain.RefundFailureUnknown
+		}
+	}
+	record.Refund.Status = status
+	record.Refund.FailureCode = failureCode
+	record.Refund.Updated = time.Now().Format(time.RFC3339)
+	s.refunds[id] = record
+	s.mu.Unlock()
+
+	event := domain.RefundEventSucceeded
+	if status == domain.RefundStatusFailed {
+		event = domain.RefundEventFailed
+	} else if s.ledger != nil {
+		s.ledger.TopUp(record.Refund.BusinessID, account, -record.Refund.Amount)
+	}
+	webhook := domain.RefundWebhook{Event: event, BusinessID: record.Refund.BusinessID, Created: record.Refund.Updated, Data: record.Refund}
+	return s.cb.Deliver(callback.RefundEvent(webhook, record.Session))
+}
+
+// payment finds the paid invoice, succeeded payment request or succeeded
+// e-wallet charge a refund is for.
+func (s *Service) payment(req domain.RefundRequest) (domain.RefundablePayment, error) {
+	switch {
+	case req.InvoiceID != "" && s.invoices != nil:
+		inv, ok := s.invoices.Get(req.InvoiceID)
+		if !ok {
+			return domain.RefundablePayment{}, ErrPaymentNotFound
+		}
+		if inv.Status != domain.InvoiceStatusPaid && inv.Status != domain.InvoiceStatusSettled {
+			return domain.RefundablePayment{}, ErrIneligible
+		}
+		return domain.RefundablePayment{
+			PaymentID:   inv.ID,
+			BusinessID:  inv.UserID,
+			Amount:      inv.PaidAmount,
+			Currency:    inv.Currency,
+			Country:     "ID",
+			ChannelCode: inv.PaymentChannel,
+		}, nil
+	case req.PaymentRequestID != "" && s.paymentRequests != nil:
+		pr, ok := s.paymentRequests.Get(req.PaymentRequestID)
+		if !ok {
+			return domain.RefundablePayment{}, ErrPaymentNotFound
+		}
+		if pr.Status != domain.PaymentRequestSucceeded {
+			return domain.RefundablePayment{}, ErrIneligible
+		}
+		return domain.RefundablePayment{
+			PaymentID:   domain.BuildPayment(pr).ID,
+			BusinessID:  pr.BusinessID,
+			Amount:      pr.Amount,
+			Currency:    pr.Currency,
+			Country:     pr.Country,
+			ChannelCode: pr.PaymentMethod.ChannelCode(),
+		}, nil
+	case req.EWalletChargeID != "" && s.ewallets != nil:
+		charge, ok := s.ewallets.Get(req.EWalletChargeID)
+		if !ok {
+			return domain.RefundablePayment{}, ErrPaymentNotFound
+		}
+		// A fully refunded charge is still eligible; the amount check
+		// turns it down.
+		if charge.Status != domain.EWalletStatusSucceeded && charge.Status != domain.EWalletStatusRefunded {
+			return domain.RefundablePayment{}, ErrIneligible
+		}
+		return domain.RefundablePayment{
+			PaymentID:   charge.ID,
+			BusinessID:  charge.BusinessID,
+			Amount:      charge.CaptureAmount,
+			Currency:    charge.Currency,
+			Country:     charge.Country(),
+			ChannelCode: charge.ChannelCode,
+		}, nil
+	}
+	return domain.RefundablePayment{}, ErrPaymentNotFound
+}
+
+func (s *Service) Get(id string) (domain.Refund, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.refunds[id]
+	return record.Refund, ok
+}
+
+// List returns matching refunds, newest first.
+func (s *Service) List(filter Filter) []domain.Refund {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refunds := make([]domain.Refund, 0)
+	for _, record := range s.refunds {
+		refund := record.Refund
+		if filter.PaymentRequestID != "" && refund.PaymentRequestID != filter.PaymentRequestID {
+			continue
+		}
+		if filter.InvoiceID != "" && refund.InvoiceID != filter.InvoiceID {
+			continue
+		}
+		if filter.EWalletChargeID != "" && refund.EWalletChargeID != filter.EWalletChargeID {
+			continue
+		}
+		refunds = append(refunds, refund)
+	}
+	sort.Slice(refunds, func(i, j int) bool {
+		if refunds[i].Created != refunds[j].Created {
+			return refunds[i].Created > refunds[j].Created
+		}
+		return refunds[i].ID < refunds[j].ID
+	})
+	if filter.Limit > 0 && len(refunds) > filter.Limit {
+		refunds = refunds[:filter.Limit]
+	}
+	return refunds
+}
+
+func (s *Service) businessID(forUserID string) string {
+	if forUserID != "" {
+		return forUserID
+	}
+	return s.userID
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refunds = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refunds := make(map[string]Record, len(s.refunds))
+	for id, record := range s.refunds {
+		refunds[id] = record
+	}
+	return refunds
+}
+
+func (s *Service) Restore(data json.RawMessage) error {
+	var refunds map[string]Record
+	if err := json.Unmarshal(data, &refunds); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refunds = make(map[string]Record, len(refunds))
+	for id, record := range refunds {
+		s.refunds[id] = record
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package refund
+
+import (
+	"errors"
+	"testing"
+
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/invoice"
+)
+
+func newPaidInvoice(t *testing.T, engine *scenario.Engine) (*invoice.Service, domain.Invoice) {
+	t.Helper()
+	invoices := invoice.NewService(engine, &callbacktest.Recorder{}, "user_mock")
+	inv, _ := invoices.Create(domain.InvoiceRequest{ExternalID: "order-1", Amount: 50000})
+	if _, err := invoices.Pay(inv.ID, domain.InvoicePayment{}); err != nil {
+		t.Fatalf("expected payment to succeed, got %v", err)
+	}
+	return invoices, inv
+}
+
+func TestCreateLimitsRefundsToWhatIsLeft(t *testing.T) {
+	engine := scenario.NewEngine(nil)
+	invoices, inv := newPaidInvoice(t, engine)
+	sender := &callbacktest.Recorder{}
+	service := NewService(engine, sender, "user_mock").WithInvoices(invoices)
+
+	refund, err := service.Create(domain.RefundRequest{InvoiceID: inv.ID, Amount: 30000})
+	if err != nil {
+		t.Fatalf("expected the refund to be created, got %v", err)
+	}
+	if stored, _ := service.Get(refund.ID); stored.Status != domain.RefundStatusSucceeded {
+		t.Fatalf("expected the refund to succeed without a rule, got %s", stored.Status)
+	}
+	if len(sender.Events()) != 1 || sender.Events()[0].Status != domain.RefundStatusSucceeded {
+		t.Fatalf("expected one refund.succeeded webhook, got %+v", sender.Events())
+	}
+
+	if _, err := service.Create(domain.RefundRequest{InvoiceID: inv.ID, Amount: 30000}); !errors.Is(err, ErrAmountExceeded) {
+		t.Fatalf("expected ErrAmountExceeded, got %v", err)
+	}
+	rest, err := service.Create(domain.RefundRequest{InvoiceID: inv.ID})
+	if err != nil || rest.Amount != 20000 {
+		t.Fatalf("expected the rest of the payment refunded, got %d %v", rest.Amount, err)
+	}
+}
+
+func TestFailedRefundsLeaveTheAmountRefundable(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{Refunds: []scenario.RefundRule{{ReferenceID: "ref-fail", Outcome: scenario.RefundFail}}})
+	invoices, inv := newPaidInvoice(t, engine)
+	service := NewService(engine, &callbacktest.Recorder{}, "user_mock").WithInvoices(invoices)
+
+	failed, _ := service.Create(domain.RefundRequest{InvoiceID: inv.ID, ReferenceID: "ref-fail"})
+	if stored, _ := service.Get(failed.ID); stored.Status != domain.RefundStatusFailed || stored.FailureCode != domain.RefundFailureUnknown {
+		t.Fatalf("expected a FAILED refund with the default failure code, got %+v", stored)
+	}
+	if refund, err := service.Create(domain.RefundRequest{InvoiceID: inv.ID}); err != nil || refund.Amount != 50000 {
+		t.Fatalf("expected the whole payment still refundable, got %d %v", refund.Amount, err)
+	}
+}
+
+func TestCreateRejectsPaymentsThatCannotBeRefunded(t *testing.T) {
+	engine := scenario.NewEngine(nil)
+	invoices := invoice.NewService(engine, &callbacktest.Recorder{}, "user_mock")
+	pending, _ := invoices.Create(domain.InvoiceRequest{ExternalID: "order-1", Amount: 50000})
+	service := NewService(engine, &callbacktest.Recorder{}, "user_mock").WithInvoices(invoices)
+
+	if _, err := service.Create(domain.RefundRequest{InvoiceID: pending.ID}); !errors.Is(err, ErrIneligible) {
+		t.Fatalf("expected ErrIneligible for an unpaid invoice, got %v", err)
+	}
+	if _, err := service.Create(domain.RefundRequest{InvoiceID: "inv_missing"}); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound, got %v", err)
+	}
+	paidInvoices, paid := newPaidInvoice(t, engine)
+	service = NewService(engine, &callbacktest.Recorder{}, "user_mock").WithInvoices(paidInvoices)
+	if _, err := service.Create(domain.RefundRequest{InvoiceID: paid.ID, ForUserID: "sub-account-1"}); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound refunding another business's invoice, got %v", err)
+	}
+	if _, err := service.Create(domain.RefundRequest{PaymentRequestID: "pr_missing"}); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound without payment requests wired, got %v", err)
+	}
+}
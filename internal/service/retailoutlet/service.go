@@ -0,0 +1,290 @@
+package retailoutlet
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound       = errors.New("fixed payment code not found")
+	ErrInactive       = errors.New("fixed payment code is not ACTIVE")
+	ErrAmountExceeded = errors.New("amount exceeds the amount left to pay")
+	ErrDuplicateCode  = errors.New("payment code is already in use at this retail outlet")
+	ErrBelowPaid      = errors.New("expected_amount must be more than has already been paid towards it")
+)
+
+type Service struct {
+	engine *scenario.Engine
+	cb     callback.Sender
+	userID string
+	ledger *balance.Ledger
+	mu     sync.Mutex
+	codes  map[string]Record
+}
+
+// Record keeps a fixed payment code with its payments. Paid is what has been
+// paid towards the current expected amount, so partial payments add up.
+type Record struct {
+	Request  domain.FixedPaymentCodeRequest   `json:"request"`
+	Code     domain.FixedPaymentCode          `json:"fixed_payment_code"`
+	Payments []domain.FixedPaymentCodePayment `json:"payments,omitempty"`
+	Paid     int                              `json:"paid,omitempty"`
+	Session  string                           `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, codes: make(map[string]Record)}
+}
+
+// WithLedger credits outlet payments to the owner's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create stores an ACTIVE payment code that expires at its expiration date,
+// then applies the scenario's retail outlet rule. Active payment codes are
+// unique per outlet.
+func (s *Service) Create(req domain.FixedPaymentCodeRequest) (domain.FixedPaymentCode, error) {
+	ownerID := req.ForUserID
+	if ownerID == "" {
+		ownerID = s.userID
+	}
+	code := domain.BuildFixedPaymentCode(req, ownerID)
+
+	s.mu.Lock()
+	if _, taken := s.activeCode(code.RetailOutletName, code.PaymentCode); taken {
+		s.mu.Unlock()
+		return domain.FixedPaymentCode{}, ErrDuplicateCode
+	}
+	s.codes[code.ID] = Record{Request: req, Code: code, Session: req.Session}
+	s.mu.Unlock()
+
+	s.scheduleExpiry(code)
+
+	rule, ok := s.engine.RetailOutletRule(req.ExternalID)
+	if !ok {
+		return code, nil
+	}
+	var action func() error
+	switch rule.Outcome {
+	case scenario.RetailOutletPay, scenario.RetailOutletPartial:
+		amount := rule.PaidAmount
+		if amount <= 0 {
+			amount = code.ExpectedAmount
+			if rule.Outcome == scenario.RetailOutletPartial {
+				amount /= 2
+			}
+		}
+		action = func() error {
+			_, err := s.pay(code.ID, amount)
+			return err
+		}
+	case scenario.RetailOutletExpire:
+		action = func() error { return s.expire(code.ID) }
+	default:
+		return code, nil
+	}
+
+	if rule.DelayMS <= 0 {
+		return code, action()
+	}
+	time.AfterFunc(time.Duration(rule.DelayMS)*time.Millisecond, func() {
+		if err := action(); err != nil {
+			log.Printf("[retailoutlet.Create] scheduled %s failed id=%s error=%v", rule.Outcome, code.ID, err)
+		}
+	})
+	return code, nil
+}
+
+// Update changes an active payment code. Xendit sends no callback for it.
+func (s *Service) Update(id string, update domain.FixedPaymentCodeUpdate) (domain.FixedPaymentCode, error) {
+	s.mu.Lock()
+	record, ok := s.codes[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.FixedPaymentCode{}, ErrNotFound
+	}
+	if record.Code.Status != domain.FixedPaymentCodeActive {
+		s.mu.Unlock()
+		return record.Code, ErrInactive
+	}
+	if update.Name != nil {
+		record.Code.Name = *update.Name
+	}
+	if update.ExpectedAmount != nil {
+		if record.Paid > 0 && *update.ExpectedAmount <= record.Paid {
+			s.mu.Unlock()
+			return record.Code, ErrBelowPaid
+		}
+		record.Code.ExpectedAmount = *update.ExpectedAmount
+	}
+	if update.ExpirationDate != nil {
+		record.Code.ExpirationDate = *update.ExpirationDate
+	}
+	if update.Description != nil {
+		record.Code.Description = *update.Description
+	}
+	s.codes[id] = record
+	s.mu.Unlock()
+
+	if update.ExpirationDate != nil {
+		s.scheduleExpiry(record.Code)
+	}
+	return record.Code, nil
+}
+
+// SimulatePayment pays amount at the outlet into the active code with
+// paymentCode, as Xendit's simulate payment endpoint does.
+func (s *Service) SimulatePayment(outlet, paymentCode string, amount int) (domain.FixedPaymentCodePayment, error) {
+	s.mu.Lock()
+	id, ok := s.activeCode(outlet, paymentCode)
+	s.mu.Unlock()
+
+	if !ok {
+		return domain.FixedPaymentCodePayment{}, ErrNotFound
+	}
+	return s.pay(id, amount)
+}
+
+func (s *Service) activeCode(outlet, paymentCode string) (string, bool) {
+	for id, record := range s.codes {
+		code := record.Code
+		if code.RetailOutletName == outlet && code.PaymentCode == paymentCode && code.Status == domain.FixedPaymentCodeActive {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// pay records a payment and sends its callback. A code is paid once payments
+// reach the expected amount: single-use codes then become INACTIVE, others
+// start over.
+func (s *Service) pay(id string, amount int) (domain.FixedPaymentCodePayment, error) {
+	s.mu.Lock()
+	record, ok := s.codes[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.FixedPaymentCodePayment{}, ErrNotFound
+	}
+	code := record.Code
+	if code.Status != domain.FixedPaymentCodeActive {
+		s.mu.Unlock()
+		return domain.FixedPaymentCodePayment{}, ErrInactive
+	}
+	if amount > code.ExpectedAmount-record.Paid {
+		s.mu.Unlock()
+		return domain.FixedPaymentCodePayment{}, ErrAmountExceeded
+	}
+	payment := domain.BuildFixedPaymentCodePayment(code, amount, len(record.Payments))
+	record.Payments = append(record.Payments, payment)
+	record.Paid += amount
+	if record.Paid == code.ExpectedAmount {
+		record.Paid = 0
+		if code.IsSingleUse {
+			record.Code.Status = domain.FixedPaymentCodeInactive
+		}
+	}
+	s.codes[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		s.ledger.TopUp(code.OwnerID, balance.AccountCash, amount)
+	}
+	return payment, s.cb.Deliver(callback.FixedPaymentCodePaymentEvent(payment, record.Session))
+}
+
+// scheduleExpiry deactivates the code at its expiration date unless the date
+// has changed by then, as it does when the code is updated or a snapshot is
+// restored.
+func (s *Service) scheduleExpiry(code domain.FixedPaymentCode) {
+	expiry, err := time.Parse(time.RFC3339, code.ExpirationDate)
+	if err != nil {
+		return
+	}
+	time.AfterFunc(time.Until(expiry), func() {
+		s.mu.Lock()
+		current := s.codes[code.ID].Code
+		s.mu.Unlock()
+		if current.ExpirationDate != code.ExpirationDate {
+			return
+		}
+		if err := s.expire(code.ID); err != nil && !errors.Is(err, ErrInactive) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[retailoutlet.scheduleExpiry] expiry failed id=%s error=%v", code.ID, err)
+		}
+	})
+}
+
+func (s *Service) expire(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.codes[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if record.Code.Status != domain.FixedPaymentCodeActive {
+		return ErrInactive
+	}
+	record.Code.Status = domain.FixedPaymentCodeInactive
+	record.Code.ExpirationDate = time.Now().Format(time.RFC3339)
+	s.codes[id] = record
+	return nil
+}
+
+func (s *Service) Get(id string) (domain.FixedPaymentCode, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.codes[id]
+	return record.Code, ok
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.codes = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make(map[string]Record, len(s.codes))
+	for id, record := range s.codes {
+		codes[id] = record
+	}
+	return codes
+}
+
+// Restore replaces the payment codes with the snapshot's and schedules the
+// expiry of the ACTIVE ones again.
+func (s *Service) Restore(data json.RawMessage) error {
+	var codes map[string]Record
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.codes = make(map[string]Record, len(codes))
+	for id, record := range codes {
+		s.codes[id] = record
+	}
+	s.mu.Unlock()
+
+	for _, record := range codes {
+		if record.Code.Status == domain.FixedPaymentCodeActive {
+			s.scheduleExpiry(record.Code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package retailoutlet
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestPartialPaymentsAddUpToTheExpectedAmount(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{RetailOutlets: []scenario.RetailOutletRule{{ExternalID: "fpc-partial", Outcome: scenario.RetailOutletPartial}}})
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(engine, sender, "user_mock").WithLedger(ledger)
+	code, err := service.Create(domain.FixedPaymentCodeRequest{ExternalID: "fpc-partial", RetailOutletName: "ALFAMART", Name: "Budi", ExpectedAmount: 10000, IsSingleUse: true})
+	if err != nil {
+		t.Fatalf("expected the code to be created, got %v", err)
+	}
+
+	tooLow := 5000
+	if _, err := service.Update(code.ID, domain.FixedPaymentCodeUpdate{ExpectedAmount: &tooLow}); !errors.Is(err, ErrBelowPaid) {
+		t.Fatalf("expected ErrBelowPaid after half was paid, got %v", err)
+	}
+	if _, err := service.SimulatePayment("ALFAMART", code.PaymentCode, 6000); !errors.Is(err, ErrAmountExceeded) {
+		t.Fatalf("expected ErrAmountExceeded paying more than is left, got %v", err)
+	}
+	if _, err := service.SimulatePayment("ALFAMART", code.PaymentCode, 5000); err != nil {
+		t.Fatalf("expected the rest to be paid, got %v", err)
+	}
+	if stored, _ := service.Get(code.ID); stored.Status != domain.FixedPaymentCodeInactive {
+		t.Fatalf("expected the paid single use code INACTIVE, got %s", stored.Status)
+	}
+	if len(sender.Events()) != 2 {
+		t.Fatalf("expected a callback for each payment, got %d", len(sender.Events()))
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 10000 {
+		t.Fatalf("expected both payments credited to CASH, got %d", got)
+	}
+}
+
+func TestMultipleUseCodesStartOverOncePaid(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	code, _ := service.Create(domain.FixedPaymentCodeRequest{ExternalID: "fpc-1", RetailOutletName: "INDOMARET", Name: "Budi", ExpectedAmount: 10000})
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.SimulatePayment("INDOMARET", code.PaymentCode, 10000); err != nil {
+			t.Fatalf("expected payment %d to be accepted, got %v", i+1, err)
+		}
+	}
+	if stored, _ := service.Get(code.ID); stored.Status != domain.FixedPaymentCodeActive {
+		t.Fatalf("expected the multiple use code to stay ACTIVE, got %s", stored.Status)
+	}
+	lower := 8000
+	if updated, err := service.Update(code.ID, domain.FixedPaymentCodeUpdate{ExpectedAmount: &lower}); err != nil || updated.ExpectedAmount != lower {
+		t.Fatalf("expected the expected amount lowered once nothing is owed, got %+v %v", updated, err)
+	}
+}
+
+func TestPaymentCodesAreUniqueWhileActive(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{RetailOutlets: []scenario.RetailOutletRule{{ExternalID: "fpc-expire", Outcome: scenario.RetailOutletExpire}}})
+	service := NewService(engine, &callbacktest.Recorder{}, "user_mock")
+	req := domain.FixedPaymentCodeRequest{ExternalID: "fpc-1", RetailOutletName: "ALFAMART", Name: "Budi", ExpectedAmount: 10000, PaymentCode: "TEST123"}
+	if _, err := service.Create(req); err != nil {
+		t.Fatalf("expected the code to be created, got %v", err)
+	}
+
+	if _, err := service.Create(req); !errors.Is(err, ErrDuplicateCode) {
+		t.Fatalf("expected ErrDuplicateCode at the same outlet, got %v", err)
+	}
+	other := req
+	other.RetailOutletName = "INDOMARET"
+	if _, err := service.Create(other); err != nil {
+		t.Fatalf("expected the code free at another outlet, got %v", err)
+	}
+
+	expired := req
+	expired.ExternalID, expired.PaymentCode = "fpc-expire", "TEST456"
+	code, _ := service.Create(expired)
+	if stored, _ := service.Get(code.ID); stored.Status != domain.FixedPaymentCodeInactive {
+		t.Fatalf("expected the code expired by its rule, got %s", stored.Status)
+	}
+	if _, err := service.Create(expired); err != nil {
+		t.Fatalf("expected an expired code to be reusable, got %v", err)
+	}
+}
+
+func TestRestoreSchedulesActiveExpiry(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	expiry := time.Now().Add(-time.Second).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{
+		"fpc_active": {Code: domain.FixedPaymentCode{ID: "fpc_active", Status: domain.FixedPaymentCodeActive, ExpirationDate: expiry}},
+	})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		code, _ := service.Get("fpc_active")
+		if code.Status == domain.FixedPaymentCodeInactive {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the restored ACTIVE code to expire, got %s", code.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,306 @@
+package virtualaccount
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+var (
+	ErrNotFound       = errors.New("callback virtual account not found")
+	ErrInactive       = errors.New("callback virtual account is not active")
+	ErrAmountMismatch = errors.New("amount does not match the expected amount of the closed virtual account")
+)
+
+type Service struct {
+	engine   *scenario.Engine
+	cb       callback.Sender
+	userID   string
+	ledger   *balance.Ledger
+	mu       sync.Mutex
+	accounts map[string]Record
+}
+
+type Record struct {
+	Request        domain.VirtualAccountRequest   `json:"request"`
+	VirtualAccount domain.VirtualAccount          `json:"virtual_account"`
+	Payments       []domain.VirtualAccountPayment `json:"payments,omitempty"`
+	Session        string                         `json:"session,omitempty"`
+}
+
+func NewService(engine *scenario.Engine, cb callback.Sender, userID string) *Service {
+	return &Service{engine: engine, cb: cb, userID: userID, accounts: make(map[string]Record)}
+}
+
+// WithLedger credits VA payments to the owner's CASH balance.
+func (s *Service) WithLedger(ledger *balance.Ledger) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// Create answers with the PENDING VA like Xendit, activates it and sends the
+// created callback, then applies the scenario's VA rule.
+func (s *Service) Create(req domain.VirtualAccountRequest) (domain.VirtualAccount, error) {
+	ownerID := req.ForUserID
+	if ownerID == "" {
+		ownerID = s.userID
+	}
+	va := domain.BuildVirtualAccount(req, ownerID)
+	active := va
+	active.Status = domain.VirtualAccountActive
+
+	s.mu.Lock()
+	s.accounts[va.ID] = Record{Request: req, VirtualAccount: active, Session: req.Session}
+	s.mu.Unlock()
+
+	errs := []error{s.cb.Deliver(callback.VirtualAccountEvent(active, req.Session))}
+	s.scheduleExpiry(active)
+
+	if rule, ok := s.engine.VirtualAccountRule(req.ExternalID); ok {
+		errs = append(errs, s.applyRule(va.ID, rule))
+	}
+	return va, errors.Join(errs...)
+}
+
+func (s *Service) applyRule(id string, rule scenario.VirtualAccountRule) error {
+	var action func() error
+	switch rule.Outcome {
+	case scenario.VirtualAccountPay, scenario.VirtualAccountPartial:
+		action = func() error {
+			amount := rule.PaidAmount
+			if amount <= 0 {
+				amount = s.amountFor(id, rule.Outcome == scenario.VirtualAccountPartial)
+			}
+			_, err := s.pay(id, amount)
+			return err
+		}
+	case scenario.VirtualAccountExpire:
+		action = func() error { return s.expire(id) }
+	default:
+		return nil
+	}
+
+	if rule.DelayMS <= 0 {
+		return action()
+	}
+	time.AfterFunc(time.Duration(rule.DelayMS)*time.Millisecond, func() {
+		if err := action(); err != nil {
+			log.Printf("[virtualaccount.applyRule] scheduled %s failed id=%s error=%v", rule.Outcome, id, err)
+		}
+	})
+	return nil
+}
+
+// amountFor is what a scripted payment pays: the expected or suggested
+// amount, or half of it for a partial payment.
+func (s *Service) amountFor(id string, partial bool) int {
+	s.mu.Lock()
+	va := s.accounts[id].VirtualAccount
+	s.mu.Unlock()
+
+	amount := va.ExpectedAmount
+	if amount <= 0 {
+		amount = va.SuggestedAmount
+	}
+	if amount <= 0 {
+		amount = 10000
+	}
+	if partial {
+		amount /= 2
+	}
+	return amount
+}
+
+// Update changes an active VA and sends the updated callback.
+func (s *Service) Update(id string, update domain.VirtualAccountUpdate) (domain.VirtualAccount, error) {
+	s.mu.Lock()
+	record, ok := s.accounts[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.VirtualAccount{}, ErrNotFound
+	}
+	if record.VirtualAccount.Status != domain.VirtualAccountActive {
+		s.mu.Unlock()
+		return record.VirtualAccount, ErrInactive
+	}
+	va := record.VirtualAccount
+	if update.SuggestedAmount != nil {
+		va.SuggestedAmount = *update.SuggestedAmount
+	}
+	if update.ExpectedAmount != nil {
+		va.ExpectedAmount = *update.ExpectedAmount
+	}
+	if update.ExpirationDate != nil {
+		va.ExpirationDate = *update.ExpirationDate
+	}
+	if update.IsSingleUse != nil {
+		va.IsSingleUse = *update.IsSingleUse
+	}
+	if update.Description != nil {
+		va.Description = *update.Description
+	}
+	if update.Name != nil {
+		va.Name = *update.Name
+	}
+	va.Updated = time.Now().Format(time.RFC3339)
+	record.VirtualAccount = va
+	s.accounts[id] = record
+	s.mu.Unlock()
+
+	if update.ExpirationDate != nil {
+		s.scheduleExpiry(va)
+	}
+	return va, s.cb.Deliver(callback.VirtualAccountEvent(va, record.Session))
+}
+
+// SimulatePayment pays amount into the newest active VA with externalID, as
+// Xendit's simulate payment endpoint does.
+func (s *Service) SimulatePayment(externalID string, amount int) (domain.VirtualAccountPayment, error) {
+	s.mu.Lock()
+	var id, created string
+	for candidate, record := range s.accounts {
+		va := record.VirtualAccount
+		if va.ExternalID == externalID && va.Status == domain.VirtualAccountActive && va.Created >= created {
+			id, created = candidate, va.Created
+		}
+	}
+	s.mu.Unlock()
+
+	if id == "" {
+		return domain.VirtualAccountPayment{}, ErrNotFound
+	}
+	return s.pay(id, amount)
+}
+
+func (s *Service) pay(id string, amount int) (domain.VirtualAccountPayment, error) {
+	s.mu.Lock()
+	record, ok := s.accounts[id]
+	if !ok {
+		s.mu.Unlock()
+		return domain.VirtualAccountPayment{}, ErrNotFound
+	}
+	va := record.VirtualAccount
+	if va.Status != domain.VirtualAccountActive {
+		s.mu.Unlock()
+		return domain.VirtualAccountPayment{}, ErrInactive
+	}
+	if va.IsClosed && va.ExpectedAmount > 0 && amount != va.ExpectedAmount {
+		s.mu.Unlock()
+		return domain.VirtualAccountPayment{}, ErrAmountMismatch
+	}
+	payment := domain.BuildVirtualAccountPayment(va, amount, len(record.Payments))
+	record.Payments = append(record.Payments, payment)
+	if va.IsSingleUse {
+		record.VirtualAccount.Status = domain.VirtualAccountInactive
+		record.VirtualAccount.Updated = payment.Updated
+	}
+	s.accounts[id] = record
+	s.mu.Unlock()
+
+	if s.ledger != nil {
+		s.ledger.TopUp(va.OwnerID, balance.AccountCash, amount)
+	}
+	errs := []error{s.cb.Deliver(callback.VirtualAccountPaymentEvent(payment, record.Session))}
+	if va.IsSingleUse {
+		errs = append(errs, s.cb.Deliver(callback.VirtualAccountEvent(record.VirtualAccount, record.Session)))
+	}
+	return payment, errors.Join(errs...)
+}
+
+// scheduleExpiry deactivates the VA at its expiration date unless the date
+// has changed by then, as it does when the VA is updated or a snapshot is
+// restored.
+func (s *Service) scheduleExpiry(va domain.VirtualAccount) {
+	expiry, err := time.Parse(time.RFC3339, va.ExpirationDate)
+	if err != nil {
+		return
+	}
+	time.AfterFunc(time.Until(expiry), func() {
+		s.mu.Lock()
+		current := s.accounts[va.ID].VirtualAccount
+		s.mu.Unlock()
+		if current.ExpirationDate != va.ExpirationDate {
+			return
+		}
+		if err := s.expire(va.ID); err != nil && !errors.Is(err, ErrInactive) && !errors.Is(err, ErrNotFound) {
+			log.Printf("[virtualaccount.scheduleExpiry] expiry failed id=%s error=%v", va.ID, err)
+		}
+	})
+}
+
+func (s *Service) expire(id string) error {
+	s.mu.Lock()
+	record, ok := s.accounts[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	if record.VirtualAccount.Status != domain.VirtualAccountActive {
+		s.mu.Unlock()
+		return ErrInactive
+	}
+	now := time.Now().Format(time.RFC3339)
+	record.VirtualAccount.Status = domain.VirtualAccountInactive
+	record.VirtualAccount.ExpirationDate = now
+	record.VirtualAccount.Updated = now
+	s.accounts[id] = record
+	s.mu.Unlock()
+
+	return s.cb.Deliver(callback.VirtualAccountEvent(record.VirtualAccount, record.Session))
+}
+
+func (s *Service) Get(id string) (domain.VirtualAccount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.accounts[id]
+	return record.VirtualAccount, ok
+}
+
+func (s *Service) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts = make(map[string]Record)
+}
+
+func (s *Service) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := make(map[string]Record, len(s.accounts))
+	for id, record := range s.accounts {
+		accounts[id] = record
+	}
+	return accounts
+}
+
+// Restore replaces the VAs with the snapshot's and schedules the expiry of
+// the ACTIVE ones again.
+func (s *Service) Restore(data json.RawMessage) error {
+	var accounts map[string]Record
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.accounts = make(map[string]Record, len(accounts))
+	for id, record := range accounts {
+		s.accounts[id] = record
+	}
+	s.mu.Unlock()
+
+	for _, record := range accounts {
+		if record.VirtualAccount.Status == domain.VirtualAccountActive {
+			s.scheduleExpiry(record.VirtualAccount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package virtualaccount
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback/callbacktest"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+)
+
+func TestClosedSingleUseAccountsTakeTheExpectedAmountOnce(t *testing.T) {
+	sender := &callbacktest.Recorder{}
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(scenario.NewEngine(nil), sender, "user_mock").WithLedger(ledger)
+	va, err := service.Create(domain.VirtualAccountRequest{ExternalID: "va-1", BankCode: "BCA", Name: "Budi", IsClosed: true, ExpectedAmount: 20000, IsSingleUse: true})
+	if err != nil || va.Status != domain.VirtualAccountPending {
+		t.Fatalf("expected the VA answered PENDING, got %+v %v", va, err)
+	}
+	if stored, _ := service.Get(va.ID); stored.Status != domain.VirtualAccountActive {
+		t.Fatalf("expected the stored VA ACTIVE, got %s", stored.Status)
+	}
+
+	if _, err := service.SimulatePayment("va-1", 10000); !errors.Is(err, ErrAmountMismatch) {
+		t.Fatalf("expected ErrAmountMismatch for a closed VA, got %v", err)
+	}
+	if _, err := service.SimulatePayment("va-1", 20000); err != nil {
+		t.Fatalf("expected the expected amount to be accepted, got %v", err)
+	}
+	if stored, _ := service.Get(va.ID); stored.Status != domain.VirtualAccountInactive {
+		t.Fatalf("expected the paid single use VA INACTIVE, got %s", stored.Status)
+	}
+	if _, err := service.SimulatePayment("va-1", 20000); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound without an active VA, got %v", err)
+	}
+	if len(sender.Events()) != 3 {
+		t.Fatalf("expected created, payment and updated callbacks, got %d", len(sender.Events()))
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 20000 {
+		t.Fatalf("expected the payment credited to CASH, got %d", got)
+	}
+}
+
+func TestRulesPayOrExpireTheAccount(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{VirtualAccounts: []scenario.VirtualAccountRule{
+		{ExternalID: "va-partial", Outcome: scenario.VirtualAccountPartial},
+		{ExternalID: "va-expire", Outcome: scenario.VirtualAccountExpire},
+	}})
+	ledger := balance.NewLedger(balance.Config{})
+	service := NewService(engine, &callbacktest.Recorder{}, "user_mock").WithLedger(ledger)
+
+	partial, _ := service.Create(domain.VirtualAccountRequest{ExternalID: "va-partial", BankCode: "BCA", Name: "Budi", SuggestedAmount: 10000})
+	if stored, _ := service.Get(partial.ID); stored.Status != domain.VirtualAccountActive {
+		t.Fatalf("expected an open VA to stay ACTIVE after a payment, got %s", stored.Status)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 5000 {
+		t.Fatalf("expected half the suggested amount paid, got %d", got)
+	}
+
+	expired, _ := service.Create(domain.VirtualAccountRequest{ExternalID: "va-expire", BankCode: "BNI", Name: "Budi"})
+	if stored, _ := service.Get(expired.ID); stored.Status != domain.VirtualAccountInactive {
+		t.Fatalf("expected the VA expired by its rule, got %s", stored.Status)
+	}
+	name := "Budi Santoso"
+	if _, err := service.Update(expired.ID, domain.VirtualAccountUpdate{Name: &name}); !errors.Is(err, ErrInactive) {
+		t.Fatalf("expected ErrInactive updating an expired VA, got %v", err)
+	}
+}
+
+func TestRestoreSchedulesActiveExpiry(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	expiry := time.Now().Add(-time.Second).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{
+		"va_active": {VirtualAccount: domain.VirtualAccount{ID: "va_active", Status: domain.VirtualAccountActive, ExpirationDate: expiry}},
+	})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		va, _ := service.Get("va_active")
+		if va.Status == domain.VirtualAccountInactive {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the restored ACTIVE VA to expire, got %s", va.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRestoredExpiryIgnoresTheOldTimer(t *testing.T) {
+	service := NewService(scenario.NewEngine(nil), &callbacktest.Recorder{}, "user_mock")
+	va, _ := service.Create(domain.VirtualAccountRequest{ExternalID: "va-1", BankCode: "BCA", Name: "Budi", ExpirationDate: time.Now().Add(time.Second).Format(time.RFC3339)})
+	stored, _ := service.Get(va.ID)
+	stored.ExpirationDate = time.Now().Add(time.Hour).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]Record{va.ID: {VirtualAccount: stored}})
+	if err := service.Restore(data); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if got, _ := service.Get(va.ID); got.Status != domain.VirtualAccountActive {
+		t.Fatalf("expected the VA kept ACTIVE until its restored expiration date, got %s", got.Status)
+	}
+}
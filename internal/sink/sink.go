@@ -0,0 +1,154 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxCaptures is how many callbacks a sink keeps; older ones are dropped.
+const MaxCaptures = 1000
+
+// Config controls how a named sink answers. Status codes are used in order,
+// one per received callback, and the last one repeats.
+type Config struct {
+	StatusCodes []int `json:"status_codes"`
+	LatencyMS   int   `json:"latency_ms"`
+}
+
+type Capture struct {
+	Received      time.Time       `json:"received"`
+	Method        string          `json:"method"`
+	CallbackToken string          `json:"callback_token,omitempty"`
+	Headers       http.Header     `json:"headers"`
+	Body          json.RawMessage `json:"body"`
+	StatusCode    int             `json:"status_code"`
+}
+
+type Store struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	Config   Config    `json:"config"`
+	Captures []Capture `json:"captures"`
+	Received int       `json:"received"`
+}
+
+func NewStore() *Store {
+	return &Store{buckets: make(map[string]*bucket)}
+}
+
+// Receive stores a callback and returns the status code and latency the sink
+// should answer with.
+func (s *Store) Receive(name, method string, headers http.Header, body []byte) (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(name)
+	status := http.StatusOK
+	if codes := b.Config.StatusCodes; len(codes) > 0 {
+		idx := b.Received
+		if idx >= len(codes) {
+			idx = len(codes) - 1
+		}
+		status = codes[idx]
+	}
+
+	if !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+	b.Captures = append(b.Captures, Capture{
+		Received:      time.Now(),
+		Method:        method,
+		CallbackToken: headers.Get("X-Callback-Token"),
+		Headers:       headers.Clone(),
+		Body:          append(json.RawMessage(nil), body...),
+		StatusCode:    status,
+	})
+	if len(b.Captures) > MaxCaptures {
+		b.Captures = append([]Capture(nil), b.Captures[len(b.Captures)-MaxCaptures:]...)
+	}
+	b.Received++
+	return status, time.Duration(b.Config.LatencyMS) * time.Millisecond
+}
+
+func (s *Store) Captures(name string) []Capture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[name]
+	if !ok {
+		return []Capture{}
+	}
+	return append([]Capture{}, b.Captures...)
+}
+
+func (s *Store) Config(name string) Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[name]
+	if !ok {
+		return Config{}
+	}
+	return b.Config
+}
+
+func (s *Store) Configure(name string, cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bucket(name).Config = cfg
+}
+
+// Clear drops what the sink captured and starts its status codes over. Its
+// config is kept.
+func (s *Store) Clear(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[name]; ok {
+		b.Captures = nil
+		b.Received = 0
+	}
+}
+
+func (s *Store) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := make(map[string]bucket, len(s.buckets))
+	for name, b := range s.buckets {
+		buckets[name] = bucket{Config: b.Config, Captures: append([]Capture(nil), b.Captures...), Received: b.Received}
+	}
+	return buckets
+}
+
+func (s *Store) Restore(data json.RawMessage) error {
+	var buckets map[string]bucket
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buckets = make(map[string]*bucket, len(buckets))
+	for name, b := range buckets {
+		b := b
+		s.buckets[name] = &b
+	}
+	return nil
+}
+
+func (s *Store) bucket(name string) *bucket {
+	b, ok := s.buckets[name]
+	if !ok {
+		b = &bucket{}
+		s.buckets[name] = b
+	}
+	return b
+}
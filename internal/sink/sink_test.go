@@ -0,0 +1,41 @@
+package sink
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClearKeepsConfig(t *testing.T) {
+	store := NewStore()
+	store.Configure("default", Config{StatusCodes: []int{500, 200}, LatencyMS: 5})
+
+	if status, _ := store.Receive("default", http.MethodPost, http.Header{}, []byte(`{}`)); status != 500 {
+		t.Fatalf("expected the first configured status, got %d", status)
+	}
+	store.Clear("default")
+
+	if got := store.Captures("default"); len(got) != 0 {
+		t.Fatalf("expected captures cleared, got %d", len(got))
+	}
+	status, latency := store.Receive("default", http.MethodPost, http.Header{}, []byte(`{}`))
+	if status != 500 || latency != 5*time.Millisecond {
+		t.Fatalf("expected the config kept and its status codes started over, got %d after %s", status, latency)
+	}
+}
+
+func TestCapturesAreCapped(t *testing.T) {
+	store := NewStore()
+	store.Configure("default", Config{StatusCodes: []int{500, 200}})
+
+	for i := 0; i < MaxCaptures+10; i++ {
+		store.Receive("default", http.MethodPost, http.Header{}, []byte(`{}`))
+	}
+	captures := store.Captures("default")
+	if len(captures) != MaxCaptures {
+		t.Fatalf("expected %d captures, got %d", MaxCaptures, len(captures))
+	}
+	if last := captures[len(captures)-1]; last.StatusCode != 200 {
+		t.Fatalf("expected the last status code to repeat, got %d", last.StatusCode)
+	}
+}
@@ -0,0 +1,133 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const Version = 1
+
+type Source interface {
+	Snapshot() any
+	Restore(data json.RawMessage) error
+}
+
+// Resetter is implemented by sources whose state is cleared by /xendit/reset.
+type Resetter interface {
+	Reset()
+}
+
+type Snapshot struct {
+	Version   int                        `json:"version"`
+	CreatedAt string                     `json:"created_at"`
+	State     map[string]json.RawMessage `json:"state"`
+}
+
+type Registry struct {
+	mu      sync.Mutex
+	sources map[string]Source
+	order   []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register adds source under name. Sources are restored and reset in the
+// order they were registered, so a source must be registered after the ones
+// it depends on, e.g. recurring plans after the clock they schedule on.
+func (r *Registry) Register(name string, source Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sources[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.sources[name] = source
+}
+
+func (r *Registry) Export() (Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snap := Snapshot{
+		Version:   Version,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		State:     make(map[string]json.RawMessage, len(r.sources)),
+	}
+	for _, name := range r.names() {
+		data, err := json.Marshal(r.sources[name].Snapshot())
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("export %s: %w", name, err)
+		}
+		snap.State[name] = data
+	}
+	return snap, nil
+}
+
+// Import restores the sections of snap. It is all or nothing: when a section
+// fails to restore, the sections restored before it get their previous state
+// back.
+func (r *Registry) Import(snap Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if snap.Version != Version {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	for name := range snap.State {
+		if _, ok := r.sources[name]; !ok {
+			return fmt.Errorf("unknown snapshot section %q", name)
+		}
+	}
+	previous := make(map[string]json.RawMessage, len(snap.State))
+	for name := range snap.State {
+		data, err := json.Marshal(r.sources[name].Snapshot())
+		if err != nil {
+			return fmt.Errorf("export %s: %w", name, err)
+		}
+		previous[name] = data
+	}
+
+	var restored []string
+	for _, name := range r.names() {
+		data, ok := snap.State[name]
+		if !ok {
+			continue
+		}
+		restored = append(restored, name)
+		if err := r.sources[name].Restore(data); err != nil {
+			return r.rollback(restored, previous, fmt.Errorf("restore %s: %w", name, err))
+		}
+	}
+	return nil
+}
+
+// rollback restores the previous state of the named sources after err.
+func (r *Registry) rollback(names []string, previous map[string]json.RawMessage, err error) error {
+	errs := []error{err}
+	for _, name := range names {
+		if rollbackErr := r.sources[name].Restore(previous[name]); rollbackErr != nil {
+			errs = append(errs, fmt.Errorf("roll back %s: %w", name, rollbackErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range r.names() {
+		if resetter, ok := r.sources[name].(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+}
+
+func (r *Registry) names() []string {
+	return r.order
+}
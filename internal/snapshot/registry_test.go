@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type recordingSource struct {
+	name  string
+	order *[]string
+}
+
+func (s recordingSource) Snapshot() any {
+	return s.name
+}
+
+func (s recordingSource) Restore(data json.RawMessage) error {
+	*s.order = append(*s.order, s.name)
+	return nil
+}
+
+func (s recordingSource) Reset() {
+	*s.order = append(*s.order, s.name)
+}
+
+type valueSource struct {
+	value *string
+}
+
+func (s valueSource) Snapshot() any {
+	return *s.value
+}
+
+func (s valueSource) Restore(data json.RawMessage) error {
+	return json.Unmarshal(data, s.value)
+}
+
+type failingSource struct{}
+
+func (failingSource) Snapshot() any {
+	return nil
+}
+
+func (failingSource) Restore(data json.RawMessage) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return errors.New("broken section")
+}
+
+func TestRegistryRestoresInRegistrationOrder(t *testing.T) {
+	var order []string
+	registry := NewRegistry()
+	for _, name := range []string{"clock", "recurring", "balance"} {
+		registry.Register(name, recordingSource{name: name, order: &order})
+	}
+
+	snap, err := registry.Export()
+	if err != nil {
+		t.Fatalf("expected export to succeed, got %v", err)
+	}
+	if err := registry.Import(snap); err != nil {
+		t.Fatalf("expected import to succeed, got %v", err)
+	}
+	registry.Reset()
+
+	want := []string{"clock", "recurring", "balance", "clock", "recurring", "balance"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRegistryRejectsUnknownSections(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Import(Snapshot{Version: Version, State: map[string]json.RawMessage{"unknown": json.RawMessage(`{}`)}})
+	if err == nil {
+		t.Fatal("expected an unknown section to be rejected")
+	}
+}
+
+func TestRegistryImportRollsBackOnError(t *testing.T) {
+	value := "before"
+	registry := NewRegistry()
+	registry.Register("first", valueSource{value: &value})
+	registry.Register("second", failingSource{})
+
+	err := registry.Import(Snapshot{Version: Version, State: map[string]json.RawMessage{
+		"first":  json.RawMessage(`"after"`),
+		"second": json.RawMessage(`{}`),
+	}})
+	if err == nil {
+		t.Fatal("expected the broken section to fail the import")
+	}
+	if value != "before" {
+		t.Fatalf("expected the first section rolled back, got %q", value)
+	}
+}
@@ -0,0 +1,183 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/clock"
+	"xendit-api-mock/internal/snapshot"
+)
+
+type AdminHandler struct {
+	snapshots *snapshot.Registry
+	callbacks *callback.Client
+	clock     *clock.Clock
+	userID    string
+}
+
+func NewAdminHandler(snapshots *snapshot.Registry, callbacks *callback.Client, userID string) *AdminHandler {
+	return &AdminHandler{snapshots: snapshots, callbacks: callbacks, userID: userID}
+}
+
+// WithClock exposes the virtual clock under /xendit/admin/clock.
+func (h *AdminHandler) WithClock(clk *clock.Clock) *AdminHandler {
+	h.clock = clk
+	return h
+}
+
+func (h *AdminHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/admin/snapshot", loggingHandler("handleSnapshot", http.HandlerFunc(h.handleSnapshot)))
+	mux.Handle("/xendit/admin/callbacks", loggingHandler("handleListCallbacks", http.HandlerFunc(h.handleListCallbacks)))
+	mux.Handle("/xendit/admin/callbacks/dead-letters", loggingHandler("handleDeadLetters", http.HandlerFunc(h.handleDeadLetters)))
+	mux.Handle("/xendit/admin/callbacks/", loggingHandler("handleCallbackDelivery", http.HandlerFunc(h.handleCallbackDelivery)))
+	mux.Handle("/xendit/admin/callback-routes", loggingHandler("handleCallbackRoutes", http.HandlerFunc(h.handleCallbackRoutes)))
+	mux.Handle("/xendit/callback_urls/", loggingHandler("handleSetCallbackURL", http.HandlerFunc(h.handleSetCallbackURL)))
+	if h.clock != nil {
+		mux.Handle("/xendit/admin/clock", loggingHandler("handleClock", http.HandlerFunc(h.handleClock)))
+		mux.Handle("/xendit/admin/clock/advance", loggingHandler("handleAdvanceClock", http.HandlerFunc(h.handleAdvanceClock)))
+	}
+}
+
+func (h *AdminHandler) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		snap, err := h.snapshots.Export()
+		if err != nil {
+			log.Printf("[handleSnapshot] export failed: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, snap)
+	case http.MethodPost:
+		var snap snapshot.Snapshot
+		if err := json.NewDecoder(r.Body).Decode(&snap); err != nil {
+			log.Printf("[handleSnapshot] decode failed: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if err := h.snapshots.Import(snap); err != nil {
+			log.Printf("[handleSnapshot] import failed: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "restored"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *AdminHandler) handleListCallbacks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	deliveries := make([]callback.Delivery, 0)
+	for _, d := range h.callbacks.History().List() {
+		if webhookID := query.Get("webhook_id"); webhookID != "" && d.WebhookID != webhookID {
+			continue
+		}
+		if resourceID := query.Get("id"); resourceID != "" && d.ResourceID != resourceID {
+			continue
+		}
+		if externalID := query.Get("external_id"); externalID != "" && d.ExternalID != externalID {
+			continue
+		}
+		if state := query.Get("state"); state != "" && d.State != state {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": deliveries})
+}
+
+func (h *AdminHandler) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": h.callbacks.History().DeadLetters()})
+}
+
+func (h *AdminHandler) handleCallbackDelivery(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/admin/callbacks/")
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		delivery, ok := h.callbacks.History().Get(segments[0])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": callback.ErrDeliveryNotFound.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, delivery)
+	case len(segments) == 2 && segments[1] == "resend" && r.Method == http.MethodPost:
+		delivery, err := h.callbacks.Resend(segments[0])
+		if errors.Is(err, callback.ErrDeliveryNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("[handleCallbackDelivery] resend failed: %v", err)
+		}
+		writeJSON(w, http.StatusOK, delivery)
+	case len(segments) == 1 || len(segments) == 2 && segments[1] == "resend":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *AdminHandler) handleClock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"now": h.clock.Now().Format(time.RFC3339)})
+}
+
+// handleAdvanceClock moves the virtual clock forward by days plus a Go
+// duration such as "36h", running whatever falls due on the way.
+func (h *AdminHandler) handleAdvanceClock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Days     int    `json:"days"`
+		Duration string `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	d := time.Duration(req.Days) * 24 * time.Hour
+	if req.Duration != "" {
+		extra, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "duration is not a valid duration"})
+			return
+		}
+		d += extra
+	}
+	if d <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "the clock only moves forward"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"now": h.clock.Advance(d).Format(time.RFC3339)})
+}
+
+func pathSegments(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
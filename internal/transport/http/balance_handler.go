@@ -0,0 +1,85 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"xendit-api-mock/internal/balance"
+)
+
+type BalanceHandler struct {
+	ledger *balance.Ledger
+	userID string
+}
+
+func NewBalanceHandler(ledger *balance.Ledger, userID string) *BalanceHandler {
+	return &BalanceHandler{ledger: ledger, userID: userID}
+}
+
+func (h *BalanceHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/balance", loggingHandler("handleBalance", http.HandlerFunc(h.handleBalance)))
+	mux.Handle("/xendit/admin/balance", loggingHandler("handleAdminBalance", http.HandlerFunc(h.handleAdminBalance)))
+}
+
+func (h *BalanceHandler) handleBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	accountType := r.URL.Query().Get("account_type")
+	if accountType == "" {
+		accountType = balance.AccountCash
+	}
+	if !balance.IsAccountType(accountType) {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "account_type must be CASH, HOLDING or TAX")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"balance": h.ledger.Balance(h.userFor(r), accountType)})
+}
+
+func (h *BalanceHandler) handleAdminBalance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.ledger.List())
+	case http.MethodPost:
+		var body struct {
+			UserID      string `json:"user_id"`
+			AccountType string `json:"account_type"`
+			Amount      int    `json:"amount"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if body.UserID == "" {
+			body.UserID = h.userID
+		}
+		if body.AccountType == "" {
+			body.AccountType = balance.AccountCash
+		}
+		if !balance.IsAccountType(body.AccountType) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "account_type must be CASH, HOLDING or TAX"})
+			return
+		}
+		if body.Amount == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount is required"})
+			return
+		}
+		writeJSON(w, http.StatusOK, balance.Account{
+			UserID:      body.UserID,
+			AccountType: body.AccountType,
+			Balance:     h.ledger.TopUp(body.UserID, body.AccountType, body.Amount),
+		})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BalanceHandler) userFor(r *http.Request) string {
+	if userID := r.Header.Get("for-user-id"); userID != "" {
+		return userID
+	}
+	return h.userID
+}
@@ -0,0 +1,45 @@
+package httptransport
+
+import (
+	"errors"
+	"net/http"
+
+	"xendit-api-mock/internal/bank"
+)
+
+type BankHandler struct {
+	catalogue *bank.Catalogue
+}
+
+func NewBankHandler(catalogue *bank.Catalogue) *BankHandler {
+	return &BankHandler{catalogue: catalogue}
+}
+
+func (h *BankHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/available_disbursements_banks", loggingHandler("handleAvailableBanks", http.HandlerFunc(h.handleAvailableBanks)))
+}
+
+func (h *BankHandler) handleAvailableBanks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.catalogue.List())
+}
+
+// writeBankError answers a failed bank.Catalogue validation with Xendit's
+// error code; unsupportedCode differs between the legacy and v2 APIs.
+func writeBankError(w http.ResponseWriter, err error, unsupportedCode string) {
+	code := "API_VALIDATION_ERROR"
+	switch {
+	case errors.Is(err, bank.ErrUnsupported):
+		code = unsupportedCode
+	case errors.Is(err, bank.ErrBelowMinimum):
+		code = "MINIMUM_TRANSFER_LIMIT_ERROR"
+	case errors.Is(err, bank.ErrAboveMaximum):
+		code = "MAXIMUM_TRANSFER_LIMIT_ERROR"
+	case errors.Is(err, bank.ErrIncrement):
+		code = "AMOUNT_INCREMENT_NOT_SUPPORTED"
+	}
+	writeXenditError(w, http.StatusBadRequest, code, err.Error())
+}
@@ -0,0 +1,126 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/batch"
+)
+
+type BatchHandler struct {
+	service *batch.Service
+	banks   *bank.Catalogue
+}
+
+func NewBatchHandler(service *batch.Service) *BatchHandler {
+	return &BatchHandler{service: service}
+}
+
+func (h *BatchHandler) WithBanks(banks *bank.Catalogue) *BatchHandler {
+	h.banks = banks
+	return h
+}
+
+func (h *BatchHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/batch_disbursements", loggingHandler("handleCreateBatchDisbursement", http.HandlerFunc(h.handleCreateBatchDisbursement)))
+	mux.Handle("/xendit/admin/batch_disbursements/", loggingHandler("handleAdminBatchDisbursement", http.HandlerFunc(h.handleAdminBatchDisbursement)))
+}
+
+func (h *BatchHandler) handleCreateBatchDisbursement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeBatchDisbursementRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateBatchDisbursement] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	if h.banks != nil {
+		for i, item := range req.Disbursements {
+			if err := h.banks.Validate(item.BankCode, item.Amount); err != nil {
+				log.Printf("[handleCreateBatchDisbursement] bank validation failed bank_code=%s: %v", item.BankCode, err)
+				writeBankError(w, fmt.Errorf("disbursements[%d]: %w", i, err), "BANK_CODE_NOT_SUPPORTED_ERROR")
+				return
+			}
+		}
+	}
+
+	resp, err := h.service.Create(req)
+	switch {
+	case errors.Is(err, batch.ErrDuplicateRef):
+		writeXenditError(w, http.StatusConflict, "DUPLICATE_ERROR", err.Error())
+		return
+	case err != nil:
+		log.Printf("[handleCreateBatchDisbursement] callback failed: %v", err)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *BatchHandler) handleAdminBatchDisbursement(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/admin/batch_disbursements/")
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": batch.ErrNotFound.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "approve" && r.Method == http.MethodPost:
+		resp, err := h.service.Approve(segments[0])
+		switch {
+		case errors.Is(err, batch.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		case errors.Is(err, batch.ErrNotApprovable):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		case err != nil:
+			log.Printf("[handleAdminBatchDisbursement] callback failed: %v", err)
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 1 || len(segments) == 2 && segments[1] == "approve":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func decodeBatchDisbursementRequest(r *http.Request) (domain.BatchDisbursementRequest, error) {
+	var req domain.BatchDisbursementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.BatchDisbursementRequest{}, fmt.Errorf("invalid json")
+	}
+	if req.Reference == "" {
+		return req, fmt.Errorf("reference is required")
+	}
+	if len(req.Disbursements) == 0 {
+		return req, fmt.Errorf("disbursements must contain at least 1 item")
+	}
+	for i, item := range req.Disbursements {
+		switch {
+		case item.Amount <= 0:
+			return req, fmt.Errorf("disbursements[%d].amount must be greater than 0", i)
+		case item.BankCode == "":
+			return req, fmt.Errorf("disbursements[%d].bank_code is required", i)
+		case item.BankAccountName == "":
+			return req, fmt.Errorf("disbursements[%d].bank_account_name is required", i)
+		case item.BankAccountNumber == "":
+			return req, fmt.Errorf("disbursements[%d].bank_account_number is required", i)
+		case item.ExternalID == "":
+			return req, fmt.Errorf("disbursements[%d].external_id is required", i)
+		}
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
@@ -0,0 +1,77 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"xendit-api-mock/internal/callback"
+)
+
+func (h *AdminHandler) handleSetCallbackURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := pathSegments(r.URL.Path, "/xendit/callback_urls/")
+	if len(segments) != 1 || !callback.IsEventType(segments[0]) {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "callback type is not supported")
+		return
+	}
+
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+		return
+	}
+	if parsed, err := url.ParseRequestURI(body.URL); err != nil || parsed.Host == "" {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "url must be a valid URL")
+		return
+	}
+
+	forUserID := r.Header.Get("for-user-id")
+	router := h.callbacks.Router()
+	router.Set(callback.Route{URL: body.URL, UserID: forUserID, EventType: segments[0]})
+	route := router.Resolve(callback.Target{EventType: segments[0], UserID: forUserID})
+
+	userID := forUserID
+	if userID == "" {
+		userID = h.userID
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":         "SUCCESSFUL",
+		"user_id":        userID,
+		"url":            route.URL,
+		"environment":    "TEST",
+		"callback_token": route.Token,
+	})
+}
+
+func (h *AdminHandler) handleCallbackRoutes(w http.ResponseWriter, r *http.Request) {
+	router := h.callbacks.Router()
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, callback.RoutingTable{Routes: router.Routes()})
+	case http.MethodPut:
+		var table callback.RoutingTable
+		if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		router.Replace(table.Routes)
+		writeJSON(w, http.StatusOK, callback.RoutingTable{Routes: router.Routes()})
+	case http.MethodPost:
+		var route callback.Route
+		if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		router.Set(route)
+		writeJSON(w, http.StatusOK, callback.RoutingTable{Routes: router.Routes()})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,323 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/card"
+)
+
+var threeDSPage = template.Must(template.New("three_ds").Parse(`<!DOCTYPE html>
+<html>
+<head><title>3DS {{.ID}}</title></head>
+<body>
+<h1>Mock 3-D Secure</h1>
+{{if eq .Status "IN_REVIEW"}}
+<p>Authenticate {{.Currency}} {{.Amount}}</p>
+<form method="post" action="{{.Path}}/complete"><button type="submit">Complete authentication</button></form>
+<form method="post" action="{{.Path}}/fail"><button type="submit">Fail authentication</button></form>
+{{else}}
+<p>Authentication {{.Status}}. You can close this window.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type CardHandler struct {
+	service *card.Service
+}
+
+func NewCardHandler(service *card.Service) *CardHandler {
+	return &CardHandler{service: service}
+}
+
+func (h *CardHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/credit_card_tokens", loggingHandler("handleCreateCardToken", http.HandlerFunc(h.handleCreateCardToken)))
+	mux.Handle("/xendit/credit_card_tokens/", loggingHandler("handleCardToken", http.HandlerFunc(h.handleCardToken)))
+	mux.Handle("/xendit/credit_card_charges", loggingHandler("handleCreateCardCharge", http.HandlerFunc(h.handleCreateCardCharge)))
+	mux.Handle("/xendit/credit_card_charges/", loggingHandler("handleCardCharge", http.HandlerFunc(h.handleCardCharge)))
+	mux.Handle(domain.CardThreeDSPath, loggingHandler("handleThreeDS", http.HandlerFunc(h.handleThreeDS)))
+}
+
+func (h *CardHandler) handleCreateCardToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeCardTokenRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateCardToken] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.service.CreateToken(req))
+}
+
+func (h *CardHandler) handleCardToken(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/credit_card_tokens/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.GetToken(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", card.ErrTokenNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "authentications":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req domain.CardAuthenticationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		if req.Amount <= 0 {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "amount must be greater than 0")
+			return
+		}
+		resp, err := h.service.Authenticate(segments[0], req)
+		if !writeCardError(w, "handleCardToken", err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case len(segments) == 3 && segments[1] == "authentications":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.GetAuthentication(segments[2])
+		if !ok || resp.CreditCardTokenID != segments[0] {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", card.ErrAuthenticationNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *CardHandler) handleCreateCardCharge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeCardChargeRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateCardCharge] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	resp, err := h.service.Charge(req)
+	if !writeCardError(w, "handleCreateCardCharge", err) {
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (h *CardHandler) handleCardCharge(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/credit_card_charges/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "CREDIT_CARD_CHARGE_NOT_FOUND_ERROR", card.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "capture":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req domain.CardCaptureRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+				return
+			}
+		}
+		resp, err := h.service.Capture(segments[0], req.Amount)
+		if !writeCardError(w, "handleCardCharge", err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// handleThreeDS serves the page behind payer_authentication_url, where a
+// tester completes or fails 3-D Secure as the cardholder would.
+func (h *CardHandler) handleThreeDS(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, domain.CardThreeDSPath)
+	if len(segments) == 0 || len(segments) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := segments[0]
+
+	var (
+		auth domain.CardAuthentication
+		ok   bool
+	)
+	if len(segments) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		auth, ok = h.service.GetAuthentication(id)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+	} else {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if segments[1] != "complete" && segments[1] != "fail" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var err error
+		auth, err = h.service.CompleteThreeDS(id, segments[1] == "complete")
+		switch {
+		case errors.Is(err, card.ErrAuthenticationNotFound):
+			http.NotFound(w, r)
+			return
+		case errors.Is(err, card.ErrNotInReview):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := map[string]any{"ID": id, "Status": auth.Status, "Amount": auth.Amount, "Currency": auth.Currency, "Path": domain.CardThreeDSPath + id}
+	if err := threeDSPage.Execute(w, data); err != nil {
+		log.Printf("[handleThreeDS] render failed: %v", err)
+	}
+}
+
+// writeCardError answers service errors with Xendit's codes and reports
+// whether the response was written. Callback failures are only logged under
+// the calling handler's name, and the caller answers as usual.
+func writeCardError(w http.ResponseWriter, handler string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, card.ErrTokenNotFound):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_TOKEN_ID_ERROR", err.Error())
+	case errors.Is(err, card.ErrTokenUsed):
+		writeXenditError(w, http.StatusBadRequest, "TOKEN_ALREADY_USED_ERROR", err.Error())
+	case errors.Is(err, card.ErrAuthenticationNotFound):
+		writeXenditError(w, http.StatusBadRequest, "AUTHENTICATION_ID_NOT_FOUND_ERROR", err.Error())
+	case errors.Is(err, card.ErrAuthenticationRequired):
+		writeXenditError(w, http.StatusBadRequest, "AUTHENTICATION_ID_MISSING_ERROR", err.Error())
+	case errors.Is(err, card.ErrAuthenticationAmount), errors.Is(err, card.ErrAuthenticationUsed):
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+	case errors.Is(err, card.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "CREDIT_CARD_CHARGE_NOT_FOUND_ERROR", err.Error())
+	case errors.Is(err, card.ErrNotAuthorized):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_CHARGE_STATUS", err.Error())
+	case errors.Is(err, card.ErrCaptureAmount):
+		writeXenditError(w, http.StatusBadRequest, "AMOUNT_GREATER_THAN_AUTHORIZED_ERROR", err.Error())
+	case errors.Is(err, card.ErrCallback):
+		log.Printf("[%s] callback failed: %v", handler, err)
+		return false
+	default:
+		log.Printf("[%s] failed: %v", handler, err)
+		writeXenditError(w, http.StatusInternalServerError, "SERVER_ERROR", err.Error())
+	}
+	return true
+}
+
+func decodeCardTokenRequest(r *http.Request) (domain.CardTokenRequest, error) {
+	var req domain.CardTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.CardTokenRequest{}, fmt.Errorf("invalid json")
+	}
+	if !domain.LuhnValid(req.CardNumber) {
+		return req, fmt.Errorf("card_number is invalid")
+	}
+	month, err := strconv.Atoi(req.CardExpMonth)
+	if err != nil || len(req.CardExpMonth) != 2 || month < 1 || month > 12 {
+		return req, fmt.Errorf("card_exp_month must be MM")
+	}
+	year, err := strconv.Atoi(req.CardExpYear)
+	if err != nil || len(req.CardExpYear) != 4 {
+		return req, fmt.Errorf("card_exp_year must be YYYY")
+	}
+	if now := time.Now(); year < now.Year() || year == now.Year() && month < int(now.Month()) {
+		return req, fmt.Errorf("card is expired")
+	}
+	if err := validateCVN(req.CardCVN, req.CardNumber); err != nil {
+		return req, err
+	}
+	if (req.ShouldAuthenticate == nil || *req.ShouldAuthenticate) && !req.IsMultipleUse && req.Amount <= 0 {
+		return req, fmt.Errorf("amount is required to authenticate a single use token")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	return req, nil
+}
+
+func decodeCardChargeRequest(r *http.Request) (domain.CardChargeRequest, error) {
+	var req domain.CardChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.CardChargeRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.TokenID == "":
+		return req, fmt.Errorf("token_id is required")
+	case req.ExternalID == "":
+		return req, fmt.Errorf("external_id is required")
+	case req.Amount <= 0:
+		return req, fmt.Errorf("amount must be greater than 0")
+	}
+	if err := validateCVN(req.CardCVN, ""); err != nil {
+		return req, err
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
+
+// validateCVN checks an optional CVN: four digits for AMEX, three otherwise,
+// or either when the card is not known.
+func validateCVN(cvn, cardNumber string) error {
+	if cvn == "" {
+		return nil
+	}
+	if _, err := strconv.Atoi(cvn); err != nil {
+		return fmt.Errorf("card_cvn must be digits")
+	}
+	switch {
+	case cardNumber == "":
+		if len(cvn) != 3 && len(cvn) != 4 {
+			return fmt.Errorf("card_cvn must be 3 or 4 digits")
+		}
+	case domain.CardBrand(cardNumber) == "AMEX":
+		if len(cvn) != 4 {
+			return fmt.Errorf("card_cvn must be 4 digits")
+		}
+	case len(cvn) != 3:
+		return fmt.Errorf("card_cvn must be 3 digits")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"time"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/customer"
+)
+
+var mobileNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type CustomerHandler struct {
+	service *customer.Service
+}
+
+func NewCustomerHandler(service *customer.Service) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/customers", loggingHandler("handleCustomers", http.HandlerFunc(h.handleCustomers)))
+	mux.Handle("/xendit/customers/", loggingHandler("handleCustomer", http.HandlerFunc(h.handleCustomer)))
+}
+
+func (h *CustomerHandler) handleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, err := decodeCustomerRequest(r)
+		if err != nil {
+			log.Printf("[handleCustomers] decode failed: %v", err)
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		resp, err := h.service.Create(req)
+		if writeCustomerError(w, err) {
+			return
+		}
+		writeJSON(w, http.StatusCreated, resp)
+	case http.MethodGet:
+		referenceID := r.URL.Query().Get("reference_id")
+		if referenceID == "" {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "reference_id is required")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h.service.Search(r.Header.Get("for-user-id"), referenceID), "has_more": false})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) handleCustomer(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/customers/")
+	if len(segments) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp, ok := h.service.Get(r.Header.Get("for-user-id"), segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", customer.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPatch:
+		var update domain.CustomerUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		if err := validateCustomerUpdate(update); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		resp, err := h.service.Update(r.Header.Get("for-user-id"), segments[0], update)
+		if writeCustomerError(w, err) {
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// writeCustomerError answers service errors with Xendit's codes and reports
+// whether the response was written.
+func writeCustomerError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, customer.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+	case errors.Is(err, customer.ErrDuplicateRef):
+		writeXenditError(w, http.StatusConflict, "DUPLICATE_ERROR", err.Error())
+	case errors.Is(err, customer.ErrDetailTypeInvalid):
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+	default:
+		writeXenditError(w, http.StatusInternalServerError, "SERVER_ERROR", err.Error())
+	}
+	return true
+}
+
+func decodeCustomerRequest(r *http.Request) (domain.CustomerRequest, error) {
+	var req domain.CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.CustomerRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.Type == domain.CustomerIndividual:
+		if req.IndividualDetail == nil {
+			return req, fmt.Errorf("individual_detail is required for INDIVIDUAL")
+		}
+		if req.BusinessDetail != nil {
+			return req, fmt.Errorf("business_detail is not allowed for INDIVIDUAL")
+		}
+	case req.Type == domain.CustomerBusiness:
+		if req.BusinessDetail == nil {
+			return req, fmt.Errorf("business_detail is required for BUSINESS")
+		}
+		if req.IndividualDetail != nil {
+			return req, fmt.Errorf("individual_detail is not allowed for BUSINESS")
+		}
+	default:
+		return req, fmt.Errorf("type must be INDIVIDUAL or BUSINESS")
+	}
+	update := domain.CustomerUpdate{
+		IndividualDetail: req.IndividualDetail,
+		BusinessDetail:   req.BusinessDetail,
+		Email:            &req.Email,
+		MobileNumber:     &req.MobileNumber,
+		PhoneNumber:      &req.PhoneNumber,
+		Addresses:        req.Addresses,
+	}
+	if err := validateCustomerUpdate(update); err != nil {
+		return req, err
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	return req, nil
+}
+
+// validateCustomerUpdate checks the formats Xendit checks, on the fields that
+// are set.
+func validateCustomerUpdate(update domain.CustomerUpdate) error {
+	if detail := update.IndividualDetail; detail != nil {
+		if detail.GivenNames == "" {
+			return fmt.Errorf("individual_detail.given_names is required")
+		}
+		if detail.DateOfBirth != "" {
+			if _, err := time.Parse("2006-01-02", detail.DateOfBirth); err != nil {
+				return fmt.Errorf("individual_detail.date_of_birth must be YYYY-MM-DD")
+			}
+		}
+		switch detail.Gender {
+		case "", "MALE", "FEMALE", "OTHER":
+		default:
+			return fmt.Errorf("individual_detail.gender must be MALE, FEMALE or OTHER")
+		}
+	}
+	if update.BusinessDetail != nil && update.BusinessDetail.BusinessName == "" {
+		return fmt.Errorf("business_detail.business_name is required")
+	}
+	if update.Email != nil && *update.Email != "" {
+		if _, err := mail.ParseAddress(*update.Email); err != nil {
+			return fmt.Errorf("email is not a valid email address")
+		}
+	}
+	if update.MobileNumber != nil && *update.MobileNumber != "" && !mobileNumberPattern.MatchString(*update.MobileNumber) {
+		return fmt.Errorf("mobile_number must be in E.164 format")
+	}
+	if update.PhoneNumber != nil && *update.PhoneNumber != "" && !mobileNumberPattern.MatchString(*update.PhoneNumber) {
+		return fmt.Errorf("phone_number must be in E.164 format")
+	}
+	for _, address := range update.Addresses {
+		if len(address.Country) != 2 {
+			return fmt.Errorf("addresses.country must be an ISO 3166-1 alpha-2 code")
+		}
+	}
+	return nil
+}
@@ -9,15 +9,22 @@ import (
 	"xendit-api-mock/internal/domain"
 )
 
+const sessionHeader = "X-Mock-Session"
+
 func decodeDisbursementRequest(r *http.Request) (domain.DisbursementRequest, error) {
 	var req domain.DisbursementRequest
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&req); err != nil {
 		if err == io.EOF {
-			return domain.DefaultDisbursementRequest(), nil
+			req = domain.DefaultDisbursementRequest()
+			req.ForUserID = r.Header.Get("for-user-id")
+			req.Session = r.Header.Get(sessionHeader)
+			return req, nil
 		}
 		return domain.DisbursementRequest{}, fmt.Errorf("invalid json")
 	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
 	defaultReq := domain.DefaultDisbursementRequest()
 	if req.ExternalID == "" {
 		req.ExternalID = defaultReq.ExternalID
@@ -0,0 +1,260 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/ewallet"
+	"xendit-api-mock/internal/service/refund"
+)
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Charge.ChannelCode}} checkout</title></head>
+<body>
+<h1>{{.Charge.ChannelCode}} mock checkout</h1>
+<p>Reference: {{.Charge.ReferenceID}}</p>
+<p>Amount: {{.Charge.Currency}} {{.Charge.ChargeAmount}}</p>
+<p>Status: <strong>{{.Charge.Status}}</strong></p>
+{{if eq .Charge.Status "PENDING"}}
+<form method="post" action="{{.Path}}/approve"><button type="submit">Approve</button></form>
+<form method="post" action="{{.Path}}/decline"><button type="submit">Decline</button></form>
+{{end}}
+</body>
+</html>
+`))
+
+type EWalletHandler struct {
+	service *ewallet.Service
+	refunds *refund.Service
+}
+
+func NewEWalletHandler(service *ewallet.Service) *EWalletHandler {
+	return &EWalletHandler{service: service}
+}
+
+// WithRefunds serves the legacy charge refunds endpoint from the refunds
+// API, so those refunds share its cap, webhooks and scenario rules.
+func (h *EWalletHandler) WithRefunds(refunds *refund.Service) *EWalletHandler {
+	h.refunds = refunds
+	return h
+}
+
+func (h *EWalletHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/ewallets/charges", loggingHandler("handleCreateEWalletCharge", http.HandlerFunc(h.handleCreateEWalletCharge)))
+	mux.Handle("/xendit/ewallets/charges/", loggingHandler("handleEWalletCharge", http.HandlerFunc(h.handleEWalletCharge)))
+	mux.Handle(domain.EWalletCheckoutPath, loggingHandler("handleEWalletCheckout", http.HandlerFunc(h.handleEWalletCheckout)))
+}
+
+func (h *EWalletHandler) handleCreateEWalletCharge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeEWalletChargeRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateEWalletCharge] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	if _, ok := domain.EWalletRedirectChannels[req.ChannelCode]; !ok {
+		writeXenditError(w, http.StatusBadRequest, "UNSUPPORTED_CHANNEL", "channel_code is not supported")
+		return
+	}
+
+	resp, cbErr := h.service.Create(req)
+	if errors.Is(cbErr, ewallet.ErrCustomerNotFound) {
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", cbErr.Error())
+		return
+	}
+	if cbErr != nil {
+		log.Printf("[handleCreateEWalletCharge] callback failed: %v", cbErr)
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func (h *EWalletHandler) handleEWalletCharge(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/ewallets/charges/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", ewallet.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "void":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, err := h.service.Void(segments[0])
+		if !writeEWalletError(w, err) {
+			writeJSON(w, http.StatusAccepted, resp)
+		}
+	case len(segments) == 2 && segments[1] == "refunds" && h.refunds != nil:
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req domain.EWalletRefundRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		switch {
+		case req.Reason != "" && !domain.RefundReasons[req.Reason]:
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "reason must be one of FRAUDULENT, DUPLICATE, REQUESTED_BY_CUSTOMER, CANCELLATION or OTHERS")
+			return
+		case req.Amount < 0:
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "amount must not be negative")
+			return
+		}
+		resp, err := h.refunds.Create(domain.RefundRequest{
+			EWalletChargeID: segments[0],
+			ReferenceID:     req.ReferenceID,
+			Amount:          req.Amount,
+			Reason:          req.Reason,
+			ForUserID:       r.Header.Get("for-user-id"),
+			Session:         r.Header.Get(sessionHeader),
+		})
+		if writeRefundError(w, err) {
+			return
+		}
+		if err != nil {
+			log.Printf("[handleEWalletCharge] webhook failed: %v", err)
+		}
+		// The legacy endpoint answered with the settled refund.
+		if settled, ok := h.refunds.Get(resp.ID); ok {
+			resp = settled
+		}
+		charge, _ := h.service.Get(segments[0])
+		writeJSON(w, http.StatusOK, domain.BuildEWalletRefund(charge, resp))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// handleEWalletCheckout serves the page behind a charge's checkout actions,
+// where a tester approves or declines the payment as the payer would, and
+// is then sent to the charge's success or failure redirect URL.
+func (h *EWalletHandler) handleEWalletCheckout(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, domain.EWalletCheckoutPath)
+	if len(segments) == 0 || len(segments) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := segments[0]
+
+	if len(segments) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		charge, ok := h.service.Get(id)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := checkoutPage.Execute(w, map[string]any{"Charge": charge, "Path": domain.EWalletCheckoutPath + id}); err != nil {
+			log.Printf("[handleEWalletCheckout] render failed: %v", err)
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var (
+		charge   domain.EWalletCharge
+		err      error
+		redirect string
+	)
+	switch segments[1] {
+	case "approve":
+		charge, err = h.service.Approve(id)
+		redirect = charge.ChannelProperties.SuccessRedirectURL
+	case "decline":
+		charge, err = h.service.Decline(id, "")
+		redirect = charge.ChannelProperties.FailureRedirectURL
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case errors.Is(err, ewallet.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, ewallet.ErrNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("[handleEWalletCheckout] callback failed: %v", err)
+	}
+	if redirect == "" {
+		redirect = domain.EWalletCheckoutPath + id
+	}
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// writeEWalletError answers service errors with Xendit's codes and reports
+// whether the response was written.
+func writeEWalletError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ewallet.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+	case errors.Is(err, ewallet.ErrNotSucceeded):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_CHARGE_STATUS", err.Error())
+	default:
+		writeXenditError(w, http.StatusInternalServerError, "SERVER_ERROR", err.Error())
+	}
+	return true
+}
+
+func decodeEWalletChargeRequest(r *http.Request) (domain.EWalletChargeRequest, error) {
+	var req domain.EWalletChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.EWalletChargeRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.Amount <= 0:
+		return req, fmt.Errorf("amount must be greater than 0")
+	case req.ChannelCode == "":
+		return req, fmt.Errorf("channel_code is required")
+	}
+	switch req.CheckoutMethod {
+	case domain.EWalletOneTimePayment:
+		if req.ChannelCode == domain.EWalletChannelOVO && req.ChannelProperties.MobileNumber == "" {
+			return req, fmt.Errorf("channel_properties.mobile_number is required for ID_OVO")
+		}
+		if domain.EWalletRedirectChannels[req.ChannelCode] && req.ChannelProperties.SuccessRedirectURL == "" {
+			return req, fmt.Errorf("channel_properties.success_redirect_url is required for %s", req.ChannelCode)
+		}
+	case domain.EWalletTokenizedPayment:
+		if req.PaymentMethodID == "" {
+			return req, fmt.Errorf("payment_method_id is required for TOKENIZED_PAYMENT")
+		}
+	default:
+		return req, fmt.Errorf("checkout_method must be ONE_TIME_PAYMENT or TOKENIZED_PAYMENT")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
@@ -2,21 +2,39 @@ package httptransport
 
 import (
 	"encoding/json"
-	"io"
+	"errors"
 	"log"
 	"net/http"
-	"time"
 
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
 	"xendit-api-mock/internal/service/disbursement"
 )
 
 type Handler struct {
-	service     *disbursement.Service
-	callbackURL string
+	service   *disbursement.Service
+	callbacks *callback.Client
+	banks     *bank.Catalogue
+	reset     func()
 }
 
-func NewHandler(service *disbursement.Service, callbackURL string) *Handler {
-	return &Handler{service: service, callbackURL: callbackURL}
+func NewHandler(service *disbursement.Service, callbacks *callback.Client) *Handler {
+	return &Handler{service: service, callbacks: callbacks, reset: service.Reset}
+}
+
+// WithReset replaces what /xendit/reset clears, which defaults to the
+// disbursement service.
+func (h *Handler) WithReset(reset func()) *Handler {
+	h.reset = reset
+	return h
+}
+
+// WithBanks rejects disbursements to bank codes missing from the catalogue
+// or outside its amount limits.
+func (h *Handler) WithBanks(banks *bank.Catalogue) *Handler {
+	h.banks = banks
+	return h
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
@@ -25,6 +43,7 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/xendit/healthz-callback", loggingHandler("handleCallbackHealth", http.HandlerFunc(h.handleCallbackHealth)))
 	mux.Handle("/xendit/simulate/success", loggingHandler("handleSimulateSuccess", http.HandlerFunc(h.handleSimulateSuccess)))
 	mux.Handle("/xendit/reset", loggingHandler("handleReset", http.HandlerFunc(h.handleReset)))
+	mux.Handle("/xendit/admin/disbursements/", loggingHandler("handleDisbursementCallback", http.HandlerFunc(h.handleDisbursementCallback)))
 }
 
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -32,58 +51,42 @@ func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) handleCallbackHealth(w http.ResponseWriter, r *http.Request) {
-	if h.callbackURL == "" {
-		log.Printf("[handleCallbackHealth] CALLBACK_URL is not set")
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error"})
-		return
-	}
-	request, err := http.NewRequest(http.MethodPost, h.callbackURL, nil)
+	req := domain.DefaultDisbursementRequest()
+	req.ExternalID = "xamock_healthcheck_" + domain.ShortHash(req.ExternalID)
+	req.Description = "xamock callback health check"
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	event := h.service.TestEvent(req)
+
+	result, err := h.callbacks.Check(event)
 	if err != nil {
-		log.Printf("[handleCallbackHealth] request build failed: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error"})
+		log.Printf("[handleCallbackHealth] check failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
 		return
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(request)
-	if err != nil {
-		log.Printf("[handleCallbackHealth] request failed: %v", err)
-		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error"})
-		return
+	body := map[string]any{
+		"status":        "ok",
+		"url":           result.URL,
+		"status_code":   result.Attempt.StatusCode,
+		"latency_ms":    result.Attempt.LatencyMS,
+		"response_body": result.Attempt.ResponseBody,
+		"payload":       event.Payload,
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("[handleCallbackHealth] response read failed: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error"})
+	if result.Attempt.Error != "" && result.Attempt.StatusCode == 0 {
+		log.Printf("[handleCallbackHealth] request failed: %s", result.Attempt.Error)
+		body["status"] = "error"
+		body["error"] = result.Attempt.Error
+		writeJSON(w, http.StatusServiceUnavailable, body)
 		return
 	}
-
-	if len(body) == 0 {
-		log.Printf("[handleCallbackHealth] response status=%d body={empty}", resp.StatusCode)
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	if result.Rejected {
+		log.Printf("[handleCallbackHealth] receiver rejected callback status=%d", result.Attempt.StatusCode)
+		body["status"] = "rejected"
+		writeJSON(w, http.StatusBadGateway, body)
 		return
 	}
-
-	if json.Valid(body) {
-		var payload interface{}
-		if err := json.Unmarshal(body, &payload); err != nil {
-			log.Printf("[handleCallbackHealth] response status=%d body=%s", resp.StatusCode, string(body))
-			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-			return
-		}
-		pretty, err := json.MarshalIndent(payload, "", "  ")
-		if err != nil {
-			log.Printf("[handleCallbackHealth] response status=%d body=%s", resp.StatusCode, string(body))
-			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-			return
-		}
-		log.Printf("[handleCallbackHealth] response status=%d json=%s", resp.StatusCode, string(pretty))
-	} else {
-		log.Printf("[handleCallbackHealth] response status=%d body=%s", resp.StatusCode, string(body))
-	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, http.StatusOK, body)
 }
 
 func (h *Handler) handleCreateDisbursement(w http.ResponseWriter, r *http.Request) {
@@ -98,6 +101,13 @@ func (h *Handler) handleCreateDisbursement(w http.ResponseWriter, r *http.Reques
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	if h.banks != nil {
+		if err := h.banks.Validate(req.BankCode, req.Amount); err != nil {
+			log.Printf("[handleCreateDisbursement] bank validation failed bank_code=%s: %v", req.BankCode, err)
+			writeBankError(w, err, "BANK_CODE_NOT_SUPPORTED_ERROR")
+			return
+		}
+	}
 
 	resp, cbErr := h.service.Create(req)
 	if cbErr != nil {
@@ -128,13 +138,49 @@ func (h *Handler) handleSimulateSuccess(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *Handler) handleDisbursementCallback(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/admin/disbursements/")
+	if len(segments) != 2 || segments[1] != "callback" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Status      string `json:"status"`
+		FailureCode string `json:"failure_code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if body.Status != domain.StatusCompleted && body.Status != domain.StatusFailed && body.Status != domain.StatusReversed {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be COMPLETED, FAILED or REVERSED"})
+		return
+	}
+
+	resp, err := h.service.SendStatus(segments[0], body.Status, body.FailureCode)
+	if errors.Is(err, disbursement.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[handleDisbursementCallback] callback failed: %v", err)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) handleReset(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	h.service.Reset()
+	h.reset()
 	writeJSON(w, http.StatusOK, map[string]string{"status": "reset"})
 }
 
@@ -143,3 +189,7 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
+
+func writeXenditError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error_code": code, "message": message})
+}
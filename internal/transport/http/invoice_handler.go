@@ -0,0 +1,163 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/invoice"
+)
+
+type InvoiceHandler struct {
+	service *invoice.Service
+}
+
+func NewInvoiceHandler(service *invoice.Service) *InvoiceHandler {
+	return &InvoiceHandler{service: service}
+}
+
+func (h *InvoiceHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/v2/invoices", loggingHandler("handleInvoices", http.HandlerFunc(h.handleInvoices)))
+	mux.Handle("/xendit/v2/invoices/", loggingHandler("handleGetInvoice", http.HandlerFunc(h.handleGetInvoice)))
+	mux.Handle("/xendit/invoices/", loggingHandler("handleExpireInvoice", http.HandlerFunc(h.handleExpireInvoice)))
+	mux.Handle("/xendit/admin/invoices/", loggingHandler("handleAdminInvoice", http.HandlerFunc(h.handleAdminInvoice)))
+}
+
+func (h *InvoiceHandler) handleInvoices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, err := decodeInvoiceRequest(r)
+		if err != nil {
+			log.Printf("[handleInvoices] decode failed: %v", err)
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		resp, cbErr := h.service.Create(req)
+		if cbErr != nil {
+			log.Printf("[handleInvoices] callback failed: %v", cbErr)
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodGet:
+		query := r.URL.Query()
+		filter := invoice.Filter{ExternalID: query.Get("external_id")}
+		for _, value := range append(query["statuses"], query["statuses[]"]...) {
+			for _, status := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if status = strings.Trim(strings.TrimSpace(status), `"`); status != "" {
+					filter.Statuses = append(filter.Statuses, status)
+				}
+			}
+		}
+		if limit := query.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 {
+				writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "limit must be a positive number")
+				return
+			}
+			filter.Limit = n
+		}
+		writeJSON(w, http.StatusOK, h.service.List(filter))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InvoiceHandler) handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/v2/invoices/")
+	if len(segments) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, ok := h.service.Get(segments[0])
+	if !ok {
+		writeXenditError(w, http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", invoice.ErrNotFound.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *InvoiceHandler) handleExpireInvoice(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/invoices/")
+	if len(segments) != 2 || segments[1] != "expire!" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, err := h.service.Expire(segments[0])
+	switch {
+	case errors.Is(err, invoice.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", err.Error())
+		return
+	case errors.Is(err, invoice.ErrNotPending):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_INVOICE_STATUS", err.Error())
+		return
+	case err != nil:
+		log.Printf("[handleExpireInvoice] callback failed: %v", err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *InvoiceHandler) handleAdminInvoice(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/admin/invoices/")
+	if len(segments) != 2 || segments[1] != "pay" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payment domain.InvoicePayment
+	if err := json.NewDecoder(r.Body).Decode(&payment); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+
+	resp, err := h.service.Pay(segments[0], payment)
+	switch {
+	case errors.Is(err, invoice.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, invoice.ErrNotPending):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[handleAdminInvoice] callback failed: %v", err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func decodeInvoiceRequest(r *http.Request) (domain.InvoiceRequest, error) {
+	var req domain.InvoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.InvoiceRequest{}, fmt.Errorf("invalid json")
+	}
+	if req.ExternalID == "" {
+		return req, fmt.Errorf("external_id is required")
+	}
+	if req.Amount <= 0 {
+		return req, fmt.Errorf("amount must be greater than 0")
+	}
+	if req.InvoiceDuration < 0 {
+		return req, fmt.Errorf("invoice_duration must not be negative")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
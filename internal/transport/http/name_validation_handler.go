@@ -0,0 +1,95 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/namevalidation"
+)
+
+type NameValidationHandler struct {
+	service *namevalidation.Service
+	banks   *bank.Catalogue
+}
+
+func NewNameValidationHandler(service *namevalidation.Service) *NameValidationHandler {
+	return &NameValidationHandler{service: service}
+}
+
+// WithBanks rejects validations for banks missing from the catalogue or that
+// do not support name validation.
+func (h *NameValidationHandler) WithBanks(banks *bank.Catalogue) *NameValidationHandler {
+	h.banks = banks
+	return h
+}
+
+func (h *NameValidationHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/bank_account_data_requests", loggingHandler("handleCreateNameValidation", http.HandlerFunc(h.handleCreateNameValidation)))
+	mux.Handle("/xendit/bank_account_data_requests/", loggingHandler("handleGetNameValidation", http.HandlerFunc(h.handleGetNameValidation)))
+}
+
+func (h *NameValidationHandler) handleCreateNameValidation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeNameValidationRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateNameValidation] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	if h.banks != nil {
+		if b, ok := h.banks.Lookup(req.BankCode); !ok || !b.CanNameValidate {
+			writeXenditError(w, http.StatusBadRequest, "BANK_CODE_NOT_SUPPORTED_ERROR", "name validation is not supported for this bank code")
+			return
+		}
+	}
+
+	resp, cbErr := h.service.Create(req)
+	if cbErr != nil {
+		log.Printf("[handleCreateNameValidation] callback failed: %v", cbErr)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *NameValidationHandler) handleGetNameValidation(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/bank_account_data_requests/")
+	if len(segments) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, ok := h.service.Get(segments[0])
+	if !ok {
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", "name validation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func decodeNameValidationRequest(r *http.Request) (domain.NameValidationRequest, error) {
+	var req domain.NameValidationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.NameValidationRequest{}, fmt.Errorf("invalid json")
+	}
+	if req.BankCode == "" {
+		return req, fmt.Errorf("bank_code is required")
+	}
+	if req.BankAccountNumber == "" {
+		return req, fmt.Errorf("bank_account_number is required")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
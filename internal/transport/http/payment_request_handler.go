@@ -0,0 +1,297 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/paymentrequest"
+)
+
+var paymentActionPage = template.Must(template.New("payment_action").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Authenticate {{.ID}}</title></head>
+<body>
+<h1>Mock authentication</h1>
+<p>{{.Description}}</p>
+<form method="post" action="{{.Path}}/approve"><button type="submit">Approve</button></form>
+<form method="post" action="{{.Path}}/decline"><button type="submit">Decline</button></form>
+</body>
+</html>
+`))
+
+type PaymentRequestHandler struct {
+	service *paymentrequest.Service
+}
+
+func NewPaymentRequestHandler(service *paymentrequest.Service) *PaymentRequestHandler {
+	return &PaymentRequestHandler{service: service}
+}
+
+func (h *PaymentRequestHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/payment_requests", loggingHandler("handlePaymentRequests", http.HandlerFunc(h.handlePaymentRequests)))
+	mux.Handle("/xendit/payment_requests/", loggingHandler("handlePaymentRequest", http.HandlerFunc(h.handlePaymentRequest)))
+	mux.Handle("/xendit/payment_methods", loggingHandler("handlePaymentMethods", http.HandlerFunc(h.handlePaymentMethods)))
+	mux.Handle("/xendit/payment_methods/", loggingHandler("handlePaymentMethod", http.HandlerFunc(h.handlePaymentMethod)))
+	mux.Handle(domain.PaymentActionPath, loggingHandler("handlePaymentAction", http.HandlerFunc(h.handlePaymentAction)))
+}
+
+func (h *PaymentRequestHandler) handlePaymentRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, err := decodePaymentRequestRequest(r)
+		if err != nil {
+			log.Printf("[handlePaymentRequests] decode failed: %v", err)
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		resp, err := h.service.Create(req)
+		if writePaymentRequestError(w, err) {
+			return
+		}
+		writeJSON(w, http.StatusCreated, resp)
+	case http.MethodGet:
+		filter, ok := paymentFilter(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h.service.List(filter), "has_more": false})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PaymentRequestHandler) handlePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/payment_requests/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", paymentrequest.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && (segments[1] == "cancel" || segments[1] == "simulate"):
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var (
+			resp domain.PaymentRequest
+			err  error
+		)
+		if segments[1] == "cancel" {
+			resp, err = h.service.Cancel(segments[0])
+		} else {
+			resp, err = h.service.Simulate(segments[0])
+		}
+		if !writePaymentRequestError(w, err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *PaymentRequestHandler) handlePaymentMethods(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req domain.PaymentMethodRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		if err := validatePaymentMethodRequest(req); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+		req.ForUserID = r.Header.Get("for-user-id")
+		req.Session = r.Header.Get(sessionHeader)
+
+		resp, err := h.service.CreateMethod(req)
+		if writePaymentRequestError(w, err) {
+			return
+		}
+		writeJSON(w, http.StatusCreated, resp)
+	case http.MethodGet:
+		filter, ok := paymentFilter(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h.service.ListMethods(filter), "has_more": false})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PaymentRequestHandler) handlePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/payment_methods/")
+	if len(segments) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, ok := h.service.GetMethod(segments[0])
+	if !ok {
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", paymentrequest.ErrMethodNotFound.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handlePaymentAction serves the page behind AUTH actions, where a tester
+// approves or declines a payment or account linking as the customer would,
+// and is then sent to the channel's success or failure return URL.
+func (h *PaymentRequestHandler) handlePaymentAction(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, domain.PaymentActionPath)
+	if len(segments) == 0 || len(segments) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := segments[0]
+
+	if len(segments) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		description, ok := h.describeAction(id)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := paymentActionPage.Execute(w, map[string]string{"ID": id, "Description": description, "Path": domain.PaymentActionPath + id}); err != nil {
+			log.Printf("[handlePaymentAction] render failed: %v", err)
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if segments[1] != "approve" && segments[1] != "decline" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	next, err := h.service.Authenticate(id, segments[1] == "approve")
+	switch {
+	case errors.Is(err, paymentrequest.ErrNotFound), errors.Is(err, paymentrequest.ErrMethodNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, paymentrequest.ErrNoPendingAction):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("[handlePaymentAction] webhook failed: %v", err)
+	}
+	if next == "" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": segments[1] + "d"})
+		return
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+func (h *PaymentRequestHandler) describeAction(id string) (string, bool) {
+	if pr, ok := h.service.Get(id); ok {
+		return fmt.Sprintf("Pay %s %d with %s %s (%s)", pr.Currency, pr.Amount, pr.PaymentMethod.Type, pr.PaymentMethod.ChannelCode(), pr.Status), true
+	}
+	if pm, ok := h.service.GetMethod(id); ok {
+		return fmt.Sprintf("Link %s %s (%s)", pm.Type, pm.ChannelCode(), pm.Status), true
+	}
+	return "", false
+}
+
+// writePaymentRequestError answers service errors with Xendit's codes and
+// reports whether the response was written. Webhook failures are only
+// logged.
+func writePaymentRequestError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, paymentrequest.ErrNotFound), errors.Is(err, paymentrequest.ErrMethodNotFound),
+		errors.Is(err, paymentrequest.ErrCustomerNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+	case errors.Is(err, paymentrequest.ErrMethodNotActive):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_PAYMENT_METHOD", err.Error())
+	case errors.Is(err, paymentrequest.ErrNotPending):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_PAYMENT_REQUEST_STATUS", err.Error())
+	default:
+		log.Printf("[handlePaymentRequests] webhook failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func paymentFilter(w http.ResponseWriter, r *http.Request) (paymentrequest.Filter, bool) {
+	query := r.URL.Query()
+	filter := paymentrequest.Filter{ReferenceID: query.Get("reference_id"), CustomerID: query.Get("customer_id")}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "limit must be a positive number")
+			return filter, false
+		}
+		filter.Limit = n
+	}
+	return filter, true
+}
+
+func decodePaymentRequestRequest(r *http.Request) (domain.PaymentRequestRequest, error) {
+	var req domain.PaymentRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.PaymentRequestRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.Amount <= 0:
+		return req, fmt.Errorf("amount must be greater than 0")
+	case req.Currency == "":
+		return req, fmt.Errorf("currency is required")
+	case (req.PaymentMethod == nil) == (req.PaymentMethodID == ""):
+		return req, fmt.Errorf("exactly one of payment_method and payment_method_id is required")
+	}
+	if req.PaymentMethod != nil {
+		if err := validatePaymentMethodRequest(*req.PaymentMethod); err != nil {
+			return req, fmt.Errorf("payment_method: %w", err)
+		}
+		if req.PaymentMethod.CustomerID == "" {
+			req.PaymentMethod.CustomerID = req.CustomerID
+		}
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
+
+func validatePaymentMethodRequest(req domain.PaymentMethodRequest) error {
+	switch req.Type {
+	case domain.PaymentMethodEWallet, domain.PaymentMethodDirectDebit, domain.PaymentMethodCard,
+		domain.PaymentMethodVirtualAccount, domain.PaymentMethodQRCode, domain.PaymentMethodOverTheCounter:
+	default:
+		return fmt.Errorf("type is not supported")
+	}
+	if req.Reusability != "" && req.Reusability != domain.PaymentMethodOneTimeUse && req.Reusability != domain.PaymentMethodMultipleUse {
+		return fmt.Errorf("reusability must be ONE_TIME_USE or MULTIPLE_USE")
+	}
+	if channel := req.Channel(); channel == nil || channel.ChannelCode == "" {
+		return fmt.Errorf("channel_code is required for %s", req.Type)
+	}
+	return nil
+}
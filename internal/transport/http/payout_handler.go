@@ -0,0 +1,125 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/payout"
+)
+
+type PayoutHandler struct {
+	service *payout.Service
+	banks   *bank.Catalogue
+}
+
+func NewPayoutHandler(service *payout.Service) *PayoutHandler {
+	return &PayoutHandler{service: service}
+}
+
+func (h *PayoutHandler) WithBanks(banks *bank.Catalogue) *PayoutHandler {
+	h.banks = banks
+	return h
+}
+
+func (h *PayoutHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/v2/payouts", loggingHandler("handlePayouts", http.HandlerFunc(h.handlePayouts)))
+	mux.Handle("/xendit/v2/payouts/", loggingHandler("handlePayout", http.HandlerFunc(h.handlePayout)))
+}
+
+func (h *PayoutHandler) handlePayouts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, err := decodePayoutRequest(r)
+		if err != nil {
+			log.Printf("[handlePayouts] decode failed: %v", err)
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+		if h.banks != nil {
+			if err := h.banks.Validate(req.ChannelCode, req.Amount); err != nil {
+				log.Printf("[handlePayouts] channel validation failed channel_code=%s: %v", req.ChannelCode, err)
+				writeBankError(w, err, "CHANNEL_CODE_NOT_SUPPORTED")
+				return
+			}
+		}
+
+		resp, err := h.service.Create(req)
+		if errors.Is(err, payout.ErrIdempotencyConflict) {
+			writeXenditError(w, http.StatusConflict, "DUPLICATE_ERROR", err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("[handlePayouts] callback failed: %v", err)
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodGet:
+		referenceID := r.URL.Query().Get("reference_id")
+		if referenceID == "" {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "reference_id is required")
+			return
+		}
+		writeJSON(w, http.StatusOK, h.service.ListByReference(r.Header.Get("for-user-id"), referenceID))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PayoutHandler) handlePayout(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/v2/payouts/")
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		resp, ok := h.service.Get(r.Header.Get("for-user-id"), segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", payout.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "cancel" && r.Method == http.MethodPost:
+		resp, err := h.service.Cancel(r.Header.Get("for-user-id"), segments[0])
+		switch {
+		case errors.Is(err, payout.ErrNotFound):
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+			return
+		case errors.Is(err, payout.ErrNotCancellable):
+			writeXenditError(w, http.StatusBadRequest, "PAYOUT_NOT_CANCELLABLE", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 1 || len(segments) == 2 && segments[1] == "cancel":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func decodePayoutRequest(r *http.Request) (domain.PayoutRequest, error) {
+	var req domain.PayoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.PayoutRequest{}, fmt.Errorf("invalid json")
+	}
+	req.IdempotencyKey = r.Header.Get("Idempotency-key")
+	switch {
+	case req.IdempotencyKey == "":
+		return req, fmt.Errorf("Idempotency-key header is required")
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.ChannelCode == "":
+		return req, fmt.Errorf("channel_code is required")
+	case req.ChannelProperties.AccountHolderName == "":
+		return req, fmt.Errorf("channel_properties.account_holder_name is required")
+	case req.ChannelProperties.AccountNumber == "":
+		return req, fmt.Errorf("channel_properties.account_number is required")
+	case req.Amount <= 0:
+		return req, fmt.Errorf("amount must be greater than 0")
+	case req.Currency == "":
+		return req, fmt.Errorf("currency is required")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
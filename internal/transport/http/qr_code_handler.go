@@ -0,0 +1,128 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/qrcode"
+)
+
+type QRCodeHandler struct {
+	service *qrcode.Service
+}
+
+func NewQRCodeHandler(service *qrcode.Service) *QRCodeHandler {
+	return &QRCodeHandler{service: service}
+}
+
+func (h *QRCodeHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/qr_codes", loggingHandler("handleCreateQRCode", http.HandlerFunc(h.handleCreateQRCode)))
+	mux.Handle("/xendit/qr_codes/", loggingHandler("handleQRCode", http.HandlerFunc(h.handleQRCode)))
+}
+
+func (h *QRCodeHandler) handleCreateQRCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeQRCodeRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateQRCode] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	resp, cbErr := h.service.Create(req)
+	if cbErr != nil {
+		log.Printf("[handleCreateQRCode] callback failed: %v", cbErr)
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *QRCodeHandler) handleQRCode(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/qr_codes/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", qrcode.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "payments":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		payments, ok := h.service.Payments(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", qrcode.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": payments, "has_more": false})
+	case len(segments) == 3 && segments[1] == "payments" && segments[2] == "simulate":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.simulatePayment(w, r, segments[0])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *QRCodeHandler) simulatePayment(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Amount int `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+		return
+	}
+
+	resp, err := h.service.Pay(id, body.Amount)
+	switch {
+	case errors.Is(err, qrcode.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+		return
+	case errors.Is(err, qrcode.ErrInactive):
+		writeXenditError(w, http.StatusBadRequest, "INACTIVE_QR_CODE", err.Error())
+		return
+	case errors.Is(err, qrcode.ErrAmountRequired):
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	case err != nil:
+		log.Printf("[handleQRCode] callback failed: %v", err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func decodeQRCodeRequest(r *http.Request) (domain.QRCodeRequest, error) {
+	var req domain.QRCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.QRCodeRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.Type != domain.QRCodeDynamic && req.Type != domain.QRCodeStatic:
+		return req, fmt.Errorf("type must be DYNAMIC or STATIC")
+	case req.Type == domain.QRCodeDynamic && req.Amount <= 0:
+		return req, fmt.Errorf("amount is required for DYNAMIC qr codes")
+	case req.Amount < 0:
+		return req, fmt.Errorf("amount must not be negative")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
@@ -0,0 +1,164 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/recurring"
+)
+
+type RecurringHandler struct {
+	service *recurring.Service
+}
+
+func NewRecurringHandler(service *recurring.Service) *RecurringHandler {
+	return &RecurringHandler{service: service}
+}
+
+func (h *RecurringHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/recurring/plans", loggingHandler("handleCreateRecurringPlan", http.HandlerFunc(h.handleCreateRecurringPlan)))
+	mux.Handle("/xendit/recurring/plans/", loggingHandler("handleRecurringPlan", http.HandlerFunc(h.handleRecurringPlan)))
+}
+
+func (h *RecurringHandler) handleCreateRecurringPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeRecurringPlanRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateRecurringPlan] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	resp, err := h.service.Create(req)
+	if writeRecurringError(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *RecurringHandler) handleRecurringPlan(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/recurring/plans/")
+	switch {
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", recurring.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 2 && segments[1] == "deactivate":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp, err := h.service.Deactivate(segments[0])
+		if !writeRecurringError(w, err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case len(segments) == 2 && segments[1] == "cycles":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cycles, err := h.service.Cycles(segments[0])
+		if !writeRecurringError(w, err) {
+			writeJSON(w, http.StatusOK, map[string]any{"data": cycles, "has_more": false})
+		}
+	case len(segments) == 3 && segments[1] == "cycles":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cycle, err := h.service.Cycle(segments[0], segments[2])
+		if !writeRecurringError(w, err) {
+			writeJSON(w, http.StatusOK, cycle)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// writeRecurringError answers service errors with Xendit's codes and reports
+// whether the response was written. Webhook failures are only logged.
+func writeRecurringError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, recurring.ErrNotFound), errors.Is(err, recurring.ErrCycleNotFound),
+		errors.Is(err, recurring.ErrCustomerNotFound), errors.Is(err, recurring.ErrMethodNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+	case errors.Is(err, recurring.ErrMethodNotActive):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_PAYMENT_METHOD", err.Error())
+	case errors.Is(err, recurring.ErrInactive):
+		writeXenditError(w, http.StatusBadRequest, "INVALID_PLAN_STATUS", err.Error())
+	default:
+		log.Printf("[handleRecurringPlan] webhook failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func decodeRecurringPlanRequest(r *http.Request) (domain.RecurringPlanRequest, error) {
+	var req domain.RecurringPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.RecurringPlanRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ReferenceID == "":
+		return req, fmt.Errorf("reference_id is required")
+	case req.CustomerID == "":
+		return req, fmt.Errorf("customer_id is required")
+	case req.RecurringAction != "" && req.RecurringAction != "PAYMENT":
+		return req, fmt.Errorf("recurring_action must be PAYMENT")
+	case req.Currency == "":
+		return req, fmt.Errorf("currency is required")
+	case req.Amount <= 0:
+		return req, fmt.Errorf("amount must be greater than 0")
+	case len(req.PaymentMethods) == 0:
+		return req, fmt.Errorf("payment_methods is required")
+	case req.FailedCycleAction != "" && req.FailedCycleAction != domain.RecurringFailedCycleResume && req.FailedCycleAction != domain.RecurringFailedCycleStop:
+		return req, fmt.Errorf("failed_cycle_action must be RESUME or STOP")
+	}
+	for _, pm := range req.PaymentMethods {
+		if pm.PaymentMethodID == "" {
+			return req, fmt.Errorf("payment_methods.payment_method_id is required")
+		}
+	}
+
+	schedule := req.Schedule
+	switch {
+	case !recurringInterval(schedule.Interval):
+		return req, fmt.Errorf("schedule.interval must be DAY, WEEK or MONTH")
+	case schedule.IntervalCount < 0 || schedule.TotalRecurrence < 0 || schedule.RetryIntervalCount < 0:
+		return req, fmt.Errorf("schedule counts must not be negative")
+	case schedule.RetryInterval != "" && !recurringInterval(schedule.RetryInterval):
+		return req, fmt.Errorf("schedule.retry_interval must be DAY, WEEK or MONTH")
+	case schedule.TotalRetry < 0 || schedule.TotalRetry > 10:
+		return req, fmt.Errorf("schedule.total_retry must be between 0 and 10")
+	}
+	if schedule.AnchorDate != "" {
+		if _, err := time.Parse(time.RFC3339, schedule.AnchorDate); err != nil {
+			return req, fmt.Errorf("schedule.anchor_date must be an RFC 3339 timestamp")
+		}
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
+
+func recurringInterval(interval string) bool {
+	return interval == domain.RecurringIntervalDay || interval == domain.RecurringIntervalWeek || interval == domain.RecurringIntervalMonth
+}
@@ -0,0 +1,125 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/refund"
+)
+
+type RefundHandler struct {
+	service *refund.Service
+}
+
+func NewRefundHandler(service *refund.Service) *RefundHandler {
+	return &RefundHandler{service: service}
+}
+
+func (h *RefundHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/refunds", loggingHandler("handleRefunds", http.HandlerFunc(h.handleRefunds)))
+	mux.Handle("/xendit/refunds/", loggingHandler("handleRefund", http.HandlerFunc(h.handleRefund)))
+}
+
+func (h *RefundHandler) handleRefunds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, err := decodeRefundRequest(r)
+		if err != nil {
+			log.Printf("[handleRefunds] decode failed: %v", err)
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		resp, err := h.service.Create(req)
+		if writeRefundError(w, err) {
+			return
+		}
+		if err != nil {
+			log.Printf("[handleRefunds] webhook failed: %v", err)
+		}
+		writeJSON(w, http.StatusCreated, resp)
+	case http.MethodGet:
+		query := r.URL.Query()
+		filter := refund.Filter{PaymentRequestID: query.Get("payment_request_id"), InvoiceID: query.Get("invoice_id"), EWalletChargeID: query.Get("ewallet_charge_id")}
+		if limit := query.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 {
+				writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "limit must be a positive number")
+				return
+			}
+			filter.Limit = n
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h.service.List(filter), "has_more": false})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *RefundHandler) handleRefund(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/refunds/")
+	if len(segments) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, ok := h.service.Get(segments[0])
+	if !ok {
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", refund.ErrNotFound.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeRefundError answers refund service errors with Xendit's codes and
+// reports whether the response was written. Webhook errors are left to the
+// caller.
+func writeRefundError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, refund.ErrPaymentNotFound):
+		writeXenditError(w, http.StatusNotFound, "DATA_NOT_FOUND", err.Error())
+	case errors.Is(err, refund.ErrIneligible):
+		writeXenditError(w, http.StatusBadRequest, "INELIGIBLE_TRANSACTION", err.Error())
+	case errors.Is(err, refund.ErrAmountExceeded):
+		writeXenditError(w, http.StatusBadRequest, "MAXIMUM_REFUND_AMOUNT_REACHED", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func decodeRefundRequest(r *http.Request) (domain.RefundRequest, error) {
+	var req domain.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.RefundRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case countSet(req.PaymentRequestID, req.InvoiceID, req.EWalletChargeID) != 1:
+		return req, fmt.Errorf("exactly one of payment_request_id, invoice_id and ewallet_charge_id is required")
+	case !domain.RefundReasons[req.Reason]:
+		return req, fmt.Errorf("reason must be one of FRAUDULENT, DUPLICATE, REQUESTED_BY_CUSTOMER, CANCELLATION or OTHERS")
+	case req.Amount < 0:
+		return req, fmt.Errorf("amount must not be negative")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
+
+func countSet(values ...string) int {
+	n := 0
+	for _, value := range values {
+		if value != "" {
+			n++
+		}
+	}
+	return n
+}
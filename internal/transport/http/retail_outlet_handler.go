@@ -0,0 +1,154 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/retailoutlet"
+)
+
+type RetailOutletHandler struct {
+	service *retailoutlet.Service
+}
+
+func NewRetailOutletHandler(service *retailoutlet.Service) *RetailOutletHandler {
+	return &RetailOutletHandler{service: service}
+}
+
+func (h *RetailOutletHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/fixed_payment_code", loggingHandler("handleCreateFixedPaymentCode", http.HandlerFunc(h.handleCreateFixedPaymentCode)))
+	mux.Handle("/xendit/fixed_payment_code/", loggingHandler("handleFixedPaymentCode", http.HandlerFunc(h.handleFixedPaymentCode)))
+}
+
+func (h *RetailOutletHandler) handleCreateFixedPaymentCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeFixedPaymentCodeRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateFixedPaymentCode] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	if _, ok := domain.RetailOutletPrefixes[req.RetailOutletName]; !ok {
+		writeXenditError(w, http.StatusBadRequest, "RETAIL_OUTLET_NOT_SUPPORTED_ERROR", "retail_outlet_name must be ALFAMART or INDOMARET")
+		return
+	}
+
+	resp, err := h.service.Create(req)
+	if h.writeError(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *RetailOutletHandler) handleFixedPaymentCode(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/fixed_payment_code/")
+	switch {
+	case len(segments) == 1 && segments[0] == "simulate_payment":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.simulatePayment(w, r)
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "FIXED_PAYMENT_CODE_NOT_FOUND_ERROR", retailoutlet.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 1 && r.Method == http.MethodPatch:
+		var update domain.FixedPaymentCodeUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		if update.ExpectedAmount != nil && *update.ExpectedAmount <= 0 {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "expected_amount must be greater than 0")
+			return
+		}
+		resp, err := h.service.Update(segments[0], update)
+		if !h.writeError(w, err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case len(segments) == 1:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *RetailOutletHandler) simulatePayment(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RetailOutletName string `json:"retail_outlet_name"`
+		PaymentCode      string `json:"payment_code"`
+		TransferAmount   int    `json:"transfer_amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+		return
+	}
+	if body.RetailOutletName == "" || body.PaymentCode == "" || body.TransferAmount <= 0 {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "retail_outlet_name, payment_code and a positive transfer_amount are required")
+		return
+	}
+
+	_, err := h.service.SimulatePayment(body.RetailOutletName, body.PaymentCode, body.TransferAmount)
+	if h.writeError(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "COMPLETED",
+		"message": fmt.Sprintf("Payment for payment code %s at %s is being processed. A callback is sent upon completion", body.PaymentCode, body.RetailOutletName),
+	})
+}
+
+// writeError answers service errors with Xendit's codes and reports whether
+// the response was written. Callback failures are only logged.
+func (h *RetailOutletHandler) writeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, retailoutlet.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "FIXED_PAYMENT_CODE_NOT_FOUND_ERROR", err.Error())
+	case errors.Is(err, retailoutlet.ErrInactive):
+		writeXenditError(w, http.StatusBadRequest, "INACTIVE_FIXED_PAYMENT_CODE_ERROR", err.Error())
+	case errors.Is(err, retailoutlet.ErrBelowPaid):
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+	case errors.Is(err, retailoutlet.ErrAmountExceeded):
+		writeXenditError(w, http.StatusBadRequest, "PAYMENT_AMOUNT_EXCEEDED_ERROR", err.Error())
+	case errors.Is(err, retailoutlet.ErrDuplicateCode):
+		writeXenditError(w, http.StatusBadRequest, "DUPLICATE_PAYMENT_CODE_ERROR", err.Error())
+	default:
+		log.Printf("[handleFixedPaymentCode] callback failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func decodeFixedPaymentCodeRequest(r *http.Request) (domain.FixedPaymentCodeRequest, error) {
+	var req domain.FixedPaymentCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.FixedPaymentCodeRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ExternalID == "":
+		return req, fmt.Errorf("external_id is required")
+	case req.RetailOutletName == "":
+		return req, fmt.Errorf("retail_outlet_name is required")
+	case req.Name == "":
+		return req, fmt.Errorf("name is required")
+	case req.ExpectedAmount <= 0:
+		return req, fmt.Errorf("expected_amount must be greater than 0")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
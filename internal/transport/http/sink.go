@@ -0,0 +1,80 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"xendit-api-mock/internal/sink"
+)
+
+type SinkHandler struct {
+	store *sink.Store
+}
+
+func NewSinkHandler(store *sink.Store) *SinkHandler {
+	return &SinkHandler{store: store}
+}
+
+func (h *SinkHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/admin/sink/", loggingHandler("handleSink", http.HandlerFunc(h.handleSink)))
+}
+
+func (h *SinkHandler) handleSink(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/admin/sink/")
+	switch {
+	case len(segments) == 1:
+		h.handleCaptures(w, r, segments[0])
+	case len(segments) == 2 && segments[1] == "config":
+		h.handleConfig(w, r, segments[0])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *SinkHandler) handleCaptures(w http.ResponseWriter, r *http.Request, name string) {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status, latency := h.store.Receive(name, r.Method, r.Header, body)
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+		writeJSON(w, status, map[string]string{"status": "received"})
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"data": h.store.Captures(name)})
+	case http.MethodDelete:
+		h.store.Clear(name)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "cleared"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *SinkHandler) handleConfig(w http.ResponseWriter, r *http.Request, name string) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.store.Config(name))
+	case http.MethodPut:
+		var cfg sink.Config
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		for _, code := range cfg.StatusCodes {
+			if code < 100 || code > 599 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status_codes must be between 100 and 599"})
+				return
+			}
+		}
+		h.store.Configure(name, cfg)
+		writeJSON(w, http.StatusOK, cfg)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
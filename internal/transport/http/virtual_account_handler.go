@@ -0,0 +1,141 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/service/virtualaccount"
+)
+
+type VirtualAccountHandler struct {
+	service *virtualaccount.Service
+}
+
+func NewVirtualAccountHandler(service *virtualaccount.Service) *VirtualAccountHandler {
+	return &VirtualAccountHandler{service: service}
+}
+
+func (h *VirtualAccountHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/xendit/callback_virtual_accounts", loggingHandler("handleCreateVirtualAccount", http.HandlerFunc(h.handleCreateVirtualAccount)))
+	mux.Handle("/xendit/callback_virtual_accounts/", loggingHandler("handleVirtualAccount", http.HandlerFunc(h.handleVirtualAccount)))
+}
+
+func (h *VirtualAccountHandler) handleCreateVirtualAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := decodeVirtualAccountRequest(r)
+	if err != nil {
+		log.Printf("[handleCreateVirtualAccount] decode failed: %v", err)
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+	if _, ok := domain.VirtualAccountMerchantCodes[req.BankCode]; !ok {
+		writeXenditError(w, http.StatusBadRequest, "BANK_NOT_SUPPORTED_ERROR", "bank_code is not supported for virtual accounts")
+		return
+	}
+
+	resp, cbErr := h.service.Create(req)
+	if cbErr != nil {
+		log.Printf("[handleCreateVirtualAccount] callback failed: %v", cbErr)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *VirtualAccountHandler) handleVirtualAccount(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/xendit/callback_virtual_accounts/")
+	switch {
+	case len(segments) == 2 && strings.HasPrefix(segments[0], "external_id=") && segments[1] == "simulate_payment":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.simulatePayment(w, r, strings.TrimPrefix(segments[0], "external_id="))
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		resp, ok := h.service.Get(segments[0])
+		if !ok {
+			writeXenditError(w, http.StatusNotFound, "CALLBACK_VIRTUAL_ACCOUNT_NOT_FOUND_ERROR", virtualaccount.ErrNotFound.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case len(segments) == 1 && r.Method == http.MethodPatch:
+		var update domain.VirtualAccountUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "invalid json")
+			return
+		}
+		resp, err := h.service.Update(segments[0], update)
+		if !h.writeError(w, err) {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case len(segments) == 1:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *VirtualAccountHandler) simulatePayment(w http.ResponseWriter, r *http.Request, externalID string) {
+	var body struct {
+		Amount int `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Amount <= 0 {
+		writeXenditError(w, http.StatusBadRequest, "API_VALIDATION_ERROR", "amount must be greater than 0")
+		return
+	}
+
+	_, err := h.service.SimulatePayment(externalID, body.Amount)
+	if h.writeError(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "COMPLETED",
+		"message": fmt.Sprintf("Payment for the Fixed VA with external id %s is currently being processed. Please ensure that you have set a callback URL for VA payments via Xendit Dashboard and we will send you a callback upon transaction completion", externalID),
+	})
+}
+
+// writeError answers service errors with Xendit's codes and reports whether
+// the response was written. Callback failures are only logged.
+func (h *VirtualAccountHandler) writeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, virtualaccount.ErrNotFound):
+		writeXenditError(w, http.StatusNotFound, "CALLBACK_VIRTUAL_ACCOUNT_NOT_FOUND_ERROR", err.Error())
+	case errors.Is(err, virtualaccount.ErrInactive):
+		writeXenditError(w, http.StatusBadRequest, "INACTIVE_VIRTUAL_ACCOUNT_ERROR", err.Error())
+	case errors.Is(err, virtualaccount.ErrAmountMismatch):
+		writeXenditError(w, http.StatusBadRequest, "EXPECTED_AMOUNT_MISMATCH_ERROR", err.Error())
+	default:
+		log.Printf("[handleVirtualAccount] callback failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func decodeVirtualAccountRequest(r *http.Request) (domain.VirtualAccountRequest, error) {
+	var req domain.VirtualAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return domain.VirtualAccountRequest{}, fmt.Errorf("invalid json")
+	}
+	switch {
+	case req.ExternalID == "":
+		return req, fmt.Errorf("external_id is required")
+	case req.BankCode == "":
+		return req, fmt.Errorf("bank_code is required")
+	case req.Name == "":
+		return req, fmt.Errorf("name is required")
+	case req.IsClosed && req.ExpectedAmount <= 0:
+		return req, fmt.Errorf("expected_amount is required when is_closed is true")
+	}
+	req.ForUserID = r.Header.Get("for-user-id")
+	req.Session = r.Header.Get(sessionHeader)
+	return req, nil
+}
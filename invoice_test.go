@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/invoice"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newInvoiceMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]domain.InvoiceCallbackPayload) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.InvoiceCallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := invoice.NewService(scenario.NewEngine(cfg), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewInvoiceHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestInvoiceSimulatedPayment(t *testing.T) {
+	var received []domain.InvoiceCallbackPayload
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newInvoiceMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/v2/invoices", `{"external_id":"order-1","amount":50000,"payer_email":"a@example.com"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+	var created domain.Invoice
+	_ = json.Unmarshal(body, &created)
+	if created.Status != domain.InvoiceStatusPending || created.InvoiceURL == "" || created.ExpiryDate == "" {
+		t.Fatalf("unexpected invoice %+v", created)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPost, "/xendit/admin/invoices/"+created.ID+"/pay", `{"payment_method":"EWALLET","payment_channel":"OVO"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 paying invoice, got %d: %s", code, body)
+	}
+	if len(received) != 1 || received[0].Status != domain.InvoiceStatusPaid || received[0].PaymentChannel != "OVO" || received[0].PaidAmount != 50000 {
+		t.Fatalf("expected PAID callback via OVO, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountHolding); got != 50000 {
+		t.Fatalf("expected paid amount in HOLDING, got %d", got)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/invoices/"+created.ID+"/expire!", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 expiring a PAID invoice, got %d", code)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/v2/invoices?statuses=PAID&external_id=order-1", "")
+	var listed []domain.Invoice
+	_ = json.Unmarshal(body, &listed)
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Fatalf("expected the paid invoice in the list, got %s", body)
+	}
+}
+
+func TestInvoiceExpireAndScenarioRules(t *testing.T) {
+	var received []domain.InvoiceCallbackPayload
+	mux := newInvoiceMux(t, &scenario.Config{
+		Invoices: []scenario.InvoiceRule{
+			{ExternalID: "auto-pay", Outcome: scenario.InvoicePay, PaymentMethod: "QR_CODE", PaymentChannel: "QRIS"},
+			{ExternalID: "auto-expire", Outcome: scenario.InvoiceExpire},
+		},
+	}, nil, &received)
+
+	doRequest(t, mux, http.MethodPost, "/xendit/v2/invoices", `{"external_id":"auto-pay","amount":1000}`)
+	doRequest(t, mux, http.MethodPost, "/xendit/v2/invoices", `{"external_id":"auto-expire","amount":1000}`)
+	if len(received) != 2 || received[0].Status != domain.InvoiceStatusPaid || received[0].PaymentChannel != "QRIS" || received[1].Status != domain.InvoiceStatusExpired {
+		t.Fatalf("expected scripted PAID and EXPIRED callbacks, got %+v", received)
+	}
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/v2/invoices", `{"external_id":"manual","amount":1000}`)
+	var created domain.Invoice
+	_ = json.Unmarshal(body, &created)
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/invoices/"+created.ID+"/expire!", "")
+	var expired domain.Invoice
+	_ = json.Unmarshal(body, &expired)
+	if code != http.StatusOK || expired.Status != domain.InvoiceStatusExpired {
+		t.Fatalf("expected EXPIRED, got %d %s", code, body)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodGet, "/xendit/v2/invoices/inv_missing", ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown invoice, got %d", code)
+	}
+}
@@ -1,12 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/bank"
 	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/clock"
 	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/batch"
+	"xendit-api-mock/internal/service/card"
+	"xendit-api-mock/internal/service/customer"
 	"xendit-api-mock/internal/service/disbursement"
+	"xendit-api-mock/internal/service/ewallet"
+	"xendit-api-mock/internal/service/invoice"
+	"xendit-api-mock/internal/service/namevalidation"
+	"xendit-api-mock/internal/service/paymentrequest"
+	"xendit-api-mock/internal/service/payout"
+	"xendit-api-mock/internal/service/qrcode"
+	"xendit-api-mock/internal/service/recurring"
+	"xendit-api-mock/internal/service/refund"
+	"xendit-api-mock/internal/service/retailoutlet"
+	"xendit-api-mock/internal/service/virtualaccount"
+	"xendit-api-mock/internal/sink"
+	"xendit-api-mock/internal/snapshot"
 	httptransport "xendit-api-mock/internal/transport/http"
 )
 
@@ -20,15 +43,146 @@ func main() {
 	engine.WithRandomStatus(randomStatus)
 	callbackURL := getenv("CALLBACK_URL", "")
 	callbackToken := getenv("CALLBACK_TOKEN", "")
-	callbackClient := callback.NewClient(callbackURL, callbackToken, nil)
+	callbackClient := callback.NewClient(callbackURL, callbackToken, nil).WithRetryPolicy(loadRetryPolicy())
+	for _, route := range loadCallbackRoutes(getenv("CALLBACK_ROUTES_FILE", "")) {
+		callbackClient.Router().Set(route)
+	}
+	var callbackSender callback.Sender = callbackClient
+	var dispatcher *callback.Dispatcher
+	if workers := getenvInt("CALLBACK_WORKERS", 4); workers > 0 {
+		dispatcher = callback.NewDispatcher(callbackClient, callback.DispatcherConfig{
+			Workers:   workers,
+			QueueSize: getenvInt("CALLBACK_QUEUE_SIZE", 100),
+			Delay:     getenvMillis("CALLBACK_DELAY_MS", 0),
+		})
+		callbackSender = dispatcher
+	}
 	userID := getenv("XENDIT_USER_ID", "user_mock")
-	service := disbursement.NewService(engine, callbackClient, userID)
-	handler := httptransport.NewHandler(service, callbackURL)
+	ledger := balance.NewLedger(loadBalanceConfig(getenv("BALANCE_FILE", "")))
+	balanceHandler := httptransport.NewBalanceHandler(ledger, userID)
+	service := disbursement.NewService(engine, callbackSender, userID).WithLedger(ledger)
+	banks := bank.NewCatalogue(loadBanks(getenv("BANKS_FILE", "")))
+	bankHandler := httptransport.NewBankHandler(banks)
+	handler := httptransport.NewHandler(service, callbackClient).WithBanks(banks)
+	batchService := batch.NewService(engine, callbackSender, userID).
+		WithAutoApprove(getenv("BATCH_AUTO_APPROVE", "true") == "true").
+		WithProcessingDelay(getenvMillis("BATCH_PROCESSING_DELAY_MS", 0)).
+		WithLedger(ledger)
+	batchHandler := httptransport.NewBatchHandler(batchService).WithBanks(banks)
+	payoutService := payout.NewService(engine, callbackSender, userID).
+		WithProcessingDelay(getenvMillis("PAYOUT_PROCESSING_DELAY_MS", 0)).
+		WithLedger(ledger)
+	payoutHandler := httptransport.NewPayoutHandler(payoutService).WithBanks(banks)
+	nameValidationService := namevalidation.NewService(engine, callbackSender, userID)
+	nameValidationHandler := httptransport.NewNameValidationHandler(nameValidationService).WithBanks(banks)
+	invoiceService := invoice.NewService(engine, callbackSender, userID).
+		WithMerchantName(getenv("MERCHANT_NAME", "Xendit Mock")).
+		WithLedger(ledger)
+	invoiceHandler := httptransport.NewInvoiceHandler(invoiceService)
+	virtualAccountService := virtualaccount.NewService(engine, callbackSender, userID).WithLedger(ledger)
+	virtualAccountHandler := httptransport.NewVirtualAccountHandler(virtualAccountService)
+	publicURL := getenv("PUBLIC_URL", "http://localhost:"+addr)
+	customerService := customer.NewService(userID)
+	customerHandler := httptransport.NewCustomerHandler(customerService)
+	ewalletService := ewallet.NewService(engine, callbackSender, userID).
+		WithBaseURL(publicURL).
+		WithLedger(ledger).
+		WithCustomers(customerService)
+	qrCodeService := qrcode.NewService(engine, callbackSender, userID).
+		WithMerchantName(getenv("MERCHANT_NAME", "Xendit Mock")).
+		WithLedger(ledger)
+	qrCodeHandler := httptransport.NewQRCodeHandler(qrCodeService)
+	retailOutletService := retailoutlet.NewService(engine, callbackSender, userID).WithLedger(ledger)
+	retailOutletHandler := httptransport.NewRetailOutletHandler(retailOutletService)
+	paymentRequestService := paymentrequest.NewService(engine, callbackSender, userID).
+		WithBaseURL(publicURL).
+		WithLedger(ledger).
+		WithCustomers(customerService)
+	paymentRequestHandler := httptransport.NewPaymentRequestHandler(paymentRequestService)
+	refundService := refund.NewService(engine, callbackSender, userID).
+		WithInvoices(invoiceService).
+		WithPaymentRequests(paymentRequestService).
+		WithEWallets(ewalletService).
+		WithLedger(ledger)
+	refundHandler := httptransport.NewRefundHandler(refundService)
+	ewalletHandler := httptransport.NewEWalletHandler(ewalletService).WithRefunds(refundService)
+	cardService := card.NewService(callbackSender, userID).
+		WithBaseURL(publicURL).
+		WithLedger(ledger)
+	cardHandler := httptransport.NewCardHandler(cardService)
+	virtualClock := clock.New()
+	recurringService := recurring.NewService(engine, callbackSender, virtualClock, userID).
+		WithCustomers(customerService).
+		WithPaymentRequests(paymentRequestService).
+		WithLedger(ledger)
+	recurringHandler := httptransport.NewRecurringHandler(recurringService)
+
+	snapshots := snapshot.NewRegistry()
+	snapshots.Register("clock", virtualClock)
+	snapshots.Register("disbursement", service)
+	snapshots.Register("batch_disbursement", batchService)
+	snapshots.Register("payout", payoutService)
+	snapshots.Register("balance", ledger)
+	snapshots.Register("name_validation", nameValidationService)
+	snapshots.Register("invoice", invoiceService)
+	snapshots.Register("virtual_account", virtualAccountService)
+	snapshots.Register("customer", customerService)
+	snapshots.Register("ewallet", ewalletService)
+	snapshots.Register("qr_code", qrCodeService)
+	snapshots.Register("retail_outlet", retailOutletService)
+	snapshots.Register("payment_request", paymentRequestService)
+	snapshots.Register("refund", refundService)
+	snapshots.Register("card", cardService)
+	snapshots.Register("recurring", recurringService)
+	snapshots.Register("callbacks", callbackClient.History())
+	snapshots.Register("callback_routes", callbackClient.Router())
+	handler.WithReset(snapshots.Reset)
+	adminHandler := httptransport.NewAdminHandler(snapshots, callbackClient, userID).WithClock(virtualClock)
+	sinkStore := sink.NewStore()
+	snapshots.Register("sink", sinkStore)
+	sinkHandler := httptransport.NewSinkHandler(sinkStore)
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
+	batchHandler.RegisterRoutes(mux)
+	payoutHandler.RegisterRoutes(mux)
+	balanceHandler.RegisterRoutes(mux)
+	bankHandler.RegisterRoutes(mux)
+	nameValidationHandler.RegisterRoutes(mux)
+	invoiceHandler.RegisterRoutes(mux)
+	virtualAccountHandler.RegisterRoutes(mux)
+	customerHandler.RegisterRoutes(mux)
+	ewalletHandler.RegisterRoutes(mux)
+	qrCodeHandler.RegisterRoutes(mux)
+	retailOutletHandler.RegisterRoutes(mux)
+	paymentRequestHandler.RegisterRoutes(mux)
+	refundHandler.RegisterRoutes(mux)
+	recurringHandler.RegisterRoutes(mux)
+	cardHandler.RegisterRoutes(mux)
+	adminHandler.RegisterRoutes(mux)
+	sinkHandler.RegisterRoutes(mux)
+
+	server := &http.Server{Addr: ":" + addr, Handler: mux}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	if err := http.ListenAndServe(":"+addr, mux); err != nil {
-		log.Fatal(err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("[main] shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), getenvMillis("SHUTDOWN_TIMEOUT_MS", 30*time.Second))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[main] server shutdown failed: %v", err)
+	}
+	if dispatcher != nil {
+		if err := dispatcher.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[main] callback dispatcher drain failed: %v", err)
+		}
 	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/bank"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/namevalidation"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func validateName(t *testing.T, mux *http.ServeMux, body string) (int, domain.NameValidation) {
+	t.Helper()
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/xendit/bank_account_data_requests", strings.NewReader(body)))
+	var validation domain.NameValidation
+	_ = json.Unmarshal(resp.Body.Bytes(), &validation)
+	return resp.Code, validation
+}
+
+func TestNameValidation(t *testing.T) {
+	var received []domain.NameValidation
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.NameValidation
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+	}))
+	defer callbackSrv.Close()
+
+	engine := scenario.NewEngine(&scenario.Config{
+		NameValidations: []scenario.NameValidation{
+			{BankCode: "BCA", AccountNumber: "111", HolderName: "BUDI KNOWN"},
+			{AccountNumber: "222", Outcome: "fail"},
+			{AccountNumber: "333", HolderName: "SLOW BANK", Pending: true},
+		},
+	})
+	service := namevalidation.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock")
+	mux := http.NewServeMux()
+	httptransport.NewNameValidationHandler(service).WithBanks(bank.NewCatalogue(bank.DefaultBanks("ID"))).RegisterRoutes(mux)
+
+	if _, v := validateName(t, mux, `{"bank_code":"BCA","bank_account_number":"111"}`); v.Status != domain.NameValidationSuccess || v.BankAccountHolderName != "BUDI KNOWN" {
+		t.Fatalf("expected directory name, got %+v", v)
+	}
+	_, first := validateName(t, mux, `{"bank_code":"BNI","bank_account_number":"987654"}`)
+	_, second := validateName(t, mux, `{"bank_code":"BNI","bank_account_number":"987654"}`)
+	if first.BankAccountHolderName == "" || first.BankAccountHolderName != second.BankAccountHolderName {
+		t.Fatalf("expected a stable generated name, got %q and %q", first.BankAccountHolderName, second.BankAccountHolderName)
+	}
+	if _, v := validateName(t, mux, `{"bank_code":"BRI","bank_account_number":"222"}`); v.Status != domain.NameValidationFailed || v.FailureReason != domain.FailureInvalidDestination {
+		t.Fatalf("expected INVALID_DESTINATION, got %+v", v)
+	}
+	if len(received) != 0 {
+		t.Fatalf("expected no callbacks for synchronous results, got %d", len(received))
+	}
+
+	_, pending := validateName(t, mux, `{"bank_code":"BCA","bank_account_number":"333"}`)
+	if pending.Status != domain.NameValidationPending {
+		t.Fatalf("expected PENDING, got %+v", pending)
+	}
+	if len(received) != 1 || received[0].ID != pending.ID || received[0].BankAccountHolderName != "SLOW BANK" {
+		t.Fatalf("expected the result by callback, got %+v", received)
+	}
+	getResp := httptest.NewRecorder()
+	mux.ServeHTTP(getResp, httptest.NewRequest(http.MethodGet, "/xendit/bank_account_data_requests/"+pending.ID, nil))
+	var stored domain.NameValidation
+	_ = json.Unmarshal(getResp.Body.Bytes(), &stored)
+	if stored.Status != domain.NameValidationSuccess {
+		t.Fatalf("expected stored result SUCCESS, got %+v", stored)
+	}
+
+	if code, _ := validateName(t, mux, `{"bank_code":"OVO","bank_account_number":"111"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bank without name validation, got %d", code)
+	}
+}
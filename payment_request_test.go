@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/paymentrequest"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+type paymentWebhook struct {
+	Event string `json:"event"`
+	Data  struct {
+		ID               string `json:"id"`
+		PaymentRequestID string `json:"payment_request_id"`
+		Status           string `json:"status"`
+		FailureCode      string `json:"failure_code"`
+	} `json:"data"`
+}
+
+func newPaymentRequestMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]paymentWebhook) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload paymentWebhook
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := paymentrequest.NewService(scenario.NewEngine(cfg), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").
+		WithBaseURL("http://mock.test").
+		WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewPaymentRequestHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestPaymentRequestAuthentication(t *testing.T) {
+	var received []paymentWebhook
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newPaymentRequestMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_requests", `{"reference_id":"order-1","amount":45000,"currency":"IDR","payment_method":{"type":"EWALLET","reusability":"ONE_TIME_USE","ewallet":{"channel_code":"DANA","channel_properties":{"success_return_url":"https://shop.test/done"}}}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", code, body)
+	}
+	var pr domain.PaymentRequest
+	_ = json.Unmarshal(body, &pr)
+	if pr.Status != domain.PaymentRequestRequiresAction || len(pr.Actions) == 0 || pr.Actions[0].URL != "http://mock.test"+domain.PaymentActionPath+pr.ID {
+		t.Fatalf("expected REQUIRES_ACTION with an auth action, got %s", body)
+	}
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, domain.PaymentActionPath+pr.ID+"/approve", nil))
+	if resp.Code != http.StatusSeeOther || resp.Header().Get("Location") != "https://shop.test/done" {
+		t.Fatalf("expected redirect to the success return URL, got %d %s", resp.Code, resp.Header().Get("Location"))
+	}
+	if len(received) != 1 || received[0].Event != domain.PaymentEventSucceeded || received[0].Data.PaymentRequestID != pr.ID {
+		t.Fatalf("expected payment.succeeded webhook, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 45000 {
+		t.Fatalf("expected payment credited to CASH, got %d", got)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/payment_requests/"+pr.ID+"/cancel", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 cancelling a paid request, got %d", code)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/payment_requests?reference_id=order-1", "")
+	var listed struct {
+		Data []domain.PaymentRequest `json:"data"`
+	}
+	_ = json.Unmarshal(body, &listed)
+	if len(listed.Data) != 1 || listed.Data[0].Status != domain.PaymentRequestSucceeded {
+		t.Fatalf("expected the succeeded request in the list, got %s", body)
+	}
+}
+
+func TestPaymentMethodLinkingAndScenarioRules(t *testing.T) {
+	var received []paymentWebhook
+	mux := newPaymentRequestMux(t, &scenario.Config{
+		PaymentRequests: []scenario.PaymentRequestRule{
+			{ChannelCode: "OVO", Outcome: scenario.PaymentRequestFail, FailureCode: "INSUFFICIENT_BALANCE"},
+		},
+	}, nil, &received)
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_methods", `{"type":"EWALLET","reusability":"MULTIPLE_USE","customer_id":"cust-1","ewallet":{"channel_code":"OVO","channel_properties":{"mobile_number":"+628123456789"}}}`)
+	var pm domain.PaymentMethod
+	_ = json.Unmarshal(body, &pm)
+	if pm.Status != domain.PaymentMethodRequiresAction || len(pm.Actions) == 0 {
+		t.Fatalf("expected a method awaiting linking, got %s", body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/payment_requests", `{"reference_id":"early","amount":1000,"currency":"IDR","payment_method_id":"`+pm.ID+`"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 paying with an unlinked method, got %d", code)
+	}
+
+	doRequest(t, mux, http.MethodPost, domain.PaymentActionPath+pm.ID+"/approve", "")
+	if len(received) != 1 || received[0].Event != domain.PaymentMethodEventActivated || received[0].Data.ID != pm.ID {
+		t.Fatalf("expected payment_method.activated webhook, got %+v", received)
+	}
+
+	doRequest(t, mux, http.MethodPost, "/xendit/payment_requests", `{"reference_id":"ovo-1","amount":1000,"currency":"IDR","payment_method_id":"`+pm.ID+`"}`)
+	if len(received) != 2 || received[1].Event != domain.PaymentEventFailed || received[1].Data.FailureCode != "INSUFFICIENT_BALANCE" {
+		t.Fatalf("expected scripted payment.failed webhook, got %+v", received)
+	}
+
+	_, body = doRequest(t, mux, http.MethodPost, "/xendit/payment_requests", `{"reference_id":"qr-1","amount":1000,"currency":"IDR","payment_method":{"type":"QR_CODE","qr_code":{"channel_code":"QRIS"}}}`)
+	var pr domain.PaymentRequest
+	_ = json.Unmarshal(body, &pr)
+	if pr.Status != domain.PaymentRequestPending || len(pr.Actions) != 0 {
+		t.Fatalf("expected a PENDING request without actions, got %s", body)
+	}
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/payment_requests/"+pr.ID+"/simulate", "")
+	_ = json.Unmarshal(body, &pr)
+	if code != http.StatusOK || pr.Status != domain.PaymentRequestSucceeded {
+		t.Fatalf("expected simulated payment to succeed, got %d %s", code, body)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/payment_requests", `{"reference_id":"x","amount":1000,"currency":"IDR","payment_method_id":"pm-missing"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown payment method, got %d", code)
+	}
+}
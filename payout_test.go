@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/payout"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+const payoutRequestBody = `{"reference_id":"ref-1","channel_code":"ID_BCA","channel_properties":{"account_holder_name":"A","account_number":"acct-1"},"amount":90000,"currency":"IDR"}`
+
+type payoutReceiver struct {
+	mu       sync.Mutex
+	webhooks []domain.PayoutWebhook
+}
+
+func (p *payoutReceiver) received() []domain.PayoutWebhook {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.PayoutWebhook(nil), p.webhooks...)
+}
+
+func newPayoutMux(t *testing.T, delay time.Duration, receiver *payoutReceiver) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var webhook domain.PayoutWebhook
+		_ = json.NewDecoder(r.Body).Decode(&webhook)
+		receiver.mu.Lock()
+		receiver.webhooks = append(receiver.webhooks, webhook)
+		receiver.mu.Unlock()
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	engine := scenario.NewEngine(&scenario.Config{
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "acct-2", Disbursements: []scenario.Rule{{Outcome: "fail_until_timeout"}}},
+			{AccountNumber: "acct-rev", Disbursements: []scenario.Rule{{Outcome: "success_then_reverse", ReverseAfterMS: 10}}},
+		},
+	})
+	service := payout.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithProcessingDelay(delay)
+	mux := http.NewServeMux()
+	httptransport.NewPayoutHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func createPayout(t *testing.T, mux *http.ServeMux, key, body string) (int, []byte) {
+	t.Helper()
+	header := http.Header{}
+	if key != "" {
+		header.Set("Idempotency-key", key)
+	}
+	return doRequestWithHeader(t, mux, http.MethodPost, "/xendit/v2/payouts", body, header)
+}
+
+func TestPayoutWebhooksFollowScenarioRules(t *testing.T) {
+	receiver := &payoutReceiver{}
+	mux := newPayoutMux(t, 0, receiver)
+
+	code, body := createPayout(t, mux, "key-1", payoutRequestBody)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+	var created domain.Payout
+	_ = json.Unmarshal(body, &created)
+	if created.Status != domain.PayoutStatusAccepted || created.BusinessID != "user_mock" {
+		t.Fatalf("unexpected create response %+v", created)
+	}
+
+	failBody := strings.NewReplacer("ref-1", "ref-fail", "acct-1", "acct-2").Replace(payoutRequestBody)
+	if code, body := createPayout(t, mux, "key-2", failBody); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+
+	webhooks := receiver.received()
+	if len(webhooks) != 2 {
+		t.Fatalf("expected two webhooks, got %d", len(webhooks))
+	}
+	if webhooks[0].Event != domain.PayoutEventSucceeded || webhooks[0].Data.Status != domain.PayoutStatusSucceeded {
+		t.Fatalf("expected payout.succeeded, got %+v", webhooks[0])
+	}
+	if webhooks[1].Event != domain.PayoutEventFailed || webhooks[1].Data.FailureCode == "" {
+		t.Fatalf("expected payout.failed with failure code, got %+v", webhooks[1])
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/v2/payouts/"+created.ID, "")
+	var fetched domain.Payout
+	_ = json.Unmarshal(body, &fetched)
+	if fetched.Status != domain.PayoutStatusSucceeded {
+		t.Fatalf("expected stored payout SUCCEEDED, got %s", fetched.Status)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/v2/payouts?reference_id=ref-fail", "")
+	var listed []domain.Payout
+	_ = json.Unmarshal(body, &listed)
+	if len(listed) != 1 || listed[0].Status != domain.PayoutStatusFailed {
+		t.Fatalf("expected one failed payout by reference, got %+v", listed)
+	}
+}
+
+func TestPayoutIdempotencyKey(t *testing.T) {
+	receiver := &payoutReceiver{}
+	mux := newPayoutMux(t, 0, receiver)
+
+	if code, _ := createPayout(t, mux, "", payoutRequestBody); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without Idempotency-key, got %d", code)
+	}
+
+	_, first := createPayout(t, mux, "key-1", payoutRequestBody)
+	code, replay := createPayout(t, mux, "key-1", payoutRequestBody)
+	var a, b domain.Payout
+	_ = json.Unmarshal(first, &a)
+	_ = json.Unmarshal(replay, &b)
+	if code != http.StatusOK || a.ID != b.ID {
+		t.Fatalf("expected replay to return the same payout, got %d %s vs %s", code, a.ID, b.ID)
+	}
+	if len(receiver.received()) != 1 {
+		t.Fatalf("expected replay not to send another webhook, got %d", len(receiver.received()))
+	}
+
+	changed := strings.Replace(payoutRequestBody, "90000", "1000", 1)
+	if code, body := createPayout(t, mux, "key-1", changed); code != http.StatusConflict || !strings.Contains(string(body), "DUPLICATE_ERROR") {
+		t.Fatalf("expected 409 DUPLICATE_ERROR, got %d %s", code, body)
+	}
+
+	header := http.Header{"Idempotency-Key": {"key-1"}, "For-User-Id": {"sub-account-1"}}
+	code, other := doRequestWithHeader(t, mux, http.MethodPost, "/xendit/v2/payouts", changed, header)
+	var c domain.Payout
+	_ = json.Unmarshal(other, &c)
+	if code != http.StatusOK || c.ID == a.ID || c.BusinessID != "sub-account-1" {
+		t.Fatalf("expected another business's key to create its own payout, got %d %s", code, other)
+	}
+
+	if code, body := doRequest(t, mux, http.MethodGet, "/xendit/v2/payouts/"+c.ID, ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 getting another business's payout, got %d %s", code, body)
+	}
+	_, body := doRequest(t, mux, http.MethodGet, "/xendit/v2/payouts?reference_id=ref-1", "")
+	var listed []domain.Payout
+	_ = json.Unmarshal(body, &listed)
+	if len(listed) != 1 || listed[0].ID != a.ID {
+		t.Fatalf("expected only the main account's payout listed, got %s", body)
+	}
+	if code, body := doRequestWithHeader(t, mux, http.MethodGet, "/xendit/v2/payouts/"+c.ID, "", header); code != http.StatusOK {
+		t.Fatalf("expected the sub-account to get its payout, got %d %s", code, body)
+	}
+}
+
+func TestPayoutCancelWhileAccepted(t *testing.T) {
+	receiver := &payoutReceiver{}
+	mux := newPayoutMux(t, 50*time.Millisecond, receiver)
+
+	var created domain.Payout
+	_, body := createPayout(t, mux, "key-1", payoutRequestBody)
+	_ = json.Unmarshal(body, &created)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/v2/payouts/"+created.ID+"/cancel", "")
+	var cancelled domain.Payout
+	_ = json.Unmarshal(body, &cancelled)
+	if code != http.StatusOK || cancelled.Status != domain.PayoutStatusCancelled {
+		t.Fatalf("expected CANCELLED, got %d %+v", code, cancelled)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if len(receiver.received()) != 0 {
+		t.Fatalf("expected no webhook for a cancelled payout, got %d", len(receiver.received()))
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/v2/payouts/"+created.ID+"/cancel", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 cancelling a CANCELLED payout, got %d", code)
+	}
+}
+
+func TestPayoutReversedAfterSuccess(t *testing.T) {
+	receiver := &payoutReceiver{}
+	mux := newPayoutMux(t, 0, receiver)
+
+	request := strings.NewReplacer("ref-1", "ref-rev", "acct-1", "acct-rev").Replace(payoutRequestBody)
+	var created domain.Payout
+	_, body := createPayout(t, mux, "key-1", request)
+	_ = json.Unmarshal(body, &created)
+
+	deadline := time.Now().Add(time.Second)
+	for len(receiver.received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	webhooks := receiver.received()
+	if len(webhooks) != 2 || webhooks[0].Event != domain.PayoutEventSucceeded || webhooks[1].Event != domain.PayoutEventReversed {
+		t.Fatalf("expected payout.succeeded then payout.reversed, got %+v", webhooks)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/v2/payouts/"+created.ID, "")
+	var fetched domain.Payout
+	_ = json.Unmarshal(body, &fetched)
+	if fetched.Status != domain.PayoutStatusReversed {
+		t.Fatalf("expected stored payout REVERSED, got %s", fetched.Status)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/qrcode"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newQRCodeMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]domain.QRPaymentWebhook) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.QRPaymentWebhook
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := qrcode.NewService(scenario.NewEngine(cfg), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewQRCodeHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestQRCodeDynamicPayment(t *testing.T) {
+	var received []domain.QRPaymentWebhook
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newQRCodeMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/qr_codes", `{"reference_id":"order-1","type":"DYNAMIC","currency":"IDR","amount":15000}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", code, body)
+	}
+	var qr domain.QRCode
+	_ = json.Unmarshal(body, &qr)
+	if qr.Status != domain.QRCodeActive || qr.ExpiresAt == "" || !strings.HasPrefix(qr.QRString, "000201010212") || !strings.Contains(qr.QRString, "540515000") {
+		t.Fatalf("unexpected qr code %s", body)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPost, "/xendit/qr_codes/"+qr.ID+"/payments/simulate", "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 simulating payment, got %d: %s", code, body)
+	}
+	if len(received) != 1 || received[0].Event != domain.QRCodeEventPayment || received[0].Data.Amount != 15000 || received[0].Data.QRID != qr.ID {
+		t.Fatalf("expected qr.payment callback, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 15000 {
+		t.Fatalf("expected payment credited to CASH, got %d", got)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/qr_codes/"+qr.ID+"/payments/simulate", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 paying a used dynamic QR code, got %d", code)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/qr_codes", `{"reference_id":"order-2","type":"DYNAMIC","currency":"IDR"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for dynamic QR code without amount, got %d", code)
+	}
+}
+
+func TestQRCodeStaticAndScenarioRules(t *testing.T) {
+	var received []domain.QRPaymentWebhook
+	mux := newQRCodeMux(t, &scenario.Config{
+		QRCodes: []scenario.QRCodeRule{
+			{ReferenceID: "scan-me", Outcome: scenario.QRCodePay, PaidAmount: 7000},
+			{ReferenceID: "stale", Outcome: scenario.QRCodeExpire},
+		},
+	}, nil, &received)
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/qr_codes", `{"reference_id":"scan-me","type":"STATIC","currency":"IDR"}`)
+	var static domain.QRCode
+	_ = json.Unmarshal(body, &static)
+	if static.ExpiresAt != "" || !strings.HasPrefix(static.QRString, "000201010211") {
+		t.Fatalf("unexpected static qr code %s", body)
+	}
+	if len(received) != 1 || received[0].Data.Amount != 7000 {
+		t.Fatalf("expected scripted payment of 7000, got %+v", received)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/qr_codes/"+static.ID+"/payments/simulate", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 paying a static QR code without amount, got %d", code)
+	}
+	doRequest(t, mux, http.MethodPost, "/xendit/qr_codes/"+static.ID+"/payments/simulate", `{"amount":3000}`)
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/qr_codes/"+static.ID+"/payments", "")
+	var payments struct {
+		Data []domain.QRPayment `json:"data"`
+	}
+	_ = json.Unmarshal(body, &payments)
+	if len(payments.Data) != 2 {
+		t.Fatalf("expected static QR code to accept repeated payments, got %s", body)
+	}
+
+	_, body = doRequest(t, mux, http.MethodPost, "/xendit/qr_codes", `{"reference_id":"stale","type":"DYNAMIC","currency":"IDR","amount":1000}`)
+	var stale domain.QRCode
+	_ = json.Unmarshal(body, &stale)
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/qr_codes/"+stale.ID, "")
+	_ = json.Unmarshal(body, &stale)
+	if stale.Status != domain.QRCodeInactive {
+		t.Fatalf("expected scripted expiry, got %s", body)
+	}
+}
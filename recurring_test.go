@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/clock"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/recurring"
+	"xendit-api-mock/internal/snapshot"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+type recurringWebhook struct {
+	Event string `json:"event"`
+	Data  struct {
+		ID          string `json:"id"`
+		Status      string `json:"status"`
+		CycleNumber int    `json:"cycle_number"`
+	} `json:"data"`
+}
+
+func newRecurringMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received chan recurringWebhook) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload recurringWebhook
+		if json.NewDecoder(r.Body).Decode(&payload) == nil && payload.Event != "" {
+			received <- payload
+		}
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	cb := callback.NewClient(callbackSrv.URL, "", nil)
+	virtualClock := clock.New()
+	service := recurring.NewService(scenario.NewEngine(cfg), cb, virtualClock, "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewRecurringHandler(service).RegisterRoutes(mux)
+	httptransport.NewAdminHandler(snapshot.NewRegistry(), cb, "user_mock").WithClock(virtualClock).RegisterRoutes(mux)
+	return mux
+}
+
+func expectRecurringEvents(t *testing.T, received chan recurringWebhook, events ...string) []recurringWebhook {
+	t.Helper()
+	got := make([]recurringWebhook, 0, len(events))
+	for _, event := range events {
+		select {
+		case webhook := <-received:
+			if webhook.Event != event {
+				t.Fatalf("expected %s, got %s (after %+v)", event, webhook.Event, got)
+			}
+			got = append(got, webhook)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s, got only %+v", event, got)
+		}
+	}
+	return got
+}
+
+func TestRecurringPlanCyclesFollowTheClock(t *testing.T) {
+	received := make(chan recurringWebhook, 16)
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newRecurringMux(t, nil, ledger, received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/recurring/plans", `{"reference_id":"sub-1","customer_id":"cust-1","currency":"IDR","amount":99000,"payment_methods":[{"payment_method_id":"pm-1","rank":1}],"schedule":{"interval":"MONTH","interval_count":1,"total_recurrence":2}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", code, body)
+	}
+	var plan domain.RecurringPlan
+	_ = json.Unmarshal(body, &plan)
+	if plan.Status != domain.RecurringPlanActive {
+		t.Fatalf("expected an ACTIVE plan, got %s", body)
+	}
+
+	got := expectRecurringEvents(t, received, domain.RecurringPlanEventActivated, domain.RecurringCycleEventCreated,
+		domain.RecurringCycleEventSucceeded, domain.RecurringCycleEventCreated)
+	if got[3].Data.CycleNumber != 2 || got[3].Data.Status != domain.RecurringCycleScheduled {
+		t.Fatalf("expected cycle 2 to be scheduled, got %+v", got[3])
+	}
+
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/admin/clock/advance", `{"days":32}`); code != http.StatusOK {
+		t.Fatalf("expected 200 advancing the clock, got %d: %s", code, body)
+	}
+	expectRecurringEvents(t, received, domain.RecurringCycleEventSucceeded, domain.RecurringPlanEventInactivated)
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 198000 {
+		t.Fatalf("expected both cycles credited to CASH, got %d", got)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/recurring/plans/"+plan.ID+"/cycles", "")
+	var cycles struct {
+		Data []domain.RecurringCycle `json:"data"`
+	}
+	_ = json.Unmarshal(body, &cycles)
+	if len(cycles.Data) != 2 || cycles.Data[1].Status != domain.RecurringCycleSucceeded {
+		t.Fatalf("expected two succeeded cycles, got %s", body)
+	}
+}
+
+func TestRecurringScenarioRetriesAndFailures(t *testing.T) {
+	received := make(chan recurringWebhook, 16)
+	cfg := &scenario.Config{Recurring: []scenario.RecurringRule{
+		{ReferenceID: "sub-retry", FailedAttempts: 1},
+		{ReferenceID: "sub-fail", Outcome: scenario.RecurringFail, FailureCode: "INSUFFICIENT_BALANCE"},
+	}}
+	mux := newRecurringMux(t, cfg, balance.NewLedger(balance.Config{}), received)
+
+	doRequest(t, mux, http.MethodPost, "/xendit/recurring/plans", `{"reference_id":"sub-retry","customer_id":"cust-1","currency":"IDR","amount":50000,"payment_methods":[{"payment_method_id":"pm-1","rank":1}],"schedule":{"interval":"WEEK","total_recurrence":1,"total_retry":2}}`)
+	expectRecurringEvents(t, received, domain.RecurringPlanEventActivated, domain.RecurringCycleEventCreated, domain.RecurringCycleEventRetrying)
+	doRequest(t, mux, http.MethodPost, "/xendit/admin/clock/advance", `{"days":1}`)
+	expectRecurringEvents(t, received, domain.RecurringCycleEventSucceeded, domain.RecurringPlanEventInactivated)
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/recurring/plans", `{"reference_id":"sub-fail","customer_id":"cust-1","currency":"IDR","amount":50000,"payment_methods":[{"payment_method_id":"pm-1","rank":1}],"failed_cycle_action":"STOP","schedule":{"interval":"MONTH","total_retry":1}}`)
+	var plan domain.RecurringPlan
+	_ = json.Unmarshal(body, &plan)
+	expectRecurringEvents(t, received, domain.RecurringPlanEventActivated, domain.RecurringCycleEventCreated, domain.RecurringCycleEventRetrying)
+	doRequest(t, mux, http.MethodPost, "/xendit/admin/clock/advance", `{"duration":"25h"}`)
+	got := expectRecurringEvents(t, received, domain.RecurringCycleEventFailed, domain.RecurringPlanEventInactivated)
+	if got[0].Data.Status != domain.RecurringCycleFailed || got[1].Data.ID != plan.ID {
+		t.Fatalf("expected the cycle to fail and stop the plan, got %+v", got)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/recurring/plans/"+plan.ID+"/deactivate", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 deactivating an INACTIVE plan, got %d", code)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/invoice"
+	"xendit-api-mock/internal/service/refund"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newRefundMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received chan domain.RefundWebhook) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.RefundWebhook
+		if json.NewDecoder(r.Body).Decode(&payload) == nil && payload.Event != "" {
+			received <- payload
+		}
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	engine := scenario.NewEngine(cfg)
+	cb := callback.NewClient(callbackSrv.URL, "", nil)
+	invoiceService := invoice.NewService(engine, cb, "user_mock").WithLedger(ledger)
+	refundService := refund.NewService(engine, cb, "user_mock").WithInvoices(invoiceService).WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewInvoiceHandler(invoiceService).RegisterRoutes(mux)
+	httptransport.NewRefundHandler(refundService).RegisterRoutes(mux)
+	return mux
+}
+
+func paidInvoice(t *testing.T, mux *http.ServeMux, externalID string) string {
+	t.Helper()
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/v2/invoices", `{"external_id":"`+externalID+`","amount":50000}`)
+	var created domain.Invoice
+	_ = json.Unmarshal(body, &created)
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/admin/invoices/"+created.ID+"/pay", `{}`); code != http.StatusOK {
+		t.Fatalf("expected 200 paying invoice, got %d: %s", code, body)
+	}
+	return created.ID
+}
+
+func TestRefundPartialRefundsUpToPaidAmount(t *testing.T) {
+	received := make(chan domain.RefundWebhook, 4)
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newRefundMux(t, nil, ledger, received)
+	invoiceID := paidInvoice(t, mux, "order-1")
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"`+invoiceID+`","reference_id":"rf-1","amount":20000,"reason":"REQUESTED_BY_CUSTOMER"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", code, body)
+	}
+	var created domain.Refund
+	_ = json.Unmarshal(body, &created)
+	if created.Amount != 20000 || created.ChannelCode != "BCA" {
+		t.Fatalf("unexpected refund %s", body)
+	}
+	webhook := <-received
+	if webhook.Event != domain.RefundEventSucceeded || webhook.Data.ID != created.ID || webhook.Data.Status != domain.RefundStatusSucceeded {
+		t.Fatalf("expected refund.succeeded webhook, got %+v", webhook)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountHolding); got != 30000 {
+		t.Fatalf("expected the refund of an unsettled invoice debited from HOLDING, got %d", got)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"`+invoiceID+`","amount":40000,"reason":"OTHERS"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 refunding more than is left, got %d: %s", code, body)
+	}
+	var apiErr struct {
+		ErrorCode string `json:"error_code"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	if apiErr.ErrorCode != "MAXIMUM_REFUND_AMOUNT_REACHED" {
+		t.Fatalf("expected MAXIMUM_REFUND_AMOUNT_REACHED, got %s", body)
+	}
+
+	_, body = doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"`+invoiceID+`","reason":"OTHERS"}`)
+	var rest domain.Refund
+	_ = json.Unmarshal(body, &rest)
+	if rest.Amount != 30000 {
+		t.Fatalf("expected the remaining 30000 refunded, got %s", body)
+	}
+	<-received
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/refunds?invoice_id="+invoiceID, "")
+	var listed struct {
+		Data []domain.Refund `json:"data"`
+	}
+	_ = json.Unmarshal(body, &listed)
+	if len(listed.Data) != 2 {
+		t.Fatalf("expected both refunds listed, got %s", body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"inv_missing","reason":"OTHERS"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown invoice, got %d", code)
+	}
+}
+
+func TestRefundScenarioRules(t *testing.T) {
+	received := make(chan domain.RefundWebhook, 4)
+	cfg := &scenario.Config{Refunds: []scenario.RefundRule{
+		{ReferenceID: "rf-fail", Outcome: scenario.RefundFail, FailureCode: "INSUFFICIENT_BALANCE"},
+		{Outcome: scenario.RefundSucceed, DelayMS: 20},
+	}}
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newRefundMux(t, cfg, ledger, received)
+	invoiceID := paidInvoice(t, mux, "order-2")
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"`+invoiceID+`","reference_id":"rf-fail","reason":"DUPLICATE"}`)
+	var failed domain.Refund
+	_ = json.Unmarshal(body, &failed)
+	webhook := <-received
+	if webhook.Event != domain.RefundEventFailed || webhook.Data.FailureCode != "INSUFFICIENT_BALANCE" {
+		t.Fatalf("expected refund.failed webhook, got %+v", webhook)
+	}
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/refunds", `{"invoice_id":"`+invoiceID+`","reference_id":"rf-later","reason":"DUPLICATE"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected a failed refund not to count against the paid amount, got %d: %s", code, body)
+	}
+	var delayed domain.Refund
+	_ = json.Unmarshal(body, &delayed)
+	if delayed.Status != domain.RefundStatusPending || delayed.Amount != 50000 {
+		t.Fatalf("expected a PENDING full refund, got %s", body)
+	}
+	select {
+	case webhook = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("expected the delayed refund to settle")
+	}
+	if webhook.Event != domain.RefundEventSucceeded || webhook.Data.ID != delayed.ID {
+		t.Fatalf("expected refund.succeeded webhook, got %+v", webhook)
+	}
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/refunds/"+failed.ID, "")
+	var got domain.Refund
+	_ = json.Unmarshal(body, &got)
+	if got.Status != domain.RefundStatusFailed {
+		t.Fatalf("expected the failed refund to stay FAILED, got %s", body)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/retailoutlet"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newRetailOutletMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]domain.FixedPaymentCodePayment) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.FixedPaymentCodePayment
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := retailoutlet.NewService(scenario.NewEngine(cfg), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewRetailOutletHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestFixedPaymentCodeSimulatedPayment(t *testing.T) {
+	var received []domain.FixedPaymentCodePayment
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newRetailOutletMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/fixed_payment_code", `{"external_id":"cash-1","retail_outlet_name":"ALFAMART","name":"Budi","expected_amount":20000,"is_single_use":true}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+	var fpc domain.FixedPaymentCode
+	_ = json.Unmarshal(body, &fpc)
+	if fpc.Status != domain.FixedPaymentCodeActive || fpc.PaymentCode == "" {
+		t.Fatalf("unexpected payment code %s", body)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/fixed_payment_code", `{"external_id":"cash-1","retail_outlet_name":"ALFAMART","name":"Budi","expected_amount":20000}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a duplicate active payment code, got %d", code)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPatch, "/xendit/fixed_payment_code/"+fpc.ID, `{"expected_amount":25000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 updating, got %d: %s", code, body)
+	}
+
+	simulate := func(amount string) int {
+		code, _ := doRequest(t, mux, http.MethodPost, "/xendit/fixed_payment_code/simulate_payment", `{"retail_outlet_name":"ALFAMART","payment_code":"`+fpc.PaymentCode+`","transfer_amount":`+amount+`}`)
+		return code
+	}
+	if code := simulate("30000"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 paying more than expected, got %d", code)
+	}
+	if code := simulate("10000"); code != http.StatusOK {
+		t.Fatalf("expected 200 for a partial payment, got %d", code)
+	}
+	code, body = doRequest(t, mux, http.MethodPatch, "/xendit/fixed_payment_code/"+fpc.ID, `{"expected_amount":5000}`)
+	if code != http.StatusBadRequest || !strings.Contains(string(body), "API_VALIDATION_ERROR") {
+		t.Fatalf("expected 400 lowering expected_amount below what was paid, got %d: %s", code, body)
+	}
+	if code := simulate("15000"); code != http.StatusOK {
+		t.Fatalf("expected 200 paying the rest, got %d", code)
+	}
+	if len(received) != 2 || received[1].Amount != 15000 || received[1].FixedPaymentCodeID != fpc.ID || received[1].Status != "COMPLETED" {
+		t.Fatalf("expected two payment callbacks, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 25000 {
+		t.Fatalf("expected payments credited to CASH, got %d", got)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/fixed_payment_code/"+fpc.ID, "")
+	_ = json.Unmarshal(body, &fpc)
+	if fpc.Status != domain.FixedPaymentCodeInactive {
+		t.Fatalf("expected paid single-use code to be INACTIVE, got %s", body)
+	}
+	if code := simulate("1000"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 paying an inactive code, got %d", code)
+	}
+}
+
+func TestFixedPaymentCodeScenarioRules(t *testing.T) {
+	var received []domain.FixedPaymentCodePayment
+	mux := newRetailOutletMux(t, &scenario.Config{
+		RetailOutlets: []scenario.RetailOutletRule{
+			{ExternalID: "cash-partial", Outcome: scenario.RetailOutletPartial},
+			{ExternalID: "cash-expired", Outcome: scenario.RetailOutletExpire},
+		},
+	}, nil, &received)
+
+	doRequest(t, mux, http.MethodPost, "/xendit/fixed_payment_code", `{"external_id":"cash-partial","retail_outlet_name":"INDOMARET","name":"Budi","expected_amount":40000}`)
+	if len(received) != 1 || received[0].Amount != 20000 {
+		t.Fatalf("expected a partial payment of half the expected amount, got %+v", received)
+	}
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/fixed_payment_code", `{"external_id":"cash-expired","retail_outlet_name":"INDOMARET","name":"Budi","expected_amount":40000}`)
+	var fpc domain.FixedPaymentCode
+	_ = json.Unmarshal(body, &fpc)
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/fixed_payment_code/"+fpc.ID, "")
+	_ = json.Unmarshal(body, &fpc)
+	if fpc.Status != domain.FixedPaymentCodeInactive {
+		t.Fatalf("expected scripted expiry, got %s", body)
+	}
+}
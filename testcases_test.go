@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"xendit-api-mock/internal/callback"
 	"xendit-api-mock/internal/domain"
 	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/disbursement"
 )
 
 func TestPickStatusDefaultSequence(t *testing.T) {
@@ -85,6 +91,9 @@ func TestNormalizeStatus(t *testing.T) {
 	if got := domain.NormalizeStatus("FAILED"); got != "FAILED" {
 		t.Fatalf("expected FAILED, got %s", got)
 	}
+	if got := domain.NormalizeStatus("REVERSED"); got != "REVERSED" {
+		t.Fatalf("expected REVERSED, got %s", got)
+	}
 	if got := domain.NormalizeStatus("UNKNOWN"); got != "FAILED" {
 		t.Fatalf("expected FAILED for unknown, got %s", got)
 	}
@@ -155,3 +164,146 @@ func TestScenarioBatchPrecedence(t *testing.T) {
 		t.Fatalf("expected batch rule to take precedence, got %s", got)
 	}
 }
+
+func TestDecideCallbackBehavior(t *testing.T) {
+	engine := scenario.NewEngine(&scenario.Config{
+		Callback: &scenario.CallbackBehavior{Repeat: 2},
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "x1", Disbursements: []scenario.Rule{
+				{ExternalID: "ext-drop", Outcome: "success", Callback: &scenario.CallbackBehavior{Drop: true}},
+			}},
+			{AccountNumber: "x2", Disbursements: []scenario.Rule{{Outcome: "success"}}},
+		},
+	})
+
+	decision := engine.Decide(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-drop"})
+	if decision.Callback == nil || !decision.Callback.Drop {
+		t.Fatalf("expected rule callback behavior, got %+v", decision.Callback)
+	}
+	decision = engine.Decide(domain.DisbursementRequest{AccountNumber: "x2", ExternalID: "ext-2"})
+	if decision.Callback == nil || decision.Callback.Repeat != 2 {
+		t.Fatalf("expected scenario default callback behavior, got %+v", decision.Callback)
+	}
+}
+
+func TestCallbackChaosDuplicatesAndStaleStatus(t *testing.T) {
+	var received []domain.CallbackPayload
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callbackSrv.Close()
+
+	engine := scenario.NewEngine(&scenario.Config{
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "x1", Disbursements: []scenario.Rule{
+				{ExternalID: "ext-dup", Outcome: "success", Callback: &scenario.CallbackBehavior{Repeat: 3, WebhookID: scenario.WebhookIDNew, StaleStatus: "FAILED"}},
+				{ExternalID: "ext-drop", Outcome: "success", Callback: &scenario.CallbackBehavior{Drop: true}},
+			}},
+		},
+	})
+	service := disbursement.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock")
+
+	if _, err := service.Create(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-dup"}); err != nil {
+		t.Fatalf("expected callbacks to be delivered, got %v", err)
+	}
+	if len(received) != 4 {
+		t.Fatalf("expected 3 duplicates and 1 stale callback, got %d", len(received))
+	}
+	webhookIDs := map[string]bool{}
+	for _, payload := range received[:3] {
+		if payload.Status != "COMPLETED" {
+			t.Fatalf("expected COMPLETED duplicates, got %s", payload.Status)
+		}
+		webhookIDs[payload.WebhookID] = true
+	}
+	if len(webhookIDs) != 3 {
+		t.Fatalf("expected a new webhook id per duplicate, got %v", webhookIDs)
+	}
+	if received[3].Status != "FAILED" {
+		t.Fatalf("expected stale FAILED after final status, got %s", received[3].Status)
+	}
+
+	received = nil
+	if _, err := service.Create(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-drop"}); err != nil {
+		t.Fatalf("expected nil error for dropped callback, got %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("expected dropped callback, got %d", len(received))
+	}
+}
+
+func TestCallbackChaosTokenModes(t *testing.T) {
+	var tokens []string
+	var present []bool
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Header["X-Callback-Token"]
+		tokens = append(tokens, r.Header.Get("X-Callback-Token"))
+		present = append(present, ok)
+	}))
+	defer callbackSrv.Close()
+
+	engine := scenario.NewEngine(&scenario.Config{
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "x1", Disbursements: []scenario.Rule{
+				{ExternalID: "ext-missing", Outcome: "success", Callback: &scenario.CallbackBehavior{Token: "missing"}},
+				{ExternalID: "ext-wrong", Outcome: "success", Callback: &scenario.CallbackBehavior{Token: "wrong"}},
+			}},
+		},
+	})
+	service := disbursement.NewService(engine, callback.NewClient(callbackSrv.URL, "secret", nil), "user_mock")
+
+	_, _ = service.Create(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-missing"})
+	_, _ = service.Create(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-wrong"})
+
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 callbacks, got %d", len(tokens))
+	}
+	if present[0] {
+		t.Fatalf("expected no token header, got %q", tokens[0])
+	}
+	if tokens[1] == "" || tokens[1] == "secret" {
+		t.Fatalf("expected wrong token, got %q", tokens[1])
+	}
+}
+
+func TestSuccessThenReverseOutcome(t *testing.T) {
+	received := make(chan domain.CallbackPayload, 2)
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload domain.CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer callbackSrv.Close()
+
+	engine := scenario.NewEngine(&scenario.Config{
+		Accounts: []scenario.AccountScenario{
+			{AccountNumber: "x1", Disbursements: []scenario.Rule{{ExternalID: "ext-rev", Outcome: "success_then_reverse", ReverseAfterMS: 10}}},
+		},
+	})
+	service := disbursement.NewService(engine, callback.NewClient(callbackSrv.URL, "", nil), "user_mock")
+
+	resp, err := service.Create(domain.DisbursementRequest{AccountNumber: "x1", ExternalID: "ext-rev"})
+	if err != nil || resp.Status != "COMPLETED" {
+		t.Fatalf("expected COMPLETED create, got %s %v", resp.Status, err)
+	}
+	for _, want := range []string{"COMPLETED", "REVERSED"} {
+		select {
+		case payload := <-received:
+			if payload.Status != want {
+				t.Fatalf("expected %s callback, got %s", want, payload.Status)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s callback", want)
+		}
+	}
+
+	snapshot, _ := json.Marshal(service.Snapshot())
+	var state disbursement.State
+	_ = json.Unmarshal(snapshot, &state)
+	if got := state.Disbursements[resp.ID].Response.Status; got != "REVERSED" {
+		t.Fatalf("expected stored disbursement REVERSED, got %s", got)
+	}
+}
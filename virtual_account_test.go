@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xendit-api-mock/internal/balance"
+	"xendit-api-mock/internal/callback"
+	"xendit-api-mock/internal/domain"
+	"xendit-api-mock/internal/scenario"
+	"xendit-api-mock/internal/service/virtualaccount"
+	httptransport "xendit-api-mock/internal/transport/http"
+)
+
+func newVirtualAccountMux(t *testing.T, cfg *scenario.Config, ledger *balance.Ledger, received *[]map[string]any) *http.ServeMux {
+	t.Helper()
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*received = append(*received, payload)
+	}))
+	t.Cleanup(callbackSrv.Close)
+
+	service := virtualaccount.NewService(scenario.NewEngine(cfg), callback.NewClient(callbackSrv.URL, "", nil), "user_mock").WithLedger(ledger)
+	mux := http.NewServeMux()
+	httptransport.NewVirtualAccountHandler(service).RegisterRoutes(mux)
+	return mux
+}
+
+func TestVirtualAccountSimulatedPayment(t *testing.T) {
+	var received []map[string]any
+	ledger := balance.NewLedger(balance.Config{})
+	mux := newVirtualAccountMux(t, nil, ledger, &received)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts", `{"external_id":"va-1","bank_code":"BNI","name":"Budi"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+	var created domain.VirtualAccount
+	_ = json.Unmarshal(body, &created)
+	if created.Status != domain.VirtualAccountPending || created.MerchantCode != "8808" || created.AccountNumber[:4] != "8808" {
+		t.Fatalf("unexpected virtual account %+v", created)
+	}
+	if len(received) != 1 || received[0]["status"] != domain.VirtualAccountActive {
+		t.Fatalf("expected ACTIVE status callback, got %+v", received)
+	}
+
+	code, body = doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts/external_id=va-1/simulate_payment", `{"amount":25000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 simulating payment, got %d: %s", code, body)
+	}
+	if len(received) != 2 || received[1]["payment_id"] == nil || received[1]["amount"] != float64(25000) || received[1]["callback_virtual_account_id"] != created.ID {
+		t.Fatalf("expected payment callback, got %+v", received)
+	}
+	if got := ledger.Balance("user_mock", balance.AccountCash); got != 25000 {
+		t.Fatalf("expected payment credited to CASH, got %d", got)
+	}
+
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts/external_id=missing/simulate_payment", `{"amount":1000}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown external_id, got %d", code)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts", `{"external_id":"va-2","bank_code":"XYZ","name":"Budi"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported bank, got %d", code)
+	}
+}
+
+func TestVirtualAccountClosedSingleUse(t *testing.T) {
+	var received []map[string]any
+	mux := newVirtualAccountMux(t, nil, nil, &received)
+
+	_, body := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts", `{"external_id":"va-closed","bank_code":"BCA","name":"Budi","is_closed":true,"expected_amount":50000,"is_single_use":true}`)
+	var created domain.VirtualAccount
+	_ = json.Unmarshal(body, &created)
+
+	code, body := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts/external_id=va-closed/simulate_payment", `{"amount":1000}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for mismatched amount, got %d: %s", code, body)
+	}
+	if code, body := doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts/external_id=va-closed/simulate_payment", `{"amount":50000}`); code != http.StatusOK {
+		t.Fatalf("expected 200 paying the expected amount, got %d: %s", code, body)
+	}
+
+	_, body = doRequest(t, mux, http.MethodGet, "/xendit/callback_virtual_accounts/"+created.ID, "")
+	var fetched domain.VirtualAccount
+	_ = json.Unmarshal(body, &fetched)
+	if fetched.Status != domain.VirtualAccountInactive {
+		t.Fatalf("expected single-use VA to be INACTIVE after payment, got %+v", fetched)
+	}
+	if code, _ := doRequest(t, mux, http.MethodPatch, "/xendit/callback_virtual_accounts/"+created.ID, `{"name":"Other"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 updating an inactive VA, got %d", code)
+	}
+}
+
+func TestVirtualAccountScenarioRules(t *testing.T) {
+	var received []map[string]any
+	mux := newVirtualAccountMux(t, &scenario.Config{
+		VirtualAccounts: []scenario.VirtualAccountRule{
+			{ExternalID: "va-partial", Outcome: scenario.VirtualAccountPartial},
+			{ExternalID: "va-expire", Outcome: scenario.VirtualAccountExpire},
+		},
+	}, nil, &received)
+
+	doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts", `{"external_id":"va-partial","bank_code":"BRI","name":"Budi","suggested_amount":40000}`)
+	if len(received) != 2 || received[1]["amount"] != float64(20000) {
+		t.Fatalf("expected a partial payment of half the suggested amount, got %+v", received)
+	}
+
+	doRequest(t, mux, http.MethodPost, "/xendit/callback_virtual_accounts", `{"external_id":"va-expire","bank_code":"BRI","name":"Budi"}`)
+	if len(received) != 4 || received[3]["status"] != domain.VirtualAccountInactive {
+		t.Fatalf("expected an INACTIVE status callback, got %+v", received)
+	}
+}